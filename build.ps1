@@ -53,7 +53,7 @@ function Build-Binary {
     $env:GOARCH = $GOARCH
     
     try {
-        $output = & go build -o $outputName -ldflags="-s -w" main.go 2>&1
+        $output = & go build -o $outputName -ldflags="-s -w" . 2>&1
         
         if ($LASTEXITCODE -eq 0) {
             if (Test-Path $outputName) {
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"genesis/pkg/channels"
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+	"genesis/pkg/moderation"
+	"genesis/pkg/monitor"
+	"genesis/pkg/utils"
+	"os"
+	"strings"
+)
+
+// checkConfig validates the configuration files the way startup would,
+// creating LLM clients and checking channel settings without connecting to
+// any provider or platform. It prints a report of what would start and
+// returns the process exit code: 0 if everything is usable, 1 otherwise.
+func checkConfig(paths config.Paths) int {
+	// Factories log while creating clients; keep only what explains a failure
+	monitor.SetupSlog("warn")
+
+	problems := 0
+	report := func(err error, format string, args ...any) {
+		line := fmt.Sprintf(format, args...)
+		if err != nil {
+			problems++
+			fmt.Printf("  %s %s: %v\n", utils.IconError, line, strings.ReplaceAll(err.Error(), "\n", "; "))
+			return
+		}
+		fmt.Printf("  %s %s\n", utils.IconOK, line)
+	}
+
+	fmt.Println("Configuration files:")
+	cfg, _, err := config.Load(paths)
+	source := paths.Config
+	if _, statErr := os.Stat(paths.Config); os.IsNotExist(statErr) && err == nil {
+		source = "environment (" + paths.Config + " not found)"
+	}
+	report(err, "%s", source)
+	if err != nil {
+		fmt.Printf("\n%d problem(s) found.\n", problems)
+		return 1
+	}
+	sysCfg, err := config.ParseSystemConfig(paths.System)
+	if _, statErr := os.Stat(paths.System); os.IsNotExist(statErr) {
+		fmt.Printf("  %s %s: not found, using defaults\n", utils.IconWarn, paths.System)
+	} else {
+		report(err, "%s", paths.System)
+	}
+	if err != nil {
+		// Startup falls back to the defaults; check against those
+		sysCfg = config.DefaultSystemConfig()
+	}
+	utils.SetUseEmoji(sysCfg.UseEmoji)
+	report(sysCfg.Validate(), "system settings")
+
+	fmt.Println("LLM providers:")
+	groups, err := llm.CheckConfig(cfg.LLM, sysCfg)
+	for _, g := range groups {
+		report(g.Err, "%s: %d client(s) for %s", g.Type, g.Clients, strings.Join(g.Models, ", "))
+	}
+	if err != nil {
+		report(err, "llm")
+	}
+
+	fmt.Println("Channels:")
+	checks := channels.NewSource(cfg.Channels, nil, sysCfg).Check()
+	if len(checks) == 0 {
+		problems++
+		fmt.Printf("  %s no channels configured\n", utils.IconError)
+	}
+	for _, c := range checks {
+		if !c.Validated && c.Err == nil {
+			fmt.Printf("  %s %s: registered, settings not checked offline\n", utils.IconWarn, c.Name)
+			continue
+		}
+		report(c.Err, "%s", c.Name)
+	}
+
+	if sysCfg.Moderation.Enabled {
+		fmt.Println("Moderation:")
+		_, err := moderation.NewFromConfig(sysCfg.Moderation)
+		report(err, "provider %q", sysCfg.Moderation.Provider)
+	}
+
+	if problems > 0 {
+		fmt.Printf("\n%d problem(s) found.\n", problems)
+		return 1
+	}
+	fmt.Println("\nConfiguration is valid.")
+	return 0
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"genesis/pkg/agent"
 	"genesis/pkg/api"
@@ -12,32 +13,63 @@ import (
 	"genesis/pkg/handler"
 	"genesis/pkg/llm"
 	_ "genesis/pkg/llm/autoload" // Auto-register LLM Providers
+	"genesis/pkg/moderation"
 	"genesis/pkg/monitor"
 	"genesis/pkg/tools"
+	"genesis/pkg/tools/external"
+	"genesis/pkg/tools/httpremote"
 	ostools "genesis/pkg/tools/os" // Aliased to avoid conflict with "os"
+	"genesis/pkg/utils"
 	"log/slog"
+	"os"
 	"os/signal"
-	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
 	"syscall"
 	"time"
 )
 
 func main() {
+	paths := config.DefaultPaths()
+	flag.StringVar(&paths.Config, "config", paths.Config, "path to the application config (env "+config.EnvConfigPath+")")
+	flag.StringVar(&paths.System, "system-config", paths.System, "path to the system settings (env "+config.EnvSystemConfigPath+")")
+	checkOnly := flag.Bool("check-config", false, "validate the configuration, print what would start and exit")
+	docsDir := flag.String("export-config-docs", "", "write annotated example configs and JSON Schemas into `dir` and exit")
+	flag.Parse()
+	if *checkOnly {
+		os.Exit(checkConfig(paths))
+	}
+	if *docsDir != "" {
+		if err := config.ExportDocs(*docsDir); err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to export config docs:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Config examples and schemas written to", *docsDir)
+		return
+	}
+
 	// Create context listening for system signals
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
 	// Initial configuration load to get log level before loop
 	// This acts as a fallback or initial console setup.
-	_, sysCfg, err := config.Load()
+	cfg, sysCfg, err := config.Load(paths)
+	watchFiles := []string{paths.Config, paths.System}
 	if err == nil {
 		monitor.SetupEnvironment(sysCfg.LogLevel)
+		// Edits to the prompt file reload like config edits. A path changed later is
+		// still read on reload, but only watched after a process restart
+		if cfg.SystemPromptFile != "" {
+			watchFiles = append(watchFiles, cfg.SystemPromptFile)
+		}
 	}
 
-	reloadCh := config.WatchConfig(ctx, "config.json", "system.json")
+	reloadCh := config.WatchConfig(ctx, watchFiles...)
 
 	for {
-		err := runAgent(ctx, reloadCh)
+		err := runAgent(ctx, reloadCh, paths)
 
 		if err != nil {
 			slog.Error("System crashed or failed to load config", "error", err)
@@ -63,9 +95,9 @@ func main() {
 }
 
 // runAgent executes a single lifecycle of the agent
-func runAgent(ctx context.Context, reloadCh <-chan struct{}) error {
+func runAgent(ctx context.Context, reloadCh <-chan struct{}, paths config.Paths) error {
 	// --- 0. Load Configuration ---
-	cfg, sysCfg, err := config.Load()
+	cfg, sysCfg, err := config.Load(paths)
 	if err != nil {
 		monitor.PrintBanner()
 		monitor.SetupSlog("info")
@@ -74,12 +106,28 @@ func runAgent(ctx context.Context, reloadCh <-chan struct{}) error {
 
 	// --- 0a. Setup Environment (logger + monitor) ---
 	m := monitor.SetupEnvironment(sysCfg.LogLevel)
+	m.MuteChannels(sysCfg.CLIMonitorMuteChannels...)
+	if err := m.SetColors(sysCfg.CLIMonitorColor, sysCfg.CLIMonitorTheme); err != nil {
+		slog.Warn("Invalid CLI monitor theme", "error", err)
+	}
+	monitor.EnableLogSampling(sysCfg.LogSampleRate)
+	utils.SetUseEmoji(sysCfg.UseEmoji)
+	if err := sysCfg.Validate(); err != nil {
+		slog.Warn("Invalid system settings, run with --check-config for details", "error", err)
+	}
 	slog.Info("==========================================")
 
 	// --- 2. Core Services ---
 	// --- 2a. Session Management ---
-	sessionsDir := filepath.Join("data", "sessions")
-	sessionManager := llm.NewSessionManager(sessionsDir)
+	sessionManager := llm.NewSessionManager(sysCfg.DataPath())
+	sessionManager.SetEvictionPolicy(sysCfg.SessionMaxInMemory, time.Duration(sysCfg.SessionIdleTTLMs)*time.Millisecond)
+	sessionManager.SetBackupRetention(sysCfg.SessionBackups)
+	sessionManager.SetCompression(sysCfg.CompressSessions)
+	sessionManager.SetInlineImages(sysCfg.InlineImages)
+	janitorCtx, stopJanitor := context.WithCancel(ctx)
+	defer stopJanitor()
+	sessionManager.StartAttachmentJanitor(janitorCtx, time.Duration(sysCfg.AttachmentMaxAgeMs)*time.Millisecond)
+	sessionManager.StartEvictionSweeper(janitorCtx)
 
 	// --- 2b. LLM Client ---
 	client, err := llm.NewFromConfig(cfg.LLM, sysCfg)
@@ -89,43 +137,153 @@ func runAgent(ctx context.Context, reloadCh <-chan struct{}) error {
 
 	// --- 2c. Pre-build Components ---
 	chs := channels.NewSource(cfg.Channels, sessionManager, sysCfg).Load()
+	worker, err := ostools.NewOSWorker(ostools.Options{
+		WorkDirRoot:    sysCfg.OSToolWorkDirRoot,
+		Shell:          sysCfg.OSToolShell[runtime.GOOS],
+		OutputEncoding: sysCfg.OSToolOutputEncoding,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to init OS worker: %w", err)
+	}
+	osTool := tools.NewOSTool(worker)
+	osTool.SetReadOnly(sysCfg.OSToolReadOnly)
 	tls := []api.Tool{
-		tools.NewOSTool(ostools.NewOSWorker()),
+		osTool,
+		tools.NewSessionTool(sessionManager),
+	}
+	// Downloads write to disk, so they are unavailable in read-only mode
+	if !sysCfg.OSToolReadOnly {
+		tls = append(tls, tools.NewDownloadTool(tools.DownloadOptions{
+			Dir:         sysCfg.DownloadPath(),
+			MaxBytes:    sysCfg.DownloadMaxBytes,
+			AllowedMIME: sysCfg.DownloadAllowedMIME,
+			Timeout:     time.Duration(sysCfg.DownloadToolTimeoutMs) * time.Millisecond,
+		}))
+	}
+
+	if sysCfg.DBToolDSN != "" {
+		dbTool, err := tools.NewDBTool(tools.DBOptions{
+			Driver:      sysCfg.DBToolDriver,
+			DSN:         sysCfg.DBToolDSN,
+			MaxRows:     sysCfg.DBToolMaxRows,
+			AllowWrites: sysCfg.DBToolAllowWrites && !sysCfg.OSToolReadOnly,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to init database tool: %w", err)
+		}
+		defer dbTool.Close()
+		tls = append(tls, dbTool)
+	}
+
+	// External providers run until this agent instance stops
+	for _, ext := range sysCfg.ExternalTools {
+		provider, err := external.Start(ctx, external.Options{
+			Command: ext.Command,
+			Args:    ext.Args,
+			Env:     ext.Env,
+			Dir:     ext.Dir,
+			Timeout: time.Duration(ext.TimeoutMs) * time.Millisecond,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to init external tools: %w", err)
+		}
+		defer provider.Close()
+		extTools, err := provider.Tools(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to init external tools: %w", err)
+		}
+		for _, t := range extTools {
+			if slices.ContainsFunc(tls, func(other api.Tool) bool { return other.Name() == t.Name() }) {
+				slog.Warn("Skipping external tool with a duplicate name", "name", t.Name(), "command", ext.Command)
+				continue
+			}
+			tls = append(tls, t)
+		}
+		slog.Info("External tools registered", "command", ext.Command, "count", len(extTools))
+	}
+	for _, h := range sysCfg.HTTPTools {
+		httpTool, err := httpremote.New(httpremote.Options{
+			Name:        h.Name,
+			Description: h.Description,
+			URL:         h.URL,
+			Parameters:  h.Parameters,
+			Required:    h.Required,
+			Headers:     h.Headers,
+			Timeout:     time.Duration(h.TimeoutMs) * time.Millisecond,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to init http tools: %w", err)
+		}
+		if slices.ContainsFunc(tls, func(other api.Tool) bool { return other.Name() == h.Name }) {
+			slog.Warn("Skipping http tool with a duplicate name", "name", h.Name)
+			continue
+		}
+		tls = append(tls, httpTool)
 	}
 
 	// --- 2d. Tools, Engine & Handler ---
 	engine := agent.NewAgentEngine(client, cfg, sysCfg, sessionManager)
 	engine.RegisterTool(tls...)
+
+	moderator, err := moderation.NewFromConfig(sysCfg.Moderation)
+	if err != nil {
+		return fmt.Errorf("failed to init moderation: %w", err)
+	}
+	if moderator != nil {
+		engine.SetModerator(moderator)
+	}
 	h := handler.NewChatHandler(engine, sessionManager)
 
 	// --- 3. Gateway Initialization ---
-	gw, err := gateway.NewGatewayBuilder().
+	builder := gateway.NewGatewayBuilder().
 		WithSystemConfig(sysCfg).
 		WithMonitor(m).
 		WithChannel(chs...).
 		WithAgentEngine(engine).
-		WithHandler(h).
-		Build()
+		WithHandler(h)
+	var webMonitor *monitor.WebMonitor
+	if sysCfg.WebMonitorPort > 0 {
+		webMonitor = monitor.NewWebMonitor(sysCfg.WebMonitorHost, sysCfg.WebMonitorPort, sysCfg.WebMonitorToken)
+		builder.WithMonitor(webMonitor)
+	}
+	if sysCfg.MonitorLogDir != "" {
+		builder.WithMonitor(monitor.NewJSONLMonitor(sysCfg.MonitorLogDir, sysCfg.MonitorLogRotation))
+	}
+	gw, err := builder.Build()
 
 	if err != nil {
 		return fmt.Errorf("failed to build gateway: %w", err)
 	}
+	if webMonitor != nil {
+		webMonitor.SetHealthSource(func() (any, bool) { return gw.HealthReport() })
+	}
 
 	// Wait for shutdown signal or reload signal
-	select {
-	case <-ctx.Done():
-		slog.Info("Received shutdown signal. Stopping services...")
-		gw.StopAll()
-		slog.Info("Bye!")
-		return nil
-	case <-reloadCh:
-		slog.Info("Configuration changes detected, stopping services...")
-		gw.StopAll()
-
-		slog.Info("Draining connections before restart...")
-		time.Sleep(1 * time.Second)
-
-		// Let runAgent return nil to trigger outer loop restart
-		return nil
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Received shutdown signal. Stopping services...")
+			gw.StopAll()
+			slog.Info("Bye!")
+			return nil
+		case <-reloadCh:
+			// A log level change alone is applied in place, without a disruptive restart
+			if newCfg, newSysCfg, err := config.Load(paths); err == nil &&
+				reflect.DeepEqual(cfg, newCfg) && sysCfg.OnlyLogLevelDiffers(newSysCfg) {
+				monitor.SetLogLevel(newSysCfg.LogLevel)
+				sysCfg = newSysCfg
+				slog.Info("Log level updated", "level", newSysCfg.LogLevel)
+				continue
+			}
+
+			slog.Info("Configuration changes detected, stopping services...")
+			gw.StopAll()
+
+			slog.Info("Draining connections before restart...")
+			time.Sleep(1 * time.Second)
+
+			// Let runAgent return nil to trigger outer loop restart
+			return nil
+		}
 	}
 }
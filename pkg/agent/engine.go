@@ -2,14 +2,17 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"genesis/pkg/api"
 	"genesis/pkg/config"
 	"genesis/pkg/llm"
+	"genesis/pkg/monitor"
 	"genesis/pkg/tools"
 	"genesis/pkg/utils"
 	"log/slog"
 	"maps"
+	"regexp"
 	"strings"
 	"time"
 
@@ -18,6 +21,10 @@ import (
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
+// stopReasonEmpty labels a response that ended normally but produced no
+// content, so it can be listed in SystemConfig.RetryStopReasons.
+const stopReasonEmpty = "empty"
+
 // AgentEngine manages the core reasoning loop, including LLM communication,
 // tool execution, and recursive turn handling.
 // It implements api.AgentEngine.
@@ -28,6 +35,10 @@ type AgentEngine struct {
 	appCfg       *config.Config
 	toolRegistry api.ToolRegistry
 	sessions     *llm.SessionManager
+	moderator    api.Moderator
+	toolCache    *tools.ToolCache
+	// noToolsPatterns are the compiled SystemConfig.NoToolsPatterns
+	noToolsPatterns []*regexp.Regexp
 }
 
 // NewAgentEngine initializes a new AgentEngine with config managers.
@@ -37,12 +48,24 @@ func NewAgentEngine(
 	sysCfg *config.SystemConfig,
 	sessions *llm.SessionManager,
 ) *AgentEngine {
-	return &AgentEngine{
+	e := &AgentEngine{
 		client:   client,
 		appCfg:   appCfg,
 		sysCfg:   sysCfg,
 		sessions: sessions,
 	}
+	if sysCfg.ToolCacheTTLMs > 0 {
+		e.toolCache = tools.NewToolCache(time.Duration(sysCfg.ToolCacheTTLMs) * time.Millisecond)
+	}
+	for _, pattern := range sysCfg.NoToolsPatterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			slog.Warn("Ignoring invalid no-tools pattern", "pattern", pattern, "error", err)
+			continue
+		}
+		e.noToolsPatterns = append(e.noToolsPatterns, re)
+	}
+	return e
 }
 
 // SetResponder sets the messaging interface used by the engine to send replies.
@@ -55,6 +78,11 @@ func (e *AgentEngine) SetToolRegistry(tr api.ToolRegistry) {
 	e.toolRegistry = tr
 }
 
+// SetModerator sets the optional content moderation hook. A nil moderator disables moderation.
+func (e *AgentEngine) SetModerator(m api.Moderator) {
+	e.moderator = m
+}
+
 // RegisterTool adds one or more tools to the engine's registry.
 // It automatically initializes the registry if it's currently nil.
 func (e *AgentEngine) RegisterTool(tl ...api.Tool) {
@@ -66,11 +94,41 @@ func (e *AgentEngine) RegisterTool(tl ...api.Tool) {
 	}
 }
 
+// sessionIDOf returns the ID of the session a history is saved under. A chat
+// may have switched to another of the user's sessions, so the history's own
+// ID wins over the chat's default one.
+func sessionIDOf(session api.SessionContext, history *llm.ChatHistory) string {
+	if id := history.GetSessionID(); id != "" {
+		return id
+	}
+	return fmt.Sprintf("%s_%s", session.ChannelID, session.ChatID)
+}
+
+// refusalMessage returns the reply for content blocked by moderation.
+func (e *AgentEngine) refusalMessage() string {
+	if msg := e.sysCfg.Moderation.RefusalMessage; msg != "" {
+		return msg
+	}
+	return utils.IconWarn.String() + " Sorry, I can't help with that request."
+}
+
 // HandleMessage is the primary entry point for processing an user message in the engine.
 func (e *AgentEngine) HandleMessage(ctx context.Context, msg *api.UnifiedMessage, history *llm.ChatHistory) llm.Message {
-	sessionID := fmt.Sprintf("%s_%s", msg.Session.ChannelID, msg.Session.ChatID)
+	sessionID := sessionIDOf(msg.Session, history)
+
+	ctx = e.withDebugID(ctx, msg)
 
-	e.ensureSystemPrompt(history)
+	e.ensureSystemPrompt(history, msg.Session, e.toolsAvailable())
+
+	// Commands are checked too: /notools sends its text to the model and
+	// /pin keeps it in every later prompt
+	if e.moderator != nil && e.sysCfg.Moderation.CheckInput && msg.Content != "" {
+		if allowed, reason := e.moderator.Check(ctx, msg.Content); !allowed {
+			slog.WarnContext(ctx, "User input blocked by moderation", "reason", reason)
+			e.responder.SendReply(msg.Session, e.refusalMessage())
+			return llm.Message{}
+		}
+	}
 
 	if strings.HasPrefix(msg.Content, "/") {
 		return e.handleSlashCommand(ctx, msg, history, sessionID)
@@ -84,7 +142,12 @@ func (e *AgentEngine) HandleMessage(ctx context.Context, msg *api.UnifiedMessage
 	}
 
 	if msg.Content != "" {
-		userMsg.Content = append(userMsg.Content, llm.NewTextBlock(msg.Content))
+		userMsg.Content = append(userMsg.Content, llm.NewTextBlock(e.senderPrefix(msg.Session)+msg.Content))
+	}
+
+	if len(msg.Files) > 0 && !llm.CapabilitiesOf(e.client).Vision {
+		slog.WarnContext(ctx, "Model does not support images", "provider", e.client.Provider(), "files", len(msg.Files))
+		e.responder.SendReply(msg.Session, utils.IconWarn.String()+" The current model cannot view images, so attachments will not be seen.")
 	}
 
 	for _, file := range msg.Files {
@@ -104,17 +167,79 @@ func (e *AgentEngine) HandleMessage(ctx context.Context, msg *api.UnifiedMessage
 
 	if len(assistantMsg.Content) > 0 {
 		history.Add(assistantMsg)
-		e.sessions.SaveSession(sessionID)
 	}
+	e.elideThinking(history, userMsg.ID)
+	e.sessions.SaveSession(sessionID)
 
-	e.maybeSummarize(ctx, sessionID, history, assistantMsg.Usage)
+	e.maybeSummarize(ctx, msg.Session, sessionID, history, assistantMsg.Usage)
 	return assistantMsg
 }
 
+// elideThinking applies PersistThinking to the messages of a completed turn,
+// starting at fromID. Reasoning stays intact while the turn runs, since tool
+// call rounds replay it to the model.
+func (e *AgentEngine) elideThinking(history *llm.ChatHistory, fromID string) {
+	switch e.sysCfg.PersistThinking {
+	case "truncate":
+		history.ElideThinking(fromID, e.sysCfg.ThinkingHistoryMaxChars)
+	case "none":
+		history.ElideThinking(fromID, 0)
+	}
+}
+
+// withDebugID makes sure the request carries a DebugID and that ctx holds it
+// under llm.DebugDirContextKey. The gateway-assigned trace ID is reused when
+// present; otherwise a new one is generated so engine-path logs and debug
+// chunks are still grouped per request.
+func (e *AgentEngine) withDebugID(ctx context.Context, msg *api.UnifiedMessage) context.Context {
+	if msg.DebugID == "" {
+		msg.DebugID = llm.DebugIDFromContext(ctx)
+	}
+	if msg.DebugID == "" {
+		msg.DebugID = utils.GenerateID()
+	}
+	if llm.DebugIDFromContext(ctx) == "" {
+		ctx = llm.WithDebugID(ctx, msg.DebugID)
+	}
+	return ctx
+}
+
+// noToolsNote is appended to SystemPrompt when tools are unavailable and no
+// SystemPromptNoTools variant is configured.
+const noToolsNote = "Tools are disabled for this conversation. Do not attempt any tool or function calls; answer directly."
+
+// groupChatNote is appended to the system prompt for group chats in GroupMode.
+const groupChatNote = "This is a group chat with several participants. Each user message starts with the sender's name in brackets, e.g. \"[Alice]: hello\". Keep track of who said what, address people by name when replying to someone in particular, and do not start your own replies with a name tag."
+
 // ensureSystemPrompt ensures that the initial system prompt is present
-// in the ChatHistory. It dynamically injects latest conversation summaries to maintain contextual continuity.
-func (e *AgentEngine) ensureSystemPrompt(history *llm.ChatHistory) {
+// in the ChatHistory. It is rebuilt on every request: the prompt variant
+// follows the effective tool availability, template variables are rendered
+// for the requesting session, and extracted user facts and the latest
+// conversation summary are injected to maintain contextual continuity.
+func (e *AgentEngine) ensureSystemPrompt(history *llm.ChatHistory, session api.SessionContext, toolsEnabled bool) {
 	prompt := e.appCfg.SystemPrompt
+	if !toolsEnabled {
+		if e.appCfg.SystemPromptNoTools != "" {
+			prompt = e.appCfg.SystemPromptNoTools
+		} else if prompt != "" {
+			prompt = fmt.Sprintf("%s\n\n%s", prompt, noToolsNote)
+		}
+	}
+	// Only the configured prompt is a template; facts and summaries are user-derived text
+	prompt = renderPrompt(prompt, session)
+
+	if e.groupMode(session) {
+		prompt = strings.TrimLeft(fmt.Sprintf("%s\n\n[GROUP CHAT]\n%s\nThe latest message is from %s.", prompt, groupChatNote, senderName(session)), "\n")
+	}
+
+	// Inject user facts first; unlike the prose summary they never drift
+	if facts := history.GetFacts(); len(facts) > 0 {
+		var sb strings.Builder
+		for _, f := range facts {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", f.Name, f.Value))
+		}
+		prompt = fmt.Sprintf("%s\n\n[USER FACTS]\n%s", prompt, strings.TrimRight(sb.String(), "\n"))
+	}
 
 	// Inject summary if available
 	if summary := history.GetSummary(); summary != "" {
@@ -128,9 +253,24 @@ func (e *AgentEngine) ensureSystemPrompt(history *llm.ChatHistory) {
 
 // handleSlashCommand parses and executes manual "slash" commands entered by the user.
 func (e *AgentEngine) handleSlashCommand(ctx context.Context, msg *api.UnifiedMessage, history *llm.ChatHistory, sessionID string) llm.Message {
+	switch cmd, arg, _ := strings.Cut(strings.TrimPrefix(msg.Content, "/"), " "); cmd {
+	case "pin":
+		e.handlePinCommand(msg, history, sessionID, strings.TrimSpace(arg))
+		return llm.Message{}
+	case "model":
+		e.handleModelCommand(msg)
+		return llm.Message{}
+	case "search":
+		e.handleSearchCommand(msg, strings.TrimSpace(arg))
+		return llm.Message{}
+	}
+
 	parts := strings.SplitN(strings.TrimPrefix(msg.Content, "/"), " ", 3)
+	if len(parts) < 2 && e.sendToolMenu(msg, parts[0]) {
+		return llm.Message{}
+	}
 	if len(parts) < 2 {
-		e.responder.SendReply(msg.Session, "❌ Format error. Please use: /[tool_name] [action] [JSON_params(optional)]\nExample: `/os list_desktop` or `/os run_command {\"command\":\"dir\"}`")
+		e.responder.SendReply(msg.Session, utils.IconError.String()+" Format error. Please use: /[tool_name] [action] [JSON_params(optional)]\nExample: `/os list_desktop` or `/os run_command {\"command\":\"dir\"}`")
 		return llm.Message{}
 	}
 
@@ -143,22 +283,30 @@ func (e *AgentEngine) handleSlashCommand(ctx context.Context, msg *api.UnifiedMe
 		if len(parts) > 2 {
 			msg.Content += " " + parts[2]
 		}
+		// Switch to the no-tools prompt for this request; the next message restores it
+		e.ensureSystemPrompt(history, msg.Session, false)
 
 		assistantMsg := e.ProcessLLMStream(ctx, msg, history)
 		if len(assistantMsg.Content) > 0 {
 			history.Add(assistantMsg)
+			e.elideThinking(history, assistantMsg.ID)
 			e.sessions.SaveSession(sessionID)
 		}
 		return assistantMsg
 	}
 
+	if toolName == "loglevel" {
+		e.handleLogLevelCommand(msg, action)
+		return llm.Message{}
+	}
+
 	var params map[string]any
 	if len(parts) > 2 {
 		if err := json.Unmarshal([]byte(parts[2]), &params); err != nil {
 			if (toolName == "os" || toolName == "os_control") && action == "run_command" {
 				params = map[string]any{"command": parts[2]}
 			} else {
-				e.responder.SendReply(msg.Session, fmt.Sprintf("❌ Parameter parsing failed: %v", err))
+				e.responder.SendReply(msg.Session, fmt.Sprintf("%s Parameter parsing failed: %v", utils.IconError, err))
 				return llm.Message{}
 			}
 		}
@@ -174,16 +322,16 @@ func (e *AgentEngine) handleSlashCommand(ctx context.Context, msg *api.UnifiedMe
 	if !ok {
 		tool, ok = e.toolRegistry.Get(toolName + "_control")
 		if !ok {
-			e.responder.SendReply(msg.Session, fmt.Sprintf("❌ Tool not found: %s", toolName))
+			e.responder.SendReply(msg.Session, fmt.Sprintf("%s Tool not found: %s", utils.IconError, toolName))
 			return llm.Message{}
 		}
 	}
 
-	e.responder.SendReply(msg.Session, fmt.Sprintf("🛠️ Manually executing tool: %s/%s...", toolName, action))
+	e.responder.SendReply(msg.Session, fmt.Sprintf("%s Manually executing tool: %s/%s...", utils.IconTool, toolName, action))
 
-	res, err := tool.Execute(ctx, args)
+	res, err := e.executeTool(ctx, msg.Session, tool, args)
 	if err != nil {
-		e.responder.SendReply(msg.Session, fmt.Sprintf("❌ Execution error: %v", err))
+		e.responder.SendReply(msg.Session, fmt.Sprintf("%s Execution error: %v", utils.IconError, err))
 		return llm.Message{}
 	}
 
@@ -198,8 +346,226 @@ func (e *AgentEngine) handleSlashCommand(ctx context.Context, msg *api.UnifiedMe
 	}
 }
 
+// toolsAvailable reports whether tools are enabled and the model can use them.
+func (e *AgentEngine) toolsAvailable() bool {
+	return e.sysCfg.EnableTools && llm.SupportsTools(e.client)
+}
+
+// senderPrefix returns the "[name]: " tag put in front of a user message
+// according to SenderNames, or "" when the sender is not named. In
+// GroupMode every message of a group chat is tagged.
+func (e *AgentEngine) senderPrefix(session api.SessionContext) string {
+	switch {
+	case e.groupMode(session), e.sysCfg.SenderNames == "always":
+	case e.sysCfg.SenderNames == "group" && session.IsGroup:
+	default:
+		return ""
+	}
+
+	if name := senderName(session); name != "" {
+		return "[" + name + "]: "
+	}
+	return ""
+}
+
+// groupMode reports whether the session is handled as a multi-party chat.
+func (e *AgentEngine) groupMode(session api.SessionContext) bool {
+	return e.sysCfg.GroupMode && session.IsGroup
+}
+
+// senderName returns the display name of the session's user, falling back
+// to the user ID.
+func senderName(session api.SessionContext) string {
+	if session.Username != "" {
+		return session.Username
+	}
+	return session.UserID
+}
+
+// isConversational reports whether content matches one of the configured
+// no-tools patterns.
+func (e *AgentEngine) isConversational(content string) bool {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return false
+	}
+	for _, re := range e.noToolsPatterns {
+		if re.MatchString(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleModelCommand reports the active model(s) and their capabilities.
+// For a fallback chain every model is listed in priority order.
+func (e *AgentEngine) handleModelCommand(msg *api.UnifiedMessage) {
+	clients := []llm.LLMClient{e.client}
+	if fc, ok := unwrapFallback(e.client); ok {
+		clients = fc.Clients
+	}
+
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(utils.IconInfo.String() + " Models:")
+	for i, c := range clients {
+		caps := llm.CapabilitiesOf(c)
+		window := "unknown"
+		if caps.MaxContext > 0 {
+			window = fmt.Sprintf("%d tokens", caps.MaxContext)
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. %s/%s (vision: %s, tools: %s, reasoning: %s, context: %s)",
+			i+1, c.Provider(), caps.Model, yesNo(caps.Vision), yesNo(caps.Tools), yesNo(caps.Reasoning), window))
+	}
+	e.responder.SendReply(msg.Session, sb.String())
+}
+
+// searchResultLimit caps the matches listed by /search.
+const searchResultLimit = 10
+
+// handleSearchCommand lists past messages of the user matching a query
+// ("/search invoice march"), across all of their sessions.
+func (e *AgentEngine) handleSearchCommand(msg *api.UnifiedMessage, query string) {
+	if query == "" {
+		e.responder.SendReply(msg.Session, utils.IconError.String()+" Usage: /search [words]")
+		return
+	}
+
+	results, err := e.sessions.Search(msg.Session.UserKey(), query, searchResultLimit)
+	if err != nil {
+		slog.Error("Session search failed", "error", err)
+		e.responder.SendReply(msg.Session, utils.IconError.String()+" Search failed.")
+		return
+	}
+	if len(results) == 0 {
+		e.responder.SendReply(msg.Session, utils.IconInfo.String()+" No messages found.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(utils.IconInfo.String() + " Search results:")
+	for i, r := range results {
+		sb.WriteString(fmt.Sprintf("\n%d. [%s] %s (%s): %s", i+1, r.Time.Format(time.DateTime), r.SessionID, r.Role, r.Snippet))
+	}
+	e.responder.SendReply(msg.Session, sb.String())
+}
+
+// sendToolMenu offers the actions of the named tool as quick-reply choices
+// when the user sends a bare "/tool". It reports false if the tool is
+// unknown or does not declare its actions.
+func (e *AgentEngine) sendToolMenu(msg *api.UnifiedMessage, toolName string) bool {
+	tool, ok := e.toolRegistry.Get(toolName)
+	if !ok {
+		if tool, ok = e.toolRegistry.Get(toolName + "_control"); !ok {
+			return false
+		}
+	}
+
+	names := toolActions(tool)
+	if len(names) == 0 {
+		return false
+	}
+	actions := make([]api.Action, len(names))
+	for i, name := range names {
+		actions[i] = api.Action{Label: name, Value: "/" + toolName + " " + name}
+	}
+	e.responder.SendWithActions(msg.Session, fmt.Sprintf("%s Choose an action for %s:", utils.IconTool, toolName), actions)
+	return true
+}
+
+// toolActions returns the values of the "action" parameter's enum, if any.
+func toolActions(tool llm.Tool) []string {
+	param, _ := tool.Parameters()["action"].(map[string]any)
+	switch enum := param["enum"].(type) {
+	case []string:
+		return enum
+	case []any:
+		names := make([]string, 0, len(enum))
+		for _, v := range enum {
+			if name, ok := v.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// unwrapFallback finds a FallbackClient behind any decorators.
+func unwrapFallback(client llm.LLMClient) (*llm.FallbackClient, bool) {
+	for client != nil {
+		if fc, ok := client.(*llm.FallbackClient); ok {
+			return fc, true
+		}
+		u, ok := client.(interface{ Unwrap() llm.LLMClient })
+		if !ok {
+			break
+		}
+		client = u.Unwrap()
+	}
+	return nil, false
+}
+
+// handlePinCommand pins a message so it survives summarization and truncation.
+// "/pin <text>" stores the text as a new pinned note; a bare "/pin" pins the
+// user's most recent message. Beyond HistoryMaxPinned the oldest pin is
+// released.
+func (e *AgentEngine) handlePinCommand(msg *api.UnifiedMessage, history *llm.ChatHistory, sessionID, text string) {
+	if text != "" {
+		history.Add(llm.Message{
+			ID:        utils.GenerateID(),
+			Role:      "user",
+			Content:   []llm.ContentBlock{llm.NewTextBlock(text)},
+			Timestamp: time.Now().Unix(),
+			Pinned:    true,
+		})
+	} else if _, ok := history.PinLast("user"); !ok {
+		e.responder.SendReply(msg.Session, utils.IconError.String()+" Nothing to pin. Usage: /pin [text]")
+		return
+	}
+
+	reply := utils.IconOK.String() + " Pinned. This message will be kept even when the conversation is summarized."
+	if history.LimitPinned(e.sysCfg.HistoryMaxPinned) > 0 {
+		reply += fmt.Sprintf(" The oldest pinned message was unpinned to stay within the limit of %d.", e.sysCfg.HistoryMaxPinned)
+	}
+
+	e.sessions.SaveSession(sessionID)
+	e.responder.SendReply(msg.Session, reply)
+}
+
+// handleLogLevelCommand temporarily changes the runtime log level
+// ("/loglevel debug") or reverts it early ("/loglevel reset"). Admin only.
+func (e *AgentEngine) handleLogLevelCommand(msg *api.UnifiedMessage, level string) {
+	if !e.sysCfg.IsAdmin(msg.Session.UserKey()) {
+		e.responder.SendReply(msg.Session, utils.IconError.String()+" Permission denied: /loglevel is restricted to admins.")
+		return
+	}
+
+	switch strings.ToLower(level) {
+	case "reset", "revert":
+		if monitor.RevertLogLevel() {
+			e.responder.SendReply(msg.Session, utils.IconOK.String()+" Log level reverted to the configured value.")
+		} else {
+			e.responder.SendReply(msg.Session, utils.IconInfo.String()+" No temporary log level is active.")
+		}
+	case "debug", "info", "warn", "warning", "error":
+		d := time.Duration(e.sysCfg.LogLevelOverrideMs) * time.Millisecond
+		monitor.OverrideLogLevel(level, d)
+		slog.Warn("Log level temporarily overridden", "level", level, "duration", d, "user", msg.Session.Username)
+		e.responder.SendReply(msg.Session, fmt.Sprintf("%s Log level set to %s for %s.", utils.IconOK, level, d))
+	default:
+		e.responder.SendReply(msg.Session, utils.IconError.String()+" Usage: /loglevel [debug|info|warn|error|reset]")
+	}
+}
+
 // maybeSummarize triggers an asynchronous summarization if history is too long.
-func (e *AgentEngine) maybeSummarize(ctx context.Context, sessionID string, history *llm.ChatHistory, usage *llm.LLMUsage) {
+func (e *AgentEngine) maybeSummarize(ctx context.Context, session api.SessionContext, sessionID string, history *llm.ChatHistory, usage *llm.LLMUsage) {
 	sysCfg := e.sysCfg
 	threshold := sysCfg.HistorySummarizeThreshold
 	maxChars := sysCfg.HistoryMaxChars
@@ -207,7 +573,13 @@ func (e *AgentEngine) maybeSummarize(ctx context.Context, sessionID string, hist
 	keepCount := sysCfg.HistoryKeepRecentCount
 
 	msgs := history.GetMessages()
-	msgCount := len(msgs)
+	// Pinned messages are never summarized away, so they do not count toward the window
+	msgCount := 0
+	for _, m := range msgs {
+		if !m.Pinned {
+			msgCount++
+		}
+	}
 
 	if msgCount <= keepCount {
 		return
@@ -224,7 +596,7 @@ func (e *AgentEngine) maybeSummarize(ctx context.Context, sessionID string, hist
 	if !overTokens {
 		for _, m := range msgs {
 			for _, b := range m.Content {
-				if b.Type == llm.BlockTypeText {
+				if b.Type == llm.BlockTypeText || b.Type == llm.BlockTypeTable {
 					totalChars += len(b.Text)
 				}
 			}
@@ -240,6 +612,20 @@ func (e *AgentEngine) maybeSummarize(ctx context.Context, sessionID string, hist
 
 	slog.InfoContext(ctx, "Triggering sliding window summarization", "session", sessionID)
 
+	// Let the channel show a transient status so the pause is not mistaken for a hang
+	if sysCfg.ShowSummarizingStatus {
+		e.responder.SendSignal(session, "summarizing:start")
+		defer e.responder.SendSignal(session, "summarizing:end")
+	}
+
+	// Facts are extracted from the same segment before it is truncated away.
+	// A failure here is not fatal; the prose summary still captures the content.
+	if sysCfg.ExtractFacts {
+		if err := e.extractFacts(ctx, history); err != nil {
+			slog.WarnContext(ctx, "Failed to extract facts", "session", sessionID, "error", err)
+		}
+	}
+
 	summary, err := e.summarizeSession(ctx, history)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to summarize session", "session", sessionID, "error", err)
@@ -247,8 +633,9 @@ func (e *AgentEngine) maybeSummarize(ctx context.Context, sessionID string, hist
 	}
 
 	history.SetSummary(summary)
-	history.TruncateHistory(e.sysCfg.HistoryKeepRecentCount)
+	orphaned := history.TruncateHistory(e.sysCfg.HistoryKeepRecentCount)
 	e.sessions.SaveSession(sessionID)
+	e.sessions.RemoveAttachments(orphaned)
 	slog.InfoContext(ctx, "Session summarized successfully", "session", sessionID)
 }
 
@@ -265,16 +652,87 @@ func (e *AgentEngine) summarizeSession(ctx context.Context, history *llm.ChatHis
 		existing = "(目前尚無摘要)"
 	}
 
-	sysCfg := e.sysCfg
-	keepCount := sysCfg.HistoryKeepRecentCount
-	if len(msgs) <= keepCount+1 {
+	transcript := e.summaryTranscript(msgs)
+	if transcript == "" {
 		return existing, nil
 	}
 
+	summarizerMsgs := []llm.Message{
+		llm.NewSystemMessage(summaryPrompt),
+		{
+			Role: "user",
+			Content: []llm.ContentBlock{
+				llm.NewTextBlock(fmt.Sprintf("【之前的摘要】：\n%s\n\n【新發生的需要被總結的片段】：\n%s\n\n請提供產出整合後的最新摘要：", existing, transcript)),
+			},
+		},
+	}
+
+	return e.completeText(ctx, summarizerMsgs)
+}
+
+// extractFacts asks the LLM for durable user facts in the segment about to be
+// summarized and merges them into the history's fact list.
+func (e *AgentEngine) extractFacts(ctx context.Context, history *llm.ChatHistory) error {
+	transcript := e.summaryTranscript(history.GetMessages())
+	if transcript == "" {
+		return nil
+	}
+
+	factsPrompt := "你是一個資訊擷取助手。請從對話片段中擷取關於用戶的長期事實與偏好（例如姓名、語言、所在地、喜好）。\n" +
+		"請僅輸出 JSON 陣列，格式為 [{\"name\": \"...\", \"value\": \"...\"}]，不要有任何其他文字。\n" +
+		"若某項已知事實已被用戶更正，請輸出新的值；若已不再成立，請輸出空字串作為 value。若沒有新的事實，請輸出 []。"
+
+	known, err := json.Marshal(history.GetFacts())
+	if err != nil {
+		return err
+	}
+
+	extractorMsgs := []llm.Message{
+		llm.NewSystemMessage(factsPrompt),
+		{
+			Role: "user",
+			Content: []llm.ContentBlock{
+				llm.NewTextBlock(fmt.Sprintf("【已知事實】：\n%s\n\n【對話片段】：\n%s", known, transcript)),
+			},
+		},
+	}
+
+	out, err := e.completeText(ctx, extractorMsgs)
+	if err != nil {
+		return err
+	}
+
+	// Models sometimes wrap the array in prose or code fences; keep only the array
+	start, end := strings.Index(out, "["), strings.LastIndex(out, "]")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON array in fact extraction output: %q", out)
+	}
+	var facts []llm.Fact
+	if err := json.Unmarshal([]byte(out[start:end+1]), &facts); err != nil {
+		return fmt.Errorf("invalid fact extraction output: %w", err)
+	}
+
+	history.MergeFacts(facts)
+	return nil
+}
+
+// summaryTranscript renders the messages that fall outside the recent window
+// as a labeled plain-text transcript. The system prompt and pinned messages
+// are skipped, as they survive summarization verbatim.
+func (e *AgentEngine) summaryTranscript(msgs []llm.Message) string {
+	keepCount := e.sysCfg.HistoryKeepRecentCount
+	if len(msgs) <= keepCount+1 {
+		return ""
+	}
+
 	toSummarize := msgs[1 : len(msgs)-keepCount]
 
 	var historyBuilder strings.Builder
 	for _, m := range toSummarize {
+		// Pinned messages stay in the history verbatim
+		if m.Pinned {
+			continue
+		}
 		roleLabel := "用戶"
 		switch m.Role {
 		case "assistant":
@@ -285,7 +743,7 @@ func (e *AgentEngine) summarizeSession(ctx context.Context, history *llm.ChatHis
 
 		var msgText strings.Builder
 		for _, b := range m.Content {
-			if b.Type == llm.BlockTypeText {
+			if b.Type == llm.BlockTypeText || b.Type == llm.BlockTypeTable {
 				msgText.WriteString(b.Text)
 			}
 		}
@@ -295,47 +753,86 @@ func (e *AgentEngine) summarizeSession(ctx context.Context, history *llm.ChatHis
 		}
 	}
 
-	summarizerMsgs := []llm.Message{
-		llm.NewSystemMessage(summaryPrompt),
-		{
-			Role: "user",
-			Content: []llm.ContentBlock{
-				llm.NewTextBlock(fmt.Sprintf("【之前的摘要】：\n%s\n\n【新發生的需要被總結的片段】：\n%s\n\n請提供產出整合後的最新摘要：", existing, historyBuilder.String())),
-			},
-		},
-	}
+	return historyBuilder.String()
+}
 
-	chunkCh, err := e.client.StreamChat(ctx, summarizerMsgs, nil)
+// completeText runs a tool-less LLM call and returns the concatenated text output.
+func (e *AgentEngine) completeText(ctx context.Context, msgs []llm.Message) (string, error) {
+	chunkCh, err := e.client.StreamChat(ctx, msgs, nil)
 	if err != nil {
 		return "", err
 	}
 
-	var summary strings.Builder
+	var out strings.Builder
 	for chunk := range chunkCh {
 		if chunk.RawError != nil {
 			return "", chunk.RawError
 		}
 		for _, b := range chunk.ContentBlocks {
 			if b.Type == llm.BlockTypeText {
-				summary.WriteString(b.Text)
+				out.WriteString(b.Text)
 			}
 		}
 	}
 
-	return summary.String(), nil
+	return out.String(), nil
 }
 
 // ProcessLLMStream manages the core Agentic reasoning loop including streaming
 // response forwarding, tool execution recursion, and error recovery.
 func (e *AgentEngine) ProcessLLMStream(ctx context.Context, msg *api.UnifiedMessage, history *llm.ChatHistory) llm.Message {
+	ctx = e.withDebugID(ctx, msg)
 	sysCfg := e.sysCfg
+
+	// Every recursion (retry, continuation, tool turn) is one more LLM call
+	if sysCfg.MaxLLMCallsPerMessage > 0 && msg.LLMCallCount >= sysCfg.MaxLLMCallsPerMessage {
+		slog.WarnContext(ctx, "LLM call budget exhausted", "calls", msg.LLMCallCount, "max", sysCfg.MaxLLMCallsPerMessage)
+		errMsg := fmt.Sprintf("Stopped after %d model calls for this message. Please simplify the request or send a follow-up.", msg.LLMCallCount)
+		e.responder.SendReply(msg.Session, utils.IconWarn.String()+" "+errMsg)
+		return llm.Message{
+			ID:        utils.GenerateID(),
+			Role:      "assistant",
+			Content:   []llm.ContentBlock{llm.NewErrorBlock(errMsg)},
+			Timestamp: time.Now().Unix(),
+		}
+	}
+
+	// The user interrupted the run, e.g., while tools were executing
+	if errors.Is(context.Cause(ctx), api.ErrRunCancelled) {
+		return llm.Message{}
+	}
+	msg.LLMCallCount++
+
+	// Clearly conversational messages are answered without tools. Decided on
+	// the first call only, so retries and continuations see the same tool set.
+	if msg.LLMCallCount == 1 && !msg.NoTools && e.toolsAvailable() && e.isConversational(msg.Content) {
+		slog.InfoContext(ctx, "Message matches a no-tools pattern, suppressing tools for this turn")
+		msg.NoTools = true
+		e.ensureSystemPrompt(history, msg.Session, false)
+	}
+
+	// Clients loaded from config carry a per-provider deadline; otherwise use the global one
 	timeout := time.Duration(sysCfg.LLMTimeoutMs) * time.Millisecond
-	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	if tc, ok := e.client.(llm.TimedClient); ok {
+		timeout = tc.Timeout()
+	}
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		runCtx, cancel = context.WithCancel(ctx)
+	}
 	defer cancel()
+	// Cancelled with a cause when the channel stops accepting the stream
+	runCtx, cancelRun := context.WithCancelCause(runCtx)
+	defer cancelRun(nil)
 
 	// Inject native tools; clients will format them appropriately
 	var availableTools []llm.Tool
-	if sysCfg.EnableTools && !msg.NoTools {
+	if sysCfg.EnableTools && !msg.NoTools && !llm.SupportsTools(e.client) {
+		slog.DebugContext(ctx, "Model does not support tools, not advertising them", "provider", e.client.Provider())
+	} else if sysCfg.EnableTools && !msg.NoTools {
 		apiTools := e.toolRegistry.GetAll()
 		availableTools = make([]llm.Tool, len(apiTools))
 		for i, t := range apiTools {
@@ -343,12 +840,31 @@ func (e *AgentEngine) ProcessLLMStream(ctx context.Context, msg *api.UnifiedMess
 		}
 	}
 
+	// Some clients block in StreamChat until the first chunk; bound that handshake too
+	firstTokenTimeout := time.Duration(sysCfg.FirstTokenTimeoutMs) * time.Millisecond
+	var handshakeTimer *time.Timer
+	if firstTokenTimeout > 0 {
+		handshakeTimer = time.AfterFunc(firstTokenTimeout, func() { cancelRun(llm.ErrFirstTokenTimeout) })
+	}
 	chunkCh, err := e.client.StreamChat(runCtx, history.GetMessages(), availableTools)
+	if handshakeTimer != nil {
+		handshakeTimer.Stop()
+	}
+
+	if err != nil && errors.Is(context.Cause(runCtx), llm.ErrFirstTokenTimeout) {
+		if e.AttemptRetry(ctx, msg, "first token timeout", llm.ErrFirstTokenTimeout, "") {
+			return e.ProcessLLMStream(ctx, msg, history)
+		}
+	}
+
+	if err != nil && errors.Is(context.Cause(ctx), api.ErrRunCancelled) {
+		return llm.Message{}
+	}
 
 	if err != nil {
 		slog.ErrorContext(runCtx, "LLM stream init failed", "error", err)
 		errMsg := fmt.Sprintf("Error during stream initiation: %v", err)
-		e.responder.SendReply(msg.Session, "❌ "+errMsg)
+		e.responder.SendReply(msg.Session, utils.IconError.String()+" "+errMsg)
 
 		return llm.Message{
 			ID:        utils.GenerateID(),
@@ -358,12 +874,28 @@ func (e *AgentEngine) ProcessLLMStream(ctx context.Context, msg *api.UnifiedMess
 		}
 	}
 
+	// When output moderation is active the reply is held back until it has been checked as a whole
+	moderateOutput := e.moderator != nil && sysCfg.Moderation.CheckOutput
+	var heldBlocks []llm.ContentBlock
+
 	blockCh := make(chan llm.ContentBlock, 100)
+	var out <-chan llm.ContentBlock = blockCh
+	if label := sysCfg.TurnSeparatorsFor(msg.Session.ChannelID).FinalAnswer; label != "" && msg.ToolRounds > 0 {
+		out = labelFirstText(blockCh, label)
+	}
 	streamDone := make(chan struct{})
 	go func() {
 		defer close(streamDone)
-		if err := e.responder.StreamReply(msg.Session, blockCh); err != nil {
+		if moderateOutput {
+			for b := range out {
+				heldBlocks = append(heldBlocks, b)
+			}
+			return
+		}
+		if err := e.responder.StreamReply(msg.Session, out); err != nil {
 			slog.ErrorContext(runCtx, "Failed to stream reply", "error", err)
+			// Stop consuming the LLM early; the user will not see the rest anyway
+			cancelRun(err)
 		}
 	}()
 
@@ -380,15 +912,65 @@ func (e *AgentEngine) ProcessLLMStream(ctx context.Context, msg *api.UnifiedMess
 	assistantMsg, streamErr := e.CollectChunks(runCtx, msg.Session, chunkCh, blockCh)
 	safeClose()
 
+	if errors.Is(streamErr, llm.ErrFirstTokenTimeout) {
+		// Abort the silent stream before retrying
+		cancelRun(streamErr)
+	}
+
+	if errors.Is(context.Cause(ctx), api.ErrRunCancelled) {
+		// Keep what was already shown to the user, but run no more tools
+		slog.InfoContext(ctx, "Run cancelled by the user")
+		assistantMsg.ToolCalls = nil
+		return assistantMsg
+	}
+
+	if errors.Is(streamErr, api.ErrChannelUnreachable) {
+		// Neither retries nor tool rounds make sense when the user cannot be reached
+		slog.WarnContext(ctx, "Channel unreachable, abandoning response", "error", streamErr)
+		assistantMsg.ToolCalls = nil
+		return assistantMsg
+	}
+
+	if moderateOutput {
+		if text := assistantMsg.GetTextContent(); text != "" {
+			if allowed, reason := e.moderator.Check(runCtx, text); !allowed {
+				slog.WarnContext(runCtx, "Assistant output blocked by moderation", "reason", reason)
+				refusal := e.refusalMessage()
+				e.responder.SendReply(msg.Session, refusal)
+				assistantMsg.Content = []llm.ContentBlock{llm.NewTextBlock(refusal)}
+				assistantMsg.ToolCalls = nil
+				return assistantMsg
+			}
+		}
+		e.StreamBlocks(ctx, msg.Session, heldBlocks)
+	}
+
 	// --- Tool Execution Logic ---
 	if len(assistantMsg.ToolCalls) > 0 {
-		sessionID := fmt.Sprintf("%s_%s", msg.Session.ChannelID, msg.Session.ChatID)
+		// Drop repeated calls before they are committed, so each stored call
+		// has exactly one result and side effects (e.g., sends) happen once
+		var dropped int
+		assistantMsg.ToolCalls, dropped = DedupeToolCalls(assistantMsg.ToolCalls)
+		if dropped > 0 {
+			slog.WarnContext(ctx, "Dropped duplicate tool calls", "count", dropped)
+		}
+
+		sessionID := sessionIDOf(msg.Session, history)
 		history.Add(assistantMsg)
 		e.sessions.SaveSession(sessionID)
 
+		// Directives follow all results, as providers expect the results
+		// of a round of calls to be adjacent
+		var directives []string
 		for _, tc := range assistantMsg.ToolCalls {
-			e.ResolveAndCommitToolCall(ctx, tc, msg, history)
+			if d := e.ResolveAndCommitToolCall(ctx, tc, msg, history); d != "" {
+				directives = append(directives, fmt.Sprintf("[Directive from tool %s] %s", tc.Name, d))
+			}
+		}
+		if len(directives) > 0 {
+			history.Add(directiveMessage(directives))
 		}
+		msg.ToolRounds++
 
 		e.sessions.SaveSession(sessionID)
 		return e.ProcessLLMStream(ctx, msg, history)
@@ -405,19 +987,28 @@ func (e *AgentEngine) ProcessLLMStream(ctx context.Context, msg *api.UnifiedMess
 	if !isNormal {
 		if reason == llm.StopReasonLength {
 			slog.InfoContext(runCtx, "Response truncated by length limit", "thinking", hasThinking, "content", hasContent)
-			e.responder.SendReply(msg.Session, "⚠️ Response truncated due to length limit.")
+			e.responder.SendReply(msg.Session, utils.IconWarn.String()+" Response truncated due to length limit.")
 			return assistantMsg
 		}
 
-		if retried := e.AttemptRetry(ctx, msg, reason, streamErr, preview); retried {
+		// A normal stop without any output is reported as "empty"
+		if streamErr == nil && !hasContent && !hasThinking && (reason == llm.StopReasonStop || reason == "UNKNOWN") {
+			reason = stopReasonEmpty
+		}
+
+		// Stream errors are filtered by transience inside AttemptRetry; stop
+		// reasons are filtered by the operator's list
+		if streamErr == nil && !sysCfg.IsRetryableStopReason(reason) {
+			slog.WarnContext(runCtx, "Abnormal response not eligible for retry", "reason", reason, "preview", preview)
+		} else if retried := e.AttemptRetry(ctx, msg, reason, streamErr, preview); retried {
 			safeClose()
 			return e.ProcessLLMStream(ctx, msg, history)
 		}
 
 		if streamErr != nil {
-			assistantMsg.AddContentBlock(llm.NewErrorBlock(fmt.Sprintf("\n❌ Stream error: %v", streamErr)))
+			assistantMsg.AddContentBlock(llm.NewErrorBlock(fmt.Sprintf("\n%s Stream error: %v", utils.IconError, streamErr)))
 		} else if !hasContent && !hasThinking {
-			assistantMsg.AddContentBlock(llm.NewErrorBlock(fmt.Sprintf("\n❌ Abnormal response: %s", reason)))
+			assistantMsg.AddContentBlock(llm.NewErrorBlock(fmt.Sprintf("\n%s Abnormal response: %s", utils.IconError, reason)))
 		}
 	}
 
@@ -435,11 +1026,27 @@ func (e *AgentEngine) CollectChunks(ctx context.Context, session api.SessionCont
 	var lastError error
 
 	sysCfg := e.sysCfg
+
+	// Close a reasoning section the stream ended in, after the blocks are flushed
+	defer func() {
+		if sysCfg.ShowThinking && inThinking(&msg) {
+			e.responder.SendSignal(session, "thinking:end")
+		}
+	}()
+
 	delay := time.Duration(sysCfg.ThinkingInitDelayMs) * time.Millisecond
 	thinkingTimer := time.NewTimer(delay)
 	defer thinkingTimer.Stop()
 	timerChan := thinkingTimer.C
 
+	// Give up on streams that stay silent for too long before the first chunk
+	var firstTokenChan <-chan time.Time
+	if sysCfg.FirstTokenTimeoutMs > 0 {
+		firstTokenTimer := time.NewTimer(time.Duration(sysCfg.FirstTokenTimeoutMs) * time.Millisecond)
+		defer firstTokenTimer.Stop()
+		firstTokenChan = firstTokenTimer.C
+	}
+
 	for {
 		select {
 		case chunk, ok := <-chunkCh:
@@ -455,8 +1062,9 @@ func (e *AgentEngine) CollectChunks(ctx context.Context, session api.SessionCont
 				thinkingTimer = nil
 				timerChan = nil
 			}
+			firstTokenChan = nil
 
-			e.ProcessChunk(ctx, chunk, &msg, blockCh)
+			e.ProcessChunk(ctx, session, chunk, &msg, blockCh)
 
 			if chunk.IsFinal {
 				return msg, lastError
@@ -465,39 +1073,122 @@ func (e *AgentEngine) CollectChunks(ctx context.Context, session api.SessionCont
 		case <-timerChan:
 			e.responder.SendSignal(session, "thinking")
 			timerChan = nil
+
+		case <-firstTokenChan:
+			return msg, llm.ErrFirstTokenTimeout
+
+		case <-ctx.Done():
+			return msg, context.Cause(ctx)
 		}
 	}
 }
 
+// loggedArgs returns tool arguments for logging, with PII masked from their
+// raw JSON when SystemConfig.RedactLogs is enabled.
+func (e *AgentEngine) loggedArgs(args map[string]any, raw string) any {
+	if e.sysCfg == nil || !e.sysCfg.RedactLogs {
+		return args
+	}
+	return utils.RedactPII(raw)
+}
+
 // HandleToolCall encapsulates the logic for resolving, parsing, and executing an individual tool call.
-func (e *AgentEngine) HandleToolCall(ctx context.Context, tc llm.ToolCall) []llm.ContentBlock {
+// Results of cacheable tools are reused within the session while they are fresh.
+// Besides the result blocks it returns the directive the tool attached, if any.
+func (e *AgentEngine) HandleToolCall(ctx context.Context, session api.SessionContext, tc llm.ToolCall) ([]llm.ContentBlock, string) {
 	cleanName := strings.TrimPrefix(tc.Name, "functions.")
 
 	tool, ok := e.toolRegistry.Get(cleanName)
 	if !ok {
 		slog.ErrorContext(ctx, "Unknown tool call", "name", tc.Name, "clean_name", cleanName)
-		return []llm.ContentBlock{llm.NewTextBlock(fmt.Sprintf("Error: Unknown tool '%s'", tc.Name))}
+		return []llm.ContentBlock{llm.NewTextBlock(fmt.Sprintf("Error: Unknown tool '%s'", tc.Name))}, ""
 	}
 
 	var args map[string]any
 	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
 		slog.ErrorContext(ctx, "Failed to parse tool args", "error", err)
-		return []llm.ContentBlock{llm.NewTextBlock(fmt.Sprintf("Error: Failed to parse tool arguments: %v", err))}
+		return []llm.ContentBlock{llm.NewTextBlock(fmt.Sprintf("Error: Failed to parse tool arguments: %v", err))}, ""
 	}
 
-	slog.InfoContext(ctx, "Executing tool", "name", tc.Name, "args", args)
-	res, err := tool.Execute(ctx, args)
+	cacheable := e.toolCache != nil && tools.IsCacheable(tool)
+	// Scoped by the chat's active session, so results do not leak across /session switches
+	scope := e.sessions.ActiveSession(fmt.Sprintf("%s_%s", session.ChannelID, session.ChatID))
+	if cacheable {
+		if res, ok := e.toolCache.Get(scope, cleanName, args); ok {
+			slog.InfoContext(ctx, "Tool result served from cache", "name", tc.Name, "args", e.loggedArgs(args, tc.Function.Arguments))
+			return ConvertToolResult(res), res.Directive
+		}
+	}
+
+	slog.InfoContext(ctx, "Executing tool", "name", tc.Name, "args", e.loggedArgs(args, tc.Function.Arguments))
+	res, err := e.executeTool(ctx, session, tool, args)
 	if err != nil {
 		slog.ErrorContext(ctx, "Tool execution error", "name", tc.Name, "error", err)
-		return []llm.ContentBlock{llm.NewTextBlock(fmt.Sprintf("Error: Tool execution failed: %v", err))}
+		return []llm.ContentBlock{llm.NewTextBlock(fmt.Sprintf("Error: Tool execution failed: %v", err))}, ""
+	}
+
+	if cacheable {
+		e.toolCache.Put(scope, cleanName, args, res)
+	}
+	return ConvertToolResult(res), res.Directive
+}
+
+// executeTool runs a tool, forwarding progress updates of StreamingTools to
+// the user as ephemeral "progress:<text>" signals. Every run is reported
+// as a tool_executed event.
+func (e *AgentEngine) executeTool(ctx context.Context, session api.SessionContext, tool api.Tool, args map[string]any) (res *api.ToolResult, err error) {
+	start := time.Now()
+	defer func() { e.emitToolExecuted(session, tool.Name(), time.Since(start), res, err) }()
+
+	ctx = api.WithSession(ctx, session)
+	st, ok := tool.(api.StreamingTool)
+	if !ok {
+		return tool.Execute(ctx, args)
 	}
 
-	return ConvertToolResult(res)
+	progress := make(chan api.Progress, 16)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for p := range progress {
+			slog.DebugContext(ctx, "Tool progress", "name", tool.Name(), "progress", p.String())
+			e.responder.SendSignal(session, "progress:"+p.String())
+		}
+	}()
+
+	res, err = st.ExecuteWithProgress(ctx, args, progress)
+	close(progress)
+	<-forwarded
+	return res, err
+}
+
+// emitToolExecuted reports a tool run to the responder, if it accepts events.
+func (e *AgentEngine) emitToolExecuted(session api.SessionContext, name string, d time.Duration, res *api.ToolResult, err error) {
+	em, ok := e.responder.(api.EventEmitter)
+	if !ok {
+		return
+	}
+	ev := api.Event{Type: api.EventToolExecuted, Session: session, Tool: name, Duration: d}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	if res != nil {
+		var texts []string
+		for _, block := range res.Content {
+			if block.Text != "" {
+				texts = append(texts, block.Text)
+			}
+		}
+		ev.Content = strings.Join(texts, "\n")
+	}
+	em.Emit(ev)
 }
 
 // ResolveAndCommitToolCall is a resilience wrapper that ensures Every tool call
 // results in a tool message being added to the history, even if the tool panics.
-func (e *AgentEngine) ResolveAndCommitToolCall(ctx context.Context, tc llm.ToolCall, msg *api.UnifiedMessage, history *llm.ChatHistory) {
+// It returns the tool's directive for the next turn, or "" if the tool set
+// none or directives are disabled.
+func (e *AgentEngine) ResolveAndCommitToolCall(ctx context.Context, tc llm.ToolCall, msg *api.UnifiedMessage, history *llm.ChatHistory) (directive string) {
 	var resultBlocks []llm.ContentBlock
 
 	defer func() {
@@ -517,10 +1208,57 @@ func (e *AgentEngine) ResolveAndCommitToolCall(ctx context.Context, tc llm.ToolC
 		history.Add(toolResMsg)
 
 		e.responder.SendSignal(msg.Session, "role:system")
-		e.StreamBlocks(ctx, msg.Session, resultBlocks)
+		e.StreamBlocks(ctx, msg.Session, e.withToolHeader(msg.Session, tc.Name, resultBlocks))
 	}()
 
-	resultBlocks = e.HandleToolCall(ctx, tc)
+	resultBlocks, directive = e.HandleToolCall(ctx, msg.Session, tc)
+	if directive != "" && !e.sysCfg.ToolDirectives {
+		slog.WarnContext(ctx, "Ignoring tool directive, tool directives are disabled", "tool", tc.Name)
+		directive = ""
+	}
+	return directive
+}
+
+// directiveMessage builds the note that passes tool directives to the next
+// LLM turn. It is a user message because providers only accept a system
+// prompt at the start of the conversation.
+func directiveMessage(directives []string) llm.Message {
+	return llm.Message{
+		ID:        utils.GenerateID(),
+		Role:      "user",
+		Content:   []llm.ContentBlock{llm.NewTextBlock(strings.Join(directives, "\n"))},
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+// withToolHeader prepends the configured tool result header to the blocks
+// streamed for a tool call. The history keeps the bare result.
+func (e *AgentEngine) withToolHeader(session api.SessionContext, toolName string, blocks []llm.ContentBlock) []llm.ContentBlock {
+	header := e.sysCfg.TurnSeparatorsFor(session.ChannelID).ToolResult
+	if header == "" {
+		return blocks
+	}
+	header = strings.ReplaceAll(header, "{tool}", strings.TrimPrefix(toolName, "functions."))
+	return append([]llm.ContentBlock{llm.NewTextBlock(header + "\n")}, blocks...)
+}
+
+// labelFirstText forwards blocks, inserting label as a line of its own before
+// the first text block. Thinking and other blocks pass through unlabeled, and
+// a stream without text (e.g., only tool calls) gets no label.
+func labelFirstText(blocks <-chan llm.ContentBlock, label string) <-chan llm.ContentBlock {
+	out := make(chan llm.ContentBlock, cap(blocks))
+	go func() {
+		defer close(out)
+		labeled := false
+		for b := range blocks {
+			if !labeled && b.Type == llm.BlockTypeText {
+				out <- llm.NewTextBlock(label + "\n")
+				labeled = true
+			}
+			out <- b
+		}
+	}()
+	return out
 }
 
 // StreamBlocks is a utility to pipe a slice of content blocks into the gateway's stream.
@@ -539,14 +1277,19 @@ func (e *AgentEngine) StreamBlocks(ctx context.Context, session api.SessionConte
 }
 
 // ProcessChunk handles the low-level parsing of a single LLM StreamChunk.
-func (e *AgentEngine) ProcessChunk(ctx context.Context, chunk llm.StreamChunk, msg *llm.Message, blockCh chan<- llm.ContentBlock) {
+// Runs of shown thinking blocks are framed by "thinking:start" and
+// "thinking:end" signals, so clients can render the reasoning as a
+// collapsible section apart from the answer.
+func (e *AgentEngine) ProcessChunk(ctx context.Context, session api.SessionContext, chunk llm.StreamChunk, msg *llm.Message, blockCh chan<- llm.ContentBlock) {
 	if chunk.Error != "" {
-		errorMsg := fmt.Sprintf("\n❌ %s", chunk.Error)
+		errorMsg := fmt.Sprintf("\n%s %s", utils.IconError, chunk.Error)
+		e.frameThinking(session, msg, llm.BlockTypeError)
 		msg.AddContentBlock(llm.NewErrorBlock(errorMsg))
 		blockCh <- llm.NewErrorBlock(errorMsg)
 	}
 
 	for _, block := range chunk.ContentBlocks {
+		e.frameThinking(session, msg, block.Type)
 		msg.AddContentBlock(block)
 
 		switch block.Type {
@@ -556,7 +1299,7 @@ func (e *AgentEngine) ProcessChunk(ctx context.Context, chunk llm.StreamChunk, m
 			if e.sysCfg.ShowThinking {
 				blockCh <- block
 			}
-		case llm.BlockTypeImage:
+		case llm.BlockTypeImage, llm.BlockTypeTable:
 			blockCh <- block
 		}
 	}
@@ -570,11 +1313,31 @@ func (e *AgentEngine) ProcessChunk(ctx context.Context, chunk llm.StreamChunk, m
 	}
 }
 
+// frameThinking signals the start or end of a run of thinking blocks before
+// a block of the given type is added to msg. It does nothing unless thinking
+// is shown.
+func (e *AgentEngine) frameThinking(session api.SessionContext, msg *llm.Message, blockType string) {
+	if !e.sysCfg.ShowThinking {
+		return
+	}
+	switch thinking := blockType == llm.BlockTypeThinking; {
+	case thinking && !inThinking(msg):
+		e.responder.SendSignal(session, "thinking:start")
+	case !thinking && inThinking(msg):
+		e.responder.SendSignal(session, "thinking:end")
+	}
+}
+
+// inThinking reports whether the message so far ends in a thinking block.
+func inThinking(msg *llm.Message) bool {
+	return len(msg.Content) > 0 && msg.Content[len(msg.Content)-1].Type == llm.BlockTypeThinking
+}
+
 // AttemptRetry checks if a retry is allowed and, if so, increments the counter.
 func (e *AgentEngine) AttemptRetry(ctx context.Context, msg *api.UnifiedMessage, reason string, streamErr error, preview string) bool {
-	if streamErr != nil && !e.client.IsTransientError(streamErr) {
+	if streamErr != nil && !errors.Is(streamErr, llm.ErrFirstTokenTimeout) && !e.client.IsTransientError(streamErr) {
 		slog.ErrorContext(ctx, "Non-transient error, skipping retry", "error", streamErr)
-		e.responder.SendReply(msg.Session, fmt.Sprintf("❌ %v", streamErr))
+		e.responder.SendReply(msg.Session, fmt.Sprintf("%s %v", utils.IconError, streamErr))
 		return false
 	}
 
@@ -582,7 +1345,7 @@ func (e *AgentEngine) AttemptRetry(ctx context.Context, msg *api.UnifiedMessage,
 	maxRetries := sysCfg.MaxRetries
 	if msg.RetryCount >= maxRetries {
 		slog.ErrorContext(ctx, "Max retries reached", "max", maxRetries, "reason", reason, "error", streamErr)
-		e.responder.SendReply(msg.Session, "❌ AI response remains abnormal, please try rephrasing or restarting the conversation.")
+		e.responder.SendReply(msg.Session, utils.IconError.String()+" AI response remains abnormal, please try rephrasing or restarting the conversation.")
 		return false
 	}
 
@@ -595,9 +1358,9 @@ func (e *AgentEngine) AttemptRetry(ctx context.Context, msg *api.UnifiedMessage,
 		"retry", fmt.Sprintf("%d/%d", msg.RetryCount, maxRetries),
 	)
 
-	retryNotice := fmt.Sprintf("⚠️ Abnormal response (%s), attempting automatic fix (%d/%d)...", reason, msg.RetryCount, maxRetries)
+	retryNotice := fmt.Sprintf("%s Abnormal response (%s), attempting automatic fix (%d/%d)...", utils.IconWarn, reason, msg.RetryCount, maxRetries)
 	if streamErr != nil {
-		retryNotice = fmt.Sprintf("⚠️ Connection error (%v), attempting automatic recovery (%d/%d)...", streamErr, msg.RetryCount, maxRetries)
+		retryNotice = fmt.Sprintf("%s Connection error (%v), attempting automatic recovery (%d/%d)...", utils.IconWarn, streamErr, msg.RetryCount, maxRetries)
 	}
 	e.responder.SendReply(msg.Session, retryNotice)
 
@@ -633,6 +1396,37 @@ func SummarizeContent(msg llm.Message) (hasContent, hasThinking bool, preview st
 	return
 }
 
+// DedupeToolCalls removes tool calls that repeat an earlier call in the same
+// turn (same tool name and equivalent JSON arguments), keeping the first
+// occurrence. It returns the remaining calls and how many were dropped.
+func DedupeToolCalls(calls []llm.ToolCall) ([]llm.ToolCall, int) {
+	seen := make(map[string]bool, len(calls))
+	kept := make([]llm.ToolCall, 0, len(calls))
+	for _, tc := range calls {
+		name := tc.Name
+		if name == "" {
+			name = tc.Function.Name
+		}
+
+		// Re-encode the arguments so key order and whitespace do not matter
+		args := tc.Function.Arguments
+		var parsed any
+		if err := json.Unmarshal([]byte(args), &parsed); err == nil {
+			if canonical, err := json.Marshal(parsed); err == nil {
+				args = string(canonical)
+			}
+		}
+
+		key := name + "\x00" + args
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, tc)
+	}
+	return kept, len(calls) - len(kept)
+}
+
 // ConvertToolResult transforms a api.ToolResult into a slice of llm.ContentBlock.
 func ConvertToolResult(res *api.ToolResult) []llm.ContentBlock {
 	var blocks []llm.ContentBlock
@@ -649,6 +1443,8 @@ func ConvertToolResult(res *api.ToolResult) []llm.ContentBlock {
 				mimeType = "image/png"
 			}
 			blocks = append(blocks, llm.NewImageBlock(data, mimeType))
+		} else if b.Type == llm.BlockTypeTable && b.Table != nil {
+			blocks = append(blocks, llm.NewTableBlock(b.Table.Headers, b.Table.Rows))
 		} else {
 			blocks = append(blocks, llm.NewTextBlock(b.Text))
 		}
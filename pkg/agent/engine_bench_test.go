@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"genesis/pkg/api"
+	"genesis/pkg/config"
+	"genesis/pkg/gateway"
+	"genesis/pkg/llm"
+	"genesis/pkg/llm/mock"
+	"genesis/pkg/tools"
+	"log/slog"
+	"runtime"
+	"testing"
+)
+
+// benchTokens is the number of tiny text chunks streamed per operation.
+const benchTokens = 10000
+
+var benchSession = api.SessionContext{ChannelID: "bench", UserID: "u1", ChatID: "c1", Username: "bench"}
+
+// discardChannel is a channel that accepts and drops every reply.
+type discardChannel struct{}
+
+func (discardChannel) ID() string                                  { return "bench" }
+func (discardChannel) Start(api.ChannelContext) error              { return nil }
+func (discardChannel) Stop() error                                 { return nil }
+func (discardChannel) Send(api.SessionContext, string) error       { return nil }
+func (discardChannel) SendSignal(api.SessionContext, string) error { return nil }
+
+func (discardChannel) Stream(_ api.SessionContext, blocks <-chan llm.ContentBlock) error {
+	for range blocks {
+	}
+	return nil
+}
+
+// newBenchEngine wires an engine streaming benchTokens chunks from the mock
+// client through a gateway to a discardChannel.
+func newBenchEngine(b *testing.B) *AgentEngine {
+	// Per-turn logging would dominate the profile
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	sysCfg := config.DefaultSystemConfig()
+	sysCfg.EnableTools = false
+	client := mock.NewMockClient("bench", map[string]any{"chunks": float64(benchTokens)})
+
+	e := NewAgentEngine(client, &config.Config{}, sysCfg, llm.NewSessionManager(b.TempDir()))
+	e.SetToolRegistry(tools.NewToolRegistry())
+	g := gateway.NewGatewayManager().WithSystemConfig(sysCfg)
+	g.Register(discardChannel{})
+	e.SetResponder(g)
+	return e
+}
+
+// runPerToken runs op b.N times and reports allocations per token and
+// throughput besides the per-operation figures.
+func runPerToken(b *testing.B, op func()) {
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for range b.N {
+		op()
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+
+	tokens := float64(b.N * benchTokens)
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/tokens, "allocs/token")
+	b.ReportMetric(tokens/b.Elapsed().Seconds(), "tokens/s")
+}
+
+// BenchmarkCollectChunks measures the chunk collection loop alone, with the
+// block channel drained directly.
+func BenchmarkCollectChunks(b *testing.B) {
+	e := newBenchEngine(b)
+	ctx := context.Background()
+
+	runPerToken(b, func() {
+		chunkCh, err := e.client.StreamChat(ctx, nil, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		blockCh := make(chan llm.ContentBlock, e.sysCfg.InternalChannelBuffer)
+		drained := make(chan struct{})
+		go func() {
+			defer close(drained)
+			for range blockCh {
+			}
+		}()
+		if _, err := e.CollectChunks(ctx, benchSession, chunkCh, blockCh); err != nil {
+			b.Fatal(err)
+		}
+		close(blockCh)
+		<-drained
+	})
+}
+
+// BenchmarkHandleMessage measures a whole turn: the engine streams the mock
+// reply through the gateway to a fake channel.
+func BenchmarkHandleMessage(b *testing.B) {
+	e := newBenchEngine(b)
+	ctx := context.Background()
+
+	runPerToken(b, func() {
+		msg := &api.UnifiedMessage{Session: benchSession, Content: "hello"}
+		reply := e.HandleMessage(ctx, msg, llm.NewChatHistory())
+		if len(reply.Content) == 0 {
+			b.Fatal("empty reply")
+		}
+	})
+}
@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"context"
+	"genesis/pkg/api"
+	"genesis/pkg/config"
+	"genesis/pkg/gateway"
+	"genesis/pkg/llm"
+	"genesis/pkg/llm/mock"
+	"genesis/pkg/tools"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestEngine wires an engine to a mock client replying "done" and a
+// gateway delivering to a discardChannel.
+func newTestEngine(t *testing.T, sysCfg *config.SystemConfig) (*AgentEngine, *mock.MockClient) {
+	t.Helper()
+	client := mock.NewMockClient("test", map[string]any{"chunks": float64(1), "chunk_text": "done"})
+	return newEngineWithClient(t, client, discardChannel{}, sysCfg), client
+}
+
+// newEngineWithClient wires an engine to client and a gateway delivering to
+// ch, which must have the ID of benchSession's channel.
+func newEngineWithClient(t *testing.T, client llm.LLMClient, ch api.Channel, sysCfg *config.SystemConfig) *AgentEngine {
+	t.Helper()
+	e := NewAgentEngine(client, &config.Config{}, sysCfg, llm.NewSessionManager(t.TempDir()))
+	e.SetToolRegistry(tools.NewToolRegistry())
+	g := gateway.NewGatewayManager().WithSystemConfig(sysCfg)
+	g.Register(ch)
+	e.SetResponder(g)
+	return e
+}
+
+// scriptedClient replays one scripted stream per StreamChat call and
+// replies "done" once the script is exhausted.
+type scriptedClient struct {
+	mu      sync.Mutex
+	streams [][]llm.StreamChunk
+}
+
+func (c *scriptedClient) Provider() string            { return "scripted" }
+func (c *scriptedClient) IsTransientError(error) bool { return false }
+
+func (c *scriptedClient) StreamChat(context.Context, []llm.Message, []llm.Tool) (<-chan llm.StreamChunk, error) {
+	c.mu.Lock()
+	chunks := []llm.StreamChunk{{ContentBlocks: []llm.ContentBlock{llm.NewTextBlock("done")}}}
+	if len(c.streams) > 0 {
+		chunks, c.streams = c.streams[0], c.streams[1:]
+	}
+	c.mu.Unlock()
+
+	ch := make(chan llm.StreamChunk, len(chunks)+1)
+	for _, chunk := range chunks {
+		ch <- chunk
+	}
+	ch <- llm.StreamChunk{IsFinal: true, FinishReason: llm.StopReasonStop}
+	close(ch)
+	return ch, nil
+}
+
+// slowChannel delivers blocks slowly, as a rate-limited channel would, and
+// records their text in delivery order.
+type slowChannel struct {
+	discardChannel
+	mu        sync.Mutex
+	delivered strings.Builder
+}
+
+func (c *slowChannel) Stream(_ api.SessionContext, blocks <-chan llm.ContentBlock) error {
+	for b := range blocks {
+		time.Sleep(2 * time.Millisecond)
+		c.mu.Lock()
+		c.delivered.WriteString(b.Text)
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+func (c *slowChannel) text() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.delivered.String()
+}
+
+// countingTool counts its executions and returns its text argument.
+type countingTool struct {
+	calls atomic.Int32
+}
+
+func (t *countingTool) Name() string                 { return "send_note" }
+func (t *countingTool) Description() string          { return "Sends a note." }
+func (t *countingTool) RequiredParameters() []string { return []string{"text"} }
+
+func (t *countingTool) Parameters() map[string]any {
+	return map[string]any{"text": map[string]any{"type": "string"}}
+}
+
+func (t *countingTool) Execute(_ context.Context, args map[string]any) (*api.ToolResult, error) {
+	t.calls.Add(1)
+	text, _ := args["text"].(string)
+	return &api.ToolResult{Content: []api.ContentBlock{{Type: "text", Text: text}}}, nil
+}
+
+// cacheableTool is a countingTool whose results may be cached.
+type cacheableTool struct {
+	countingTool
+}
+
+func (t *cacheableTool) Cacheable() bool { return true }
+
+func TestHandleToolCallServesRepeatedCallsFromCache(t *testing.T) {
+	sysCfg := config.DefaultSystemConfig()
+	sysCfg.ToolCacheTTLMs = 60000
+	e, _ := newTestEngine(t, sysCfg)
+	tool := &cacheableTool{}
+	e.RegisterTool(tool)
+
+	call := func(text string) string {
+		tc := llm.ToolCall{Name: "send_note", Function: llm.FunctionCall{Name: "send_note", Arguments: `{"text":"` + text + `"}`}}
+		blocks, _ := e.HandleToolCall(context.Background(), benchSession, tc)
+		return blocks[0].Text
+	}
+
+	if got := call("hi"); got != "hi" {
+		t.Fatalf("first call = %q, want %q", got, "hi")
+	}
+	if got := call("hi"); got != "hi" {
+		t.Fatalf("repeated call = %q, want %q", got, "hi")
+	}
+	if n := tool.calls.Load(); n != 1 {
+		t.Fatalf("tool executed %d times for identical calls, want 1", n)
+	}
+	call("other")
+	if n := tool.calls.Load(); n != 2 {
+		t.Fatalf("tool executed %d times after a call with new arguments, want 2", n)
+	}
+}
+
+func TestHandleMessagePassesRegisteredTools(t *testing.T) {
+	sysCfg := config.DefaultSystemConfig()
+	sysCfg.EnableTools = true
+	e, client := newTestEngine(t, sysCfg)
+	e.RegisterTool(tools.NewOSTool(nil))
+
+	msg := &api.UnifiedMessage{Session: benchSession, Content: "list the files in my home directory"}
+	e.HandleMessage(context.Background(), msg, llm.NewChatHistory())
+
+	for _, tool := range client.LastTools() {
+		if tool.Name() == "os_control" {
+			return
+		}
+	}
+	t.Fatalf("os_control not offered to the model, got %d tools", len(client.LastTools()))
+}
+
+func TestProcessLLMStreamDedupesToolCalls(t *testing.T) {
+	sysCfg := config.DefaultSystemConfig()
+	sysCfg.EnableTools = true
+	call := func(id, args string) llm.ToolCall {
+		return llm.ToolCall{ID: id, Name: "send_note", Function: llm.FunctionCall{Name: "send_note", Arguments: args}}
+	}
+	// The same call streamed twice, with different IDs and key order
+	client := &scriptedClient{streams: [][]llm.StreamChunk{{
+		{ToolCalls: []llm.ToolCall{call("c1", `{"text":"hi","to":"bob"}`)}},
+		{ToolCalls: []llm.ToolCall{call("c2", `{"to": "bob", "text": "hi"}`)}},
+	}}}
+	e := newEngineWithClient(t, client, discardChannel{}, sysCfg)
+	tool := &countingTool{}
+	e.RegisterTool(tool)
+
+	history := llm.NewChatHistory()
+	msg := &api.UnifiedMessage{Session: benchSession, Content: "send bob a note saying hi"}
+	e.HandleMessage(context.Background(), msg, history)
+
+	if n := tool.calls.Load(); n != 1 {
+		t.Fatalf("tool executed %d times, want 1", n)
+	}
+	for _, m := range history.GetMessages() {
+		if len(m.ToolCalls) > 0 && len(m.ToolCalls) != 1 {
+			t.Fatalf("assistant message stores %d tool calls, want 1", len(m.ToolCalls))
+		}
+	}
+}
+
+func TestDedupeToolCalls(t *testing.T) {
+	calls := []llm.ToolCall{
+		{Name: "a", Function: llm.FunctionCall{Arguments: `{"x":1,"y":2}`}},
+		{Name: "a", Function: llm.FunctionCall{Arguments: `{"y":2,"x":1}`}},
+		{Name: "a", Function: llm.FunctionCall{Arguments: `{"x":2}`}},
+		{Function: llm.FunctionCall{Name: "b", Arguments: `{"x":1,"y":2}`}},
+		{Name: "b", Function: llm.FunctionCall{Arguments: `{"x":1,"y":2}`}},
+	}
+
+	kept, dropped := DedupeToolCalls(calls)
+	if dropped != 2 || len(kept) != 3 {
+		t.Fatalf("kept %d, dropped %d; want 3 and 2", len(kept), dropped)
+	}
+	if kept[1].Function.Arguments != `{"x":2}` {
+		t.Errorf("kept[1] = %s, want the first occurrence order preserved", kept[1].Function.Arguments)
+	}
+}
+
+func TestProcessLLMStreamDeliversToolTurnsInOrder(t *testing.T) {
+	sysCfg := config.DefaultSystemConfig()
+	sysCfg.EnableTools = true
+	step := func(text, toolOutput string) []llm.StreamChunk {
+		return []llm.StreamChunk{
+			{ContentBlocks: []llm.ContentBlock{llm.NewTextBlock(text)}},
+			{ToolCalls: []llm.ToolCall{{
+				ID:       toolOutput,
+				Name:     "send_note",
+				Function: llm.FunctionCall{Name: "send_note", Arguments: `{"text":"` + toolOutput + `"}`},
+			}}},
+		}
+	}
+	client := &scriptedClient{streams: [][]llm.StreamChunk{step("<step1>", "<out1>"), step("<step2>", "<out2>")}}
+	ch := &slowChannel{}
+	e := newEngineWithClient(t, client, ch, sysCfg)
+	e.RegisterTool(&countingTool{})
+
+	msg := &api.UnifiedMessage{Session: benchSession, Content: "send two notes"}
+	e.HandleMessage(context.Background(), msg, llm.NewChatHistory())
+
+	delivered := ch.text()
+	last := -1
+	for _, segment := range []string{"<step1>", "<out1>", "<step2>", "<out2>", "done"} {
+		i := strings.Index(delivered, segment)
+		if i <= last {
+			t.Fatalf("%s delivered out of order: %q", segment, delivered)
+		}
+		last = i
+	}
+}
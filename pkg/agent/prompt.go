@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"genesis/pkg/api"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// promptData is the data available to system prompt templates, e.g.,
+// "You are talking to {{.Username}} on {{.ChannelID}}. Today is {{.Date}}."
+// It only carries plain strings, so templates cannot reach engine state.
+type promptData struct {
+	Username  string // Display name of the user
+	UserID    string // Platform-specific user identifier
+	ChannelID string // Channel the request came from (e.g., "telegram")
+	ChatID    string // Platform-specific chat identifier
+	Date      string // Current local date (YYYY-MM-DD)
+	Time      string // Current local time (HH:MM)
+	Weekday   string // Current local weekday (e.g., "Monday")
+}
+
+// renderPrompt expands template variables in prompt for the given session.
+// Prompts without "{{" are returned unchanged. A prompt that fails to parse
+// or execute is logged and used verbatim, so a typo never blocks replies.
+func renderPrompt(prompt string, session api.SessionContext) string {
+	if !strings.Contains(prompt, "{{") {
+		return prompt
+	}
+
+	tmpl, err := template.New("system_prompt").Option("missingkey=zero").Parse(prompt)
+	if err != nil {
+		slog.Warn("Invalid system prompt template, using it verbatim", "error", err)
+		return prompt
+	}
+
+	now := time.Now()
+	data := promptData{
+		Username:  session.Username,
+		UserID:    session.UserID,
+		ChannelID: session.ChannelID,
+		ChatID:    session.ChatID,
+		Date:      now.Format("2006-01-02"),
+		Time:      now.Format("15:04"),
+		Weekday:   now.Weekday().String(),
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		slog.Warn("Failed to render system prompt template, using it verbatim", "error", err)
+		return prompt
+	}
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package api
+
+import "time"
+
+// EventType identifies the kind of an Event.
+type EventType string
+
+// Event types emitted by the gateway and the agent engine.
+const (
+	EventMessageReceived EventType = "message_received" // A user message arrived from a channel
+	EventReplySent       EventType = "reply_sent"       // A reply was delivered to the user
+	EventToolExecuted    EventType = "tool_executed"    // A tool ran, successfully or not
+	EventError           EventType = "error"            // An error was reported to the user or a reply could not be delivered
+)
+
+// Event is a structured record of something that happened while serving a
+// session. Unlike monitor messages it is meant for programs (alerting,
+// analytics) rather than display. Content is redacted like monitor output.
+type Event struct {
+	Type      EventType      `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	Session   SessionContext `json:"session"`
+	Role      string         `json:"role,omitempty"`     // Author of a reply_sent event: "assistant" or "system"
+	Content   string         `json:"content,omitempty"`  // Message, reply or tool output text, or the error shown to the user
+	Tool      string         `json:"tool,omitempty"`     // Tool name, for tool_executed
+	Duration  time.Duration  `json:"duration,omitempty"` // Tool run time, for tool_executed
+	Error     string         `json:"error,omitempty"`    // Cause of a failure, for error and failed tool_executed events
+}
+
+// EventSink receives the events of a gateway. OnEvent is called
+// synchronously on the goroutine serving the session, so sinks doing slow
+// work (network calls, disk writes) should hand events off to their own
+// goroutine.
+type EventSink interface {
+	OnEvent(ev Event)
+}
+
+// EventEmitter is implemented by responders that accept events from the
+// components they serve, such as tool runs reported by the agent engine.
+type EventEmitter interface {
+	Emit(ev Event)
+}
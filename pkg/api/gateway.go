@@ -1,15 +1,29 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"genesis/pkg/llm"
 )
 
+// ErrChannelUnreachable is returned (wrapped) by MessageResponder.StreamReply
+// when the channel gave up delivering a stream, so producers can stop early.
+var ErrChannelUnreachable = errors.New("channel unreachable")
+
+// ErrRunCancelled is the cause of a run's context when the user interrupted
+// it, so the engine can end the run quietly instead of reporting an error.
+var ErrRunCancelled = errors.New("run cancelled by the user")
+
 // Channel defines the standardized lifecycle interface for communication platforms.
 type Channel interface {
 	ID() string
 	Start(ctx ChannelContext) error
 	Stop() error
 	Send(session SessionContext, message string) error
+	// Stream delivers blocks until the channel is closed. It must not return
+	// before everything it accepted has been sent (or sending failed), since
+	// the gateway treats its return as the point where the next reply to the
+	// same recipient may start.
 	Stream(session SessionContext, blocks <-chan llm.ContentBlock) error
 }
 
@@ -17,11 +31,44 @@ type Channel interface {
 // platforms that support control signals (e.g., typing indicators, thinking UI).
 type SignalingChannel interface {
 	Channel
-	// SendSignal transmits a control signal (e.g., "thinking", "role:system")
+	// SendSignal transmits a control signal (e.g., "thinking", "role:system",
+	// "progress:Indexed 40/100 files", "summarizing:start"/"summarizing:end",
+	// "thinking:start"/"thinking:end" around streamed reasoning)
 	// to the target session to change UI state or metadata.
 	SendSignal(session SessionContext, signal string) error
 }
 
+// HealthCheckable is an optional extension of the Channel interface for
+// channels that can detect that they stopped working (e.g., a crashed
+// listener or a polling loop stuck on errors). The gateway polls Health and
+// restarts unhealthy channels with Stop followed by Start, so implementations
+// must support being started again after Stop.
+type HealthCheckable interface {
+	Channel
+	// Health returns nil while the channel is operating normally, or an
+	// error describing why it is not.
+	Health() error
+}
+
+// Action is a quick-reply choice attached to an outgoing message. Choosing
+// it delivers Value back to the handler as a regular UnifiedMessage from the
+// user who chose it, so interactive flows (approvals, menus) need no special
+// handling downstream.
+type Action struct {
+	Label string `json:"label"` // Text shown on the button
+	Value string `json:"value"` // Content of the message sent when the action is chosen
+}
+
+// InteractiveChannel is an optional extension of the Channel interface for
+// platforms that can render quick-reply buttons (e.g., Telegram inline
+// keyboards, Slack blocks, Discord components). Channels without it receive
+// the choices as plain text.
+type InteractiveChannel interface {
+	Channel
+	// SendWithActions sends text with the given actions attached as buttons.
+	SendWithActions(session SessionContext, text string, actions []Action) error
+}
+
 // ChannelContext provides the interface for a Channel implementation to
 // communicate back with the Gateway core.
 type ChannelContext interface {
@@ -32,8 +79,24 @@ type ChannelContext interface {
 // MessageResponder defines the capabilities for sending responses back to a channel.
 type MessageResponder interface {
 	SendReply(session SessionContext, content string) error
+	// StreamReply returns once the reply has been delivered, after replies
+	// to the same recipient that started earlier, so callers can rely on
+	// consecutive calls reaching the user in order.
 	StreamReply(session SessionContext, blocks <-chan llm.ContentBlock) error
 	SendSignal(session SessionContext, signal string) error
+	// SendWithActions sends text with quick-reply actions, or a plain-text
+	// list of the choices on channels that cannot render buttons.
+	SendWithActions(session SessionContext, text string, actions []Action) error
+}
+
+// RunTracker is an optional extension of MessageResponder for responders
+// that let users interrupt the processing of their messages. The handler
+// registers each run with BeginRun, uses the returned context for it and
+// calls the returned function once it has finished. BeginRun may wait for
+// earlier runs to the same recipient, so their replies do not interleave. An
+// interrupted run's context is cancelled with ErrRunCancelled.
+type RunTracker interface {
+	BeginRun(ctx context.Context, session SessionContext) (context.Context, func())
 }
 
 // UnifiedMessage defines the standardized internal data structure for all
@@ -45,8 +108,10 @@ type UnifiedMessage struct {
 	Raw           any              // Optional storage for the original platform-specific payload object
 	RetryCount    int              // Counter for automatic recovery attempts during stream failures
 	ContinueCount int              // Counter for content continuation calls (handling length limits)
+	LLMCallCount  int              // Total LLM calls made for this message across retries, continuations and tool turns
+	ToolRounds    int              // Rounds of tool calls executed for this message so far
 	NoTools       bool             // Virtual flag to disable tool calling for specific requests
-	DebugID       string           // Unique identifier for grouping agentic loop logs for this request
+	DebugID       string           // Trace ID assigned by the gateway; correlates all logs and debug chunks of this request
 }
 
 // SessionContext encapsulates identity and routing information for a specific
@@ -56,6 +121,13 @@ type SessionContext struct {
 	UserID    string // Platform-specific unique identifier for the user
 	ChatID    string // Platform-specific identifier for the chat or group (may match UserID for DMs)
 	Username  string // Display name or nickname of the user as provided by the platform
+	IsGroup   bool   // True when the chat is shared by several users (group chats, IRC channels)
+}
+
+// UserKey identifies the user across chats of the same channel, in the
+// "channel:user" form recorded as a session participant.
+func (s SessionContext) UserKey() string {
+	return s.ChannelID + ":" + s.UserID
 }
 
 // FileAttachment represents a single file or binary object uploaded by a user.
@@ -0,0 +1,11 @@
+package api
+
+import "context"
+
+// Moderator defines a content moderation check applied to user input before
+// it reaches the LLM and to assistant output before it reaches the user.
+type Moderator interface {
+	// Check classifies the given text. It returns false along with a short,
+	// human-readable reason when the content should be blocked.
+	Check(ctx context.Context, text string) (allowed bool, reason string)
+}
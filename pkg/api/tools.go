@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"genesis/pkg/llm"
 )
 
@@ -14,21 +15,85 @@ type Tool interface {
 	Execute(ctx context.Context, args map[string]any) (*ToolResult, error)
 }
 
+// CacheableTool is an optional extension of Tool. Tools whose results are
+// deterministic for the same arguments (e.g., fetching a stable URL) can opt
+// into result caching by returning true from Cacheable. Tools with side
+// effects or time-dependent output (screenshots, clocks) must not implement it.
+type CacheableTool interface {
+	Tool
+	Cacheable() bool
+}
+
+// Progress is an ephemeral status update emitted by a long-running tool
+// (e.g., "Indexed 40/100 files"). It is shown to the user while the tool
+// runs but never becomes part of the tool result or the history.
+type Progress struct {
+	Message string // Human-readable status line
+	Done    int    // Units of work completed so far (optional)
+	Total   int    // Total units of work, or 0 if unknown (optional)
+}
+
+// String formats the progress for display, appending "(done/total)" when known.
+func (p Progress) String() string {
+	if p.Total > 0 {
+		return fmt.Sprintf("%s (%d/%d)", p.Message, p.Done, p.Total)
+	}
+	return p.Message
+}
+
+// StreamingTool is an optional extension of Tool for long-running operations
+// that can report progress. The engine calls ExecuteWithProgress instead of
+// Execute and forwards every update to the user as a "progress:<text>" signal.
+// The engine owns the progress channel: it drains it for the whole call and
+// closes it after ExecuteWithProgress returns, so the tool must not close it
+// or send after returning.
+type StreamingTool interface {
+	Tool
+	ExecuteWithProgress(ctx context.Context, args map[string]any, progress chan<- Progress) (*ToolResult, error)
+}
+
+// sessionKey is the context key under which the engine passes the session
+// a tool call belongs to.
+type sessionKey struct{}
+
+// WithSession returns a context carrying the session of the current request.
+func WithSession(ctx context.Context, session SessionContext) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext returns the session a tool is executed for. Tools that
+// act on behalf of the user (e.g., reading their other sessions) use it to
+// scope what they may access.
+func SessionFromContext(ctx context.Context) (SessionContext, bool) {
+	session, ok := ctx.Value(sessionKey{}).(SessionContext)
+	return session, ok
+}
+
 // ToolResult encapsulates the outcome of a tool execution.
 // It can contain multiple content blocks (text logs, images) and
 // arbitrary metadata for the handler to process.
+//
+// Directive optionally steers the next LLM turn (e.g., "Ask the user to
+// confirm before deleting"). The engine adds it as a note after the results
+// of the current round instead of mixing it into the tool output, and only
+// when SystemConfig.ToolDirectives is enabled. A directive is read by the
+// model as an instruction rather than as data, so a tool must never copy
+// content it does not control (web pages, files, remote replies) into it:
+// doing so turns that content into a prompt injection.
 type ToolResult struct {
-	Content []ContentBlock `json:"content"`           // Ordered blocks of result data
-	Details map[string]any `json:"details,omitempty"` // Arbitrary technical metadata
+	Content   []ContentBlock `json:"content"`             // Ordered blocks of result data
+	Details   map[string]any `json:"details,omitempty"`   // Arbitrary technical metadata
+	Directive string         `json:"directive,omitempty"` // Instruction for the next LLM turn, never shown to the user
 }
 
 // ContentBlock is an atomic data unit within a ToolResult.
 // It is designed to be converted into llm.ContentBlocks by the handler.
 type ContentBlock struct {
-	Type     string `json:"type"`                // Data format: "text" or "image"
-	Text     string `json:"text,omitempty"`      // String content (for text type)
-	Data     string `json:"data,omitempty"`      // Base64 encoded image data (for image type)
-	MimeType string `json:"mime_type,omitempty"` // MIME type for image data (e.g., "image/jpeg")
+	Type     string     `json:"type"`                // Data format: "text", "image" or "table"
+	Text     string     `json:"text,omitempty"`      // String content (for text type)
+	Data     string     `json:"data,omitempty"`      // Base64 encoded image data (for image type)
+	MimeType string     `json:"mime_type,omitempty"` // MIME type for image data (e.g., "image/jpeg")
+	Table    *llm.Table `json:"table,omitempty"`     // Headers and rows (for table type)
 }
 
 // ToolRegistry defines the interface for managing and accessing tools.
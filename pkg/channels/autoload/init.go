@@ -1,6 +1,8 @@
 package autoload
 
 import (
+	_ "genesis/pkg/channels/irc"
+	_ "genesis/pkg/channels/mattermost"
 	_ "genesis/pkg/channels/telegram"
 	_ "genesis/pkg/channels/web"
 )
@@ -0,0 +1,58 @@
+package irc
+
+import (
+	"fmt"
+	"genesis/pkg/api"
+	"genesis/pkg/channels"
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// IRCFactory implements the channels.ChannelFactory interface to
+// instantiate IRC communication adapters.
+type IRCFactory struct{}
+
+// Create parses the IRC-specific configuration and initializes an
+// IRCChannel instance.
+func (f *IRCFactory) Create(rawConfig jsoniter.RawMessage, sessions *llm.SessionManager, system *config.SystemConfig) (api.Channel, error) {
+	ircCfg, err := parseConfig(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewIRCChannel(ircCfg), nil
+}
+
+// Validate implements channels.ConfigValidator.
+func (f *IRCFactory) Validate(rawConfig jsoniter.RawMessage, system *config.SystemConfig) error {
+	_, err := parseConfig(rawConfig)
+	return err
+}
+
+// parseConfig decodes the channel configuration over the defaults and
+// checks it.
+func parseConfig(rawConfig jsoniter.RawMessage) (IRCConfig, error) {
+	var ircCfg IRCConfig
+	// Set default flood protection
+	ircCfg.FloodDelayMs = 1000
+	ircCfg.FloodBurst = 4
+
+	if err := json.Unmarshal(rawConfig, &ircCfg); err != nil {
+		return ircCfg, fmt.Errorf("failed to parse irc config: %w", err)
+	}
+
+	if ircCfg.Server == "" {
+		return ircCfg, fmt.Errorf("missing irc server")
+	}
+	if ircCfg.Nick == "" {
+		return ircCfg, fmt.Errorf("missing irc nick")
+	}
+	return ircCfg, nil
+}
+
+func init() {
+	channels.RegisterChannel("irc", &IRCFactory{})
+}
@@ -0,0 +1,399 @@
+package irc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"genesis/pkg/api"
+	"genesis/pkg/llm"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxLineBytes is the hard IRC protocol limit for a single line, including
+// the trailing CRLF (RFC 1459, section 2.3).
+const maxLineBytes = 512
+
+// prefixReserve is the number of bytes reserved for the ":nick!user@host "
+// prefix that the server prepends when relaying our message to other clients.
+const prefixReserve = 100
+
+// IRCConfig encapsulates the connection parameters required to join an
+// IRC network.
+type IRCConfig struct {
+	Server       string   `json:"server"`         // Address in "host:port" form (e.g., "irc.libera.chat:6697")
+	Nick         string   `json:"nick"`           // Nickname used by the bot
+	Channels     []string `json:"channels"`       // Channels to join after registration (e.g., ["#genesis"])
+	Password     string   `json:"password"`       // Optional server password (PASS)
+	TLS          bool     `json:"tls"`            // Connect using TLS
+	FloodDelayMs int      `json:"flood_delay_ms"` // Minimum delay between lines once the burst is spent. Default: 1000
+	FloodBurst   int      `json:"flood_burst"`    // Number of lines that can be sent back-to-back. Default: 4
+}
+
+// IRCChannel is the implementation of api.Channel for IRC networks.
+// It maps PRIVMSGs into UnifiedMessages, answering in channels only when
+// addressed by nick, and splits long replies into multiple protocol-safe
+// lines with flood protection.
+type IRCChannel struct {
+	config     IRCConfig          // Connection parameters
+	conn       net.Conn           // Active connection to the IRC server
+	writeMu    sync.Mutex         // Serializes writes and guards the flood bucket
+	tokens     int                // Remaining lines in the flood burst
+	lastRefill time.Time          // Last time the flood bucket was refilled
+	nickMu     sync.Mutex         // Guards nick
+	nick       string             // Current nickname, suffixed with "_" while the configured one is taken
+	stopCancel context.CancelFunc // Terminates the read/reconnect loop of the current Start
+}
+
+// NewIRCChannel creates an IRC channel with the given configuration.
+// The connection is established lazily in Start.
+func NewIRCChannel(cfg IRCConfig) *IRCChannel {
+	return &IRCChannel{
+		config:     cfg,
+		tokens:     cfg.FloodBurst,
+		lastRefill: time.Now(),
+		nick:       cfg.Nick,
+	}
+}
+
+// ID returns the unique platform identifier "irc".
+func (c *IRCChannel) ID() string {
+	return "irc"
+}
+
+// Start connects to the IRC server and runs the read loop in a background
+// goroutine. Lost connections are re-established automatically until Stop.
+func (c *IRCChannel) Start(ctx api.ChannelContext) error {
+	stopCtx, stopCancel := context.WithCancel(context.Background())
+	c.stopCancel = stopCancel
+
+	conn, err := c.connect()
+	if err != nil {
+		stopCancel()
+		return err
+	}
+
+	go func() {
+		for {
+			c.readLoop(ctx, conn)
+
+			select {
+			case <-stopCtx.Done():
+				return // Gracefully exit on shutdown
+			default:
+			}
+
+			slog.Warn("IRC connection lost, reconnecting", "server", c.config.Server)
+			for {
+				select {
+				case <-stopCtx.Done():
+					return
+				case <-time.After(5 * time.Second):
+				}
+				if conn, err = c.connect(); err == nil {
+					break
+				}
+				slog.Debug("Failed to reconnect to IRC", "error", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// connect dials the server and performs the registration handshake.
+func (c *IRCChannel) connect() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if c.config.TLS {
+		host, _, _ := net.SplitHostPort(c.config.Server)
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.config.Server, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", c.config.Server)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to irc server: %w", err)
+	}
+
+	c.writeMu.Lock()
+	c.conn = conn
+	c.writeMu.Unlock()
+
+	nick := c.currentNick()
+	if c.config.Password != "" {
+		c.writeLine("PASS " + c.config.Password)
+	}
+	c.writeLine("NICK " + nick)
+	c.writeLine(fmt.Sprintf("USER %s 0 * :Genesis", nick))
+
+	slog.Info("IRC connected", "server", c.config.Server, "nick", nick)
+	return conn, nil
+}
+
+// currentNick returns the nickname the bot registers and is addressed by.
+func (c *IRCChannel) currentNick() string {
+	c.nickMu.Lock()
+	defer c.nickMu.Unlock()
+	return c.nick
+}
+
+// readLoop consumes lines from the connection until it is closed.
+func (c *IRCChannel) readLoop(ctx api.ChannelContext, conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		prefix, command, params := parseLine(scanner.Text())
+
+		switch command {
+		case "PING":
+			c.writeLine("PONG :" + strings.Join(params, " "))
+
+		case "001": // RPL_WELCOME: registration complete, join channels
+			for _, ch := range c.config.Channels {
+				c.writeLine("JOIN " + ch)
+			}
+
+		case "433": // ERR_NICKNAMEINUSE
+			c.nickMu.Lock()
+			c.nick += "_"
+			nick := c.nick
+			c.nickMu.Unlock()
+			c.writeLine("NICK " + nick)
+
+		case "PRIVMSG":
+			if len(params) < 2 {
+				continue
+			}
+			nick := prefix
+			if i := strings.Index(nick, "!"); i != -1 {
+				nick = nick[:i]
+			}
+
+			// Channel messages reply to the channel; private messages reply to the sender
+			target := params[0]
+			isChannel := strings.HasPrefix(target, "#") || strings.HasPrefix(target, "&")
+			if !isChannel {
+				target = nick
+			}
+
+			// Ignore CTCP requests (e.g., VERSION, ACTION)
+			text := params[1]
+			if strings.HasPrefix(text, "\x01") {
+				continue
+			}
+
+			// In channels, only messages addressed to the bot are answered
+			if isChannel {
+				var ok bool
+				if text, ok = stripMention(text, c.currentNick()); !ok {
+					continue
+				}
+			}
+
+			msg := &api.UnifiedMessage{
+				Session: api.SessionContext{
+					ChannelID: "irc",
+					UserID:    nick,
+					ChatID:    target,
+					Username:  nick,
+					IsGroup:   isChannel,
+				},
+				Content: text,
+			}
+			ctx.OnMessage(c.ID(), msg)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		slog.Debug("IRC read loop ended", "error", err)
+	}
+}
+
+// stripMention reports whether text addresses nick, either as a leading
+// "nick: " / "nick, " or by mentioning it anywhere, and returns the text
+// without the leading address.
+func stripMention(text, nick string) (string, bool) {
+	if nick == "" {
+		return text, false
+	}
+	if len(text) > len(nick) && strings.EqualFold(text[:len(nick)], nick) {
+		rest := text[len(nick):]
+		if rest[0] == ':' || rest[0] == ',' {
+			return strings.TrimSpace(rest[1:]), true
+		}
+	}
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_[]\\`^{}|", r)
+	}) {
+		if strings.EqualFold(word, nick) {
+			return text, true
+		}
+	}
+	return text, false
+}
+
+// parseLine splits a raw IRC line into its prefix, command and parameters.
+// The trailing parameter (after " :") is returned as the last element.
+func parseLine(line string) (prefix, command string, params []string) {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, ":") {
+		i := strings.Index(line, " ")
+		if i == -1 {
+			return line[1:], "", nil
+		}
+		prefix, line = line[1:i], line[i+1:]
+	}
+
+	var trailing string
+	hasTrailing := false
+	if i := strings.Index(line, " :"); i != -1 {
+		trailing, line = line[i+2:], line[:i]
+		hasTrailing = true
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return prefix, "", nil
+	}
+	command, params = strings.ToUpper(fields[0]), fields[1:]
+	if hasTrailing {
+		params = append(params, trailing)
+	}
+	return prefix, command, params
+}
+
+// writeLine sends a single raw line to the server, applying flood protection.
+func (c *IRCChannel) writeLine(line string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.conn == nil {
+		return fmt.Errorf("irc not connected")
+	}
+
+	c.waitForToken()
+	_, err := c.conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+// waitForToken implements a simple token bucket: up to FloodBurst lines may
+// be sent immediately, after which one line is allowed per FloodDelayMs.
+// Caller must hold writeMu.
+func (c *IRCChannel) waitForToken() {
+	delay := time.Duration(c.config.FloodDelayMs) * time.Millisecond
+	if delay <= 0 {
+		return
+	}
+
+	if refill := int(time.Since(c.lastRefill) / delay); refill > 0 {
+		c.tokens = min(c.tokens+refill, max(c.config.FloodBurst, 1))
+		c.lastRefill = c.lastRefill.Add(time.Duration(refill) * delay)
+	}
+
+	if c.tokens <= 0 {
+		time.Sleep(delay - time.Since(c.lastRefill))
+		c.lastRefill = time.Now()
+		return
+	}
+	c.tokens--
+}
+
+func (c *IRCChannel) Stop() error {
+	if c.stopCancel != nil {
+		c.stopCancel()
+	}
+
+	c.writeMu.Lock()
+	conn := c.conn
+	c.writeMu.Unlock()
+
+	if conn != nil {
+		conn.Write([]byte("QUIT :Bye\r\n"))
+		return conn.Close()
+	}
+	return nil
+}
+
+// Send delivers a message to the session's channel or nick, splitting it
+// on newlines and then into chunks that fit within the IRC line limit.
+func (c *IRCChannel) Send(session api.SessionContext, message string) error {
+	header := fmt.Sprintf("PRIVMSG %s :", session.ChatID)
+	limit := maxLineBytes - prefixReserve - len(header) - len("\r\n")
+
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		for _, chunk := range splitBytes(line, limit) {
+			if err := c.writeLine(header + chunk); err != nil {
+				return fmt.Errorf("irc send failed: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// splitBytes splits s into pieces of at most limit bytes without breaking
+// UTF-8 sequences, preferring to cut at the last space within the limit.
+func splitBytes(s string, limit int) []string {
+	var chunks []string
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if space := strings.LastIndex(s[:cut], " "); space > limit/2 {
+			cut = space
+		}
+		chunks = append(chunks, s[:cut])
+		s = strings.TrimLeft(s[cut:], " ")
+	}
+	if s != "" {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
+
+// SendSignal implements the api.SignalingChannel interface.
+// IRC has no typing indicators, so signals are ignored.
+func (c *IRCChannel) SendSignal(session api.SessionContext, signal string) error {
+	return nil
+}
+
+// Stream implements the streaming response protocol for IRC.
+// Since flooding a channel with partial lines is considered rude, text is
+// aggregated and sent once the stream ends. Thinking blocks are not relayed.
+func (c *IRCChannel) Stream(session api.SessionContext, blocks <-chan llm.ContentBlock) error {
+	var textBuf strings.Builder
+
+	for block := range blocks {
+		switch block.Type {
+		case llm.BlockTypeText, llm.BlockTypeError:
+			textBuf.WriteString(block.Text)
+		case llm.BlockTypeTable:
+			// Text already holds the aligned plain-text rendering
+			textBuf.WriteString("\n" + block.Text)
+		case llm.BlockTypeImage:
+			if block.Source != nil && block.Source.Type == "url" {
+				textBuf.WriteString("\n" + block.Source.URL + "\n")
+			} else {
+				textBuf.WriteString("\n[image]\n")
+			}
+		}
+	}
+
+	if textBuf.Len() > 0 {
+		return c.Send(session, textBuf.String())
+	}
+	return nil
+}
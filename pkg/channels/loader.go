@@ -1,10 +1,13 @@
 package channels
 
 import (
+	"fmt"
 	"genesis/pkg/api"
 	"genesis/pkg/config"
 	"genesis/pkg/llm"
 	"log/slog"
+	"slices"
+	"strings"
 
 	jsoniter "github.com/json-iterator/go"
 )
@@ -53,3 +56,30 @@ func (s *Source) Load() []api.Channel {
 	}
 	return result
 }
+
+// ChannelCheck is the outcome of checking one configured channel.
+type ChannelCheck struct {
+	Name      string // Channel name as configured
+	Validated bool   // False if the factory cannot check its config offline
+	Err       error  // Why the channel would fail to be created, if it would
+}
+
+// Check validates the configuration of every channel without creating it,
+// so no platform is contacted. Results are sorted by channel name.
+func (s *Source) Check() []ChannelCheck {
+	var result []ChannelCheck
+	for name, rawConfig := range s.configs {
+		check := ChannelCheck{Name: name}
+		factory, ok := GetChannelFactory(name)
+		switch v, canValidate := factory.(ConfigValidator); {
+		case !ok:
+			check.Err = fmt.Errorf("unknown channel type")
+		case canValidate:
+			check.Validated = true
+			check.Err = v.Validate(rawConfig, s.system)
+		}
+		result = append(result, check)
+	}
+	slices.SortFunc(result, func(a, b ChannelCheck) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
@@ -0,0 +1,53 @@
+package mattermost
+
+import (
+	"fmt"
+	"genesis/pkg/api"
+	"genesis/pkg/channels"
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// MattermostFactory implements the channels.ChannelFactory interface to
+// instantiate Mattermost communication adapters.
+type MattermostFactory struct{}
+
+// Create parses the Mattermost-specific configuration and initializes a
+// MattermostChannel instance with synchronized system-level timeouts.
+func (f *MattermostFactory) Create(rawConfig jsoniter.RawMessage, sessions *llm.SessionManager, system *config.SystemConfig) (api.Channel, error) {
+	mmCfg, err := parseConfig(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewMattermostChannel(mmCfg, system.DownloadTimeoutMs, system.DataPath("attachments"))
+}
+
+// Validate implements channels.ConfigValidator.
+func (f *MattermostFactory) Validate(rawConfig jsoniter.RawMessage, system *config.SystemConfig) error {
+	_, err := parseConfig(rawConfig)
+	return err
+}
+
+// parseConfig decodes and checks the channel configuration.
+func parseConfig(rawConfig jsoniter.RawMessage) (MattermostConfig, error) {
+	var mmCfg MattermostConfig
+	if err := json.Unmarshal(rawConfig, &mmCfg); err != nil {
+		return mmCfg, fmt.Errorf("failed to parse mattermost config: %w", err)
+	}
+
+	if mmCfg.ServerURL == "" {
+		return mmCfg, fmt.Errorf("missing mattermost server url")
+	}
+	if mmCfg.Token == "" {
+		return mmCfg, fmt.Errorf("missing mattermost token")
+	}
+	return mmCfg, nil
+}
+
+func init() {
+	channels.RegisterChannel("mattermost", &MattermostFactory{})
+}
@@ -0,0 +1,478 @@
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"genesis/pkg/api"
+	"genesis/pkg/llm"
+	"genesis/pkg/utils"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MattermostConfig encapsulates the credentials required to authenticate
+// with a Mattermost server as a bot or personal access token user.
+type MattermostConfig struct {
+	ServerURL string `json:"server_url"` // Base URL of the server (e.g., "https://chat.example.com")
+	Token     string `json:"token"`      // Bot or personal access token
+}
+
+// messageLimit is slightly below Mattermost's default 16383 character post limit.
+const messageLimit = 16000
+
+// post mirrors the subset of the Mattermost Post object used by the channel.
+type post struct {
+	ID        string   `json:"id,omitempty"`
+	ChannelID string   `json:"channel_id"`
+	RootID    string   `json:"root_id,omitempty"`
+	UserID    string   `json:"user_id,omitempty"`
+	Message   string   `json:"message"`
+	FileIDs   []string `json:"file_ids,omitempty"`
+}
+
+// wsEvent mirrors a Mattermost WebSocket event envelope.
+type wsEvent struct {
+	Event string         `json:"event"`
+	Data  map[string]any `json:"data"`
+}
+
+// MattermostChannel is the implementation of api.Channel for Mattermost.
+// Incoming posts are received over the WebSocket API and replies are sent
+// through the REST API. Every thread maps to one session, and replies to a
+// top-level channel post open a thread under it; direct messages share the
+// session of their channel.
+type MattermostChannel struct {
+	config      MattermostConfig   // Auth credentials
+	baseURL     string             // Normalized server URL without trailing slash
+	botUserID   string             // User ID of the bot, used to ignore its own posts
+	httpClient  *http.Client       // Client for REST calls and file downloads
+	attachments string             // Directory downloaded files are saved to
+	ws          *websocket.Conn    // Active WebSocket connection
+	wsMu        sync.Mutex         // Serializes WebSocket writes
+	seq         int64              // Sequence number for WebSocket actions
+	stopCancel  context.CancelFunc // Terminates the event loop of the current Start
+}
+
+// NewMattermostChannel creates a Mattermost channel and verifies the token
+// by resolving the bot's own user ID.
+func NewMattermostChannel(cfg MattermostConfig, timeoutMs int, attachmentsDir string) (*MattermostChannel, error) {
+	c := &MattermostChannel{
+		config:      cfg,
+		baseURL:     strings.TrimRight(cfg.ServerURL, "/"),
+		attachments: attachmentsDir,
+		httpClient: &http.Client{
+			Timeout: time.Duration(timeoutMs) * time.Millisecond,
+		},
+	}
+
+	var me struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	if err := c.apiRequest(http.MethodGet, "/api/v4/users/me", nil, &me); err != nil {
+		return nil, fmt.Errorf("failed to authorize mattermost bot: %w", err)
+	}
+	c.botUserID = me.ID
+
+	slog.Info("Mattermost bot authorized", "username", me.Username)
+	return c, nil
+}
+
+// ID returns the unique platform identifier "mattermost".
+func (c *MattermostChannel) ID() string {
+	return "mattermost"
+}
+
+// Start opens the WebSocket connection and processes events in a background
+// goroutine, reconnecting automatically until Stop is called.
+func (c *MattermostChannel) Start(ctx api.ChannelContext) error {
+	stopCtx, stopCancel := context.WithCancel(context.Background())
+	c.stopCancel = stopCancel
+
+	if err := c.connect(stopCtx); err != nil {
+		stopCancel()
+		return err
+	}
+
+	go func() {
+		for {
+			c.readLoop(stopCtx, ctx)
+
+			select {
+			case <-stopCtx.Done():
+				return // Gracefully exit on shutdown
+			case <-time.After(3 * time.Second):
+			}
+
+			if err := c.connect(stopCtx); err != nil {
+				slog.Debug("Failed to reconnect to mattermost", "error", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// connect establishes the authenticated WebSocket connection.
+func (c *MattermostChannel) connect(stopCtx context.Context) error {
+	wsURL := "ws" + strings.TrimPrefix(c.baseURL, "http") + "/api/v4/websocket"
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.config.Token)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(stopCtx, wsURL, header)
+	if err != nil {
+		return fmt.Errorf("failed to connect mattermost websocket: %w", err)
+	}
+
+	c.wsMu.Lock()
+	c.ws = conn
+	c.wsMu.Unlock()
+	return nil
+}
+
+// readLoop consumes WebSocket events until the connection drops.
+func (c *MattermostChannel) readLoop(stopCtx context.Context, ctx api.ChannelContext) {
+	c.wsMu.Lock()
+	conn := c.ws
+	c.wsMu.Unlock()
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		var event wsEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			select {
+			case <-stopCtx.Done():
+			default:
+				slog.Debug("Mattermost websocket read failed", "error", err)
+			}
+			return
+		}
+
+		if event.Event != "posted" {
+			continue
+		}
+
+		rawPost, ok := event.Data["post"].(string)
+		if !ok {
+			continue
+		}
+		var p post
+		if err := json.Unmarshal([]byte(rawPost), &p); err != nil {
+			slog.Error("Failed to parse mattermost post", "error", err)
+			continue
+		}
+		if p.UserID == c.botUserID {
+			continue
+		}
+
+		username, _ := event.Data["sender_name"].(string)
+		channelType, _ := event.Data["channel_type"].(string) // "D" for direct messages
+
+		// Every thread gets its own session and replies: a top-level channel
+		// post starts a thread rooted at itself. DMs are a single
+		// conversation, so their top-level posts are answered in place
+		chatID := p.ChannelID
+		rootID := p.RootID
+		if rootID == "" && channelType != "D" {
+			rootID = p.ID
+		}
+		if rootID != "" {
+			chatID += ":" + rootID
+		}
+		session := api.SessionContext{
+			ChannelID: "mattermost",
+			UserID:    p.UserID,
+			ChatID:    chatID,
+			Username:  strings.TrimPrefix(username, "@"),
+			IsGroup:   channelType != "D",
+		}
+
+		if len(p.FileIDs) == 0 {
+			ctx.OnMessage(c.ID(), &api.UnifiedMessage{Session: session, Content: p.Message})
+			continue
+		}
+
+		// Download attachments asynchronously to avoid blocking the event loop
+		go func(s api.SessionContext, text string, fileIDs []string) {
+			var files []api.FileAttachment
+			for _, id := range fileIDs {
+				file, err := c.downloadFile(id)
+				if err != nil {
+					slog.Error("Mattermost file download failed", "file_id", id, "error", err)
+					continue
+				}
+				files = append(files, *file)
+			}
+			ctx.OnMessage(c.ID(), &api.UnifiedMessage{Session: s, Content: text, Files: files})
+		}(session, p.Message, p.FileIDs)
+	}
+}
+
+// downloadFile fetches an attachment and streams it to the attachments directory.
+func (c *MattermostChannel) downloadFile(fileID string) (*api.FileAttachment, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/api/v4/files/"+fileID, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status code %d", resp.StatusCode)
+	}
+
+	// Named by content, so a file posted twice is stored once
+	localPath, mimeType, err := utils.SaveAttachmentFrom(c.attachments, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.FileAttachment{
+		Filename: filepath.Base(localPath),
+		MimeType: mimeType,
+		Path:     localPath,
+	}, nil
+}
+
+// apiRequest performs an authenticated JSON REST call.
+func (c *MattermostChannel) apiRequest(method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("mattermost api %s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// splitChatID recovers the Mattermost channel ID and thread root from a
+// session ChatID. The root is empty for direct-message sessions outside
+// threads.
+func splitChatID(chatID string) (channelID, rootID string) {
+	channelID, rootID, _ = strings.Cut(chatID, ":")
+	return channelID, rootID
+}
+
+func (c *MattermostChannel) Stop() error {
+	if c.stopCancel != nil {
+		c.stopCancel()
+	}
+
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+	if c.ws != nil {
+		return c.ws.Close()
+	}
+	return nil
+}
+
+// Send posts a reply, splitting messages that exceed the post limit.
+func (c *MattermostChannel) Send(session api.SessionContext, message string) error {
+	return c.sendPosts(session, message, nil)
+}
+
+// sendPosts posts message in the session's channel or thread, split into
+// posts within messageLimit. Files are attached to the last post, so they
+// follow the text they belong to.
+func (c *MattermostChannel) sendPosts(session api.SessionContext, message string, fileIDs []string) error {
+	channelID, rootID := splitChatID(session.ChatID)
+
+	parts := splitMessage(message)
+	if len(parts) == 0 && len(fileIDs) > 0 {
+		parts = []string{""}
+	}
+	for i, part := range parts {
+		p := post{
+			ChannelID: channelID,
+			RootID:    rootID,
+			Message:   part,
+		}
+		if i == len(parts)-1 {
+			p.FileIDs = fileIDs
+		}
+		if err := c.apiRequest(http.MethodPost, "/api/v4/posts", p, nil); err != nil {
+			return fmt.Errorf("mattermost send failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// splitMessage splits message into pieces of at most messageLimit runes.
+func splitMessage(message string) []string {
+	var parts []string
+	msgRunes := []rune(message)
+	for i := 0; i < len(msgRunes); i += messageLimit {
+		end := min(i+messageLimit, len(msgRunes))
+		parts = append(parts, string(msgRunes[i:end]))
+	}
+	return parts
+}
+
+// SendSignal implements the api.SignalingChannel interface by sending a
+// typing indicator for the "thinking" signal.
+func (c *MattermostChannel) SendSignal(session api.SessionContext, signal string) error {
+	if signal != llm.BlockTypeThinking {
+		return nil
+	}
+
+	channelID, rootID := splitChatID(session.ChatID)
+
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+	if c.ws == nil {
+		return fmt.Errorf("mattermost websocket not connected")
+	}
+
+	c.seq++
+	return c.ws.WriteJSON(map[string]any{
+		"seq":    c.seq,
+		"action": "user_typing",
+		"data": map[string]string{
+			"channel_id": channelID,
+			"parent_id":  rootID,
+		},
+	})
+}
+
+// uploadImage uploads an image block and returns the resulting file ID.
+func (c *MattermostChannel) uploadImage(channelID string, block llm.ContentBlock) (string, error) {
+	if block.Source == nil {
+		return "", fmt.Errorf("image source is nil")
+	}
+
+	data := block.Source.Data
+	if len(data) == 0 && block.Source.Type == "file" && block.Source.Path != "" {
+		var err error
+		if data, err = os.ReadFile(block.Source.Path); err != nil {
+			return "", fmt.Errorf("failed to read image: %w", err)
+		}
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("unsupported image source type: %s", block.Source.Type)
+	}
+
+	_, ext := utils.DetectMimeAndExt(data)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("channel_id", channelID)
+	part, err := writer.CreateFormFile("files", "image"+ext)
+	if err != nil {
+		return "", err
+	}
+	part.Write(data)
+	writer.Close()
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/api/v4/files", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("file upload failed: status code %d", resp.StatusCode)
+	}
+
+	var result struct {
+		FileInfos []struct {
+			ID string `json:"id"`
+		} `json:"file_infos"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if len(result.FileInfos) == 0 {
+		return "", fmt.Errorf("file upload returned no file info")
+	}
+	return result.FileInfos[0].ID, nil
+}
+
+// Stream implements the streaming response protocol for Mattermost.
+// Text is accumulated and posted once the stream ends; images are uploaded
+// and attached to the final post so they follow the text.
+func (c *MattermostChannel) Stream(session api.SessionContext, blocks <-chan llm.ContentBlock) error {
+	var thinkingBuf strings.Builder
+	var textBuf strings.Builder
+	var fileIDs []string
+
+	channelID, _ := splitChatID(session.ChatID)
+
+	for block := range blocks {
+		switch block.Type {
+		case llm.BlockTypeThinking:
+			thinkingBuf.WriteString(block.Text)
+		case llm.BlockTypeText, llm.BlockTypeError:
+			textBuf.WriteString(block.Text)
+		case llm.BlockTypeTable:
+			// Mattermost renders Markdown tables natively
+			if block.Table != nil {
+				textBuf.WriteString("\n" + block.Table.Markdown())
+			}
+		case llm.BlockTypeImage:
+			id, err := c.uploadImage(channelID, block)
+			if err != nil {
+				slog.Error("Failed to upload image", "error", err)
+				continue
+			}
+			fileIDs = append(fileIDs, id)
+		}
+	}
+
+	if thinkingBuf.Len() > 0 {
+		thinkingMsg := utils.ReasoningHeader() + thinkingBuf.String()
+		if err := c.Send(session, thinkingMsg); err != nil {
+			slog.Error("Failed to send thinking", "error", err)
+		}
+	}
+
+	return c.sendPosts(session, textBuf.String(), fileIDs)
+}
@@ -17,6 +17,13 @@ type ChannelFactory interface {
 	Create(rawConfig jsoniter.RawMessage, sessions *llm.SessionManager, system *config.SystemConfig) (api.Channel, error)
 }
 
+// ConfigValidator is an optional extension of ChannelFactory. Create usually
+// connects to the platform (e.g., to authorize the bot); Validate performs
+// the same configuration checks without any network access, for dry runs.
+type ConfigValidator interface {
+	Validate(rawConfig jsoniter.RawMessage, system *config.SystemConfig) error
+}
+
 // channelRegistry is an internal global map stores the mapping between
 // platform names (e.g., "telegram") and their factory implementations.
 var channelRegistry = make(map[string]ChannelFactory)
@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"fmt"
+	"genesis/pkg/api"
+	"log/slog"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	maxCallbackData = 64 // Telegram's limit on callback_data, in bytes
+	buttonsPerRow   = 3  // Inline keyboard row width
+)
+
+// Compile-time check that the channel renders quick-reply buttons.
+var _ api.InteractiveChannel = (*TelegramChannel)(nil)
+
+// SendWithActions implements api.InteractiveChannel by sending text with an
+// inline keyboard. A press arrives as a callback query and is forwarded by
+// handleCallback as a message carrying the action's Value.
+func (t *TelegramChannel) SendWithActions(session api.SessionContext, text string, actions []api.Action) error {
+	chatID, err := strconv.ParseInt(session.ChatID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid chat id for telegram: %s", session.ChatID)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, a := range actions {
+		if len(a.Value) > maxCallbackData {
+			return fmt.Errorf("action %q: value exceeds %d bytes", a.Label, maxCallbackData)
+		}
+		if i%buttonsPerRow == 0 {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], tgbotapi.NewInlineKeyboardButtonData(a.Label, a.Value))
+	}
+
+	// Text beyond the message limit goes out first, so the keyboard stays
+	// attached to the final chunk right above the user's input
+	runes := []rune(text)
+	if len(runes) > t.messageLimit {
+		cut := len(runes) - t.messageLimit
+		if err := t.Send(session, string(runes[:cut])); err != nil {
+			return err
+		}
+		text = string(runes[cut:])
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if len(rows) > 0 {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	if err := t.sendWithRetry(msg); err != nil {
+		return fmt.Errorf("telegram send failed: %w", err)
+	}
+	return nil
+}
+
+// handleCallback turns a button press into a message from the pressing
+// user. The query is answered to stop the client's loading spinner and the
+// keyboard is removed so the choice cannot be submitted twice.
+func (t *TelegramChannel) handleCallback(ctx api.ChannelContext, q *tgbotapi.CallbackQuery) {
+	if _, err := t.bot.Request(tgbotapi.NewCallback(q.ID, "")); err != nil {
+		slog.Debug("Failed to answer telegram callback query", "error", err)
+	}
+
+	// Queries from inline-mode messages have no chat to reply to
+	if q.From == nil || q.Message == nil || q.Message.Chat == nil || q.Data == "" {
+		return
+	}
+
+	remove := tgbotapi.NewEditMessageReplyMarkup(q.Message.Chat.ID, q.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := t.bot.Request(remove); err != nil {
+		slog.Debug("Failed to remove telegram inline keyboard", "error", err)
+	}
+
+	ctx.OnMessage(t.ID(), &api.UnifiedMessage{
+		Session: api.SessionContext{
+			ChannelID: "telegram",
+			UserID:    strconv.FormatInt(q.From.ID, 10),
+			ChatID:    strconv.FormatInt(q.Message.Chat.ID, 10),
+			Username:  q.From.UserName,
+			IsGroup:   q.Message.Chat.IsGroup() || q.Message.Chat.IsSuperGroup(),
+		},
+		Content: q.Data,
+		Raw:     q,
+	})
+}
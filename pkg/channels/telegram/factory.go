@@ -19,16 +19,54 @@ type TelegramFactory struct{}
 // Create parses the channel-specific configuration and initializes a
 // TelegramChannel instance with synchronized system-level timeouts.
 func (f *TelegramFactory) Create(rawConfig jsoniter.RawMessage, sessions *llm.SessionManager, system *config.SystemConfig) (api.Channel, error) {
+	tgCfg, err := parseConfig(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewTelegramChannel(tgCfg, system.TelegramMessageLimit, system.DownloadTimeoutMs, system.DataPath("attachments"))
+}
+
+// Validate implements channels.ConfigValidator.
+func (f *TelegramFactory) Validate(rawConfig jsoniter.RawMessage, system *config.SystemConfig) error {
+	_, err := parseConfig(rawConfig)
+	return err
+}
+
+// parseConfig decodes the channel configuration over the defaults and
+// checks it.
+func parseConfig(rawConfig jsoniter.RawMessage) (TelegramConfig, error) {
 	var tgCfg TelegramConfig
+	// Set default send retry policy
+	tgCfg.SendRetries = 3
+	tgCfg.SendRetryDelayMs = 1000
+	// Set default polling backoff
+	tgCfg.PollRetryDelayMs = 1000
+	tgCfg.PollRetryMaxDelayMs = 60000
+	tgCfg.PollRetryJitter = 0.2
+	tgCfg.Mode = ModePolling
+	tgCfg.WebhookListen = ":8443"
+	// Set default update processing concurrency
+	tgCfg.Workers = 4
+	tgCfg.WorkerQueueSize = 64
+
 	if err := json.Unmarshal(rawConfig, &tgCfg); err != nil {
-		return nil, fmt.Errorf("failed to parse telegram config: %w", err)
+		return tgCfg, fmt.Errorf("failed to parse telegram config: %w", err)
 	}
 
 	if tgCfg.Token == "" {
-		return nil, fmt.Errorf("missing telegram token")
+		return tgCfg, fmt.Errorf("missing telegram token")
 	}
 
-	return NewTelegramChannel(tgCfg, system.TelegramMessageLimit, system.DownloadTimeoutMs)
+	switch tgCfg.Mode {
+	case "", ModePolling:
+	case ModeWebhook:
+		if tgCfg.WebhookURL == "" {
+			return tgCfg, fmt.Errorf("telegram webhook mode requires webhook_url")
+		}
+	default:
+		return tgCfg, fmt.Errorf("unknown telegram mode %q (expected %q or %q)", tgCfg.Mode, ModePolling, ModeWebhook)
+	}
+	return tgCfg, nil
 }
 
 func init() {
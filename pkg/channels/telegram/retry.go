@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendWithRetry delivers a Chattable, retrying transient failures (flood
+// control, server errors, network blips) with exponential backoff.
+// Telegram's retry_after hint takes precedence over the computed delay.
+func (t *TelegramChannel) sendWithRetry(c tgbotapi.Chattable) error {
+	delay := time.Duration(t.config.SendRetryDelayMs) * time.Millisecond
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if _, err = t.bot.Send(c); err == nil {
+			return nil
+		}
+
+		wait, transient := retryDelay(err, delay)
+		if !transient || attempt >= t.config.SendRetries {
+			return err
+		}
+
+		slog.Warn("Telegram send failed, retrying", "attempt", attempt+1, "max", t.config.SendRetries, "wait", wait, "error", err)
+		select {
+		case <-t.stopContext().Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// pollBackoff returns how long to wait after the given number of
+// consecutive polling failures: the initial delay doubled per failure,
+// capped at the maximum and randomized by the configured jitter so that
+// restarted instances do not poll in lockstep.
+func (t *TelegramChannel) pollBackoff(failures int) time.Duration {
+	base := time.Duration(max(t.config.PollRetryDelayMs, 1)) * time.Millisecond
+	maxDelay := max(time.Duration(t.config.PollRetryMaxDelayMs)*time.Millisecond, base)
+
+	// Cap the shift so the delay cannot overflow after a long outage
+	wait := min(base<<min(max(failures-1, 0), 20), maxDelay)
+
+	if jitter := min(max(t.config.PollRetryJitter, 0), 1); jitter > 0 {
+		wait += time.Duration((rand.Float64()*2 - 1) * jitter * float64(wait))
+	}
+	return wait
+}
+
+// errPollConflict is reported by Health while Telegram keeps answering
+// GetUpdates with 409 Conflict.
+var errPollConflict = errors.New("another bot instance is polling with this token")
+
+// confirmedConflicts is the number of consecutive 409 responses after which
+// the conflict is attributed to another instance. A single 409 is expected
+// right after a reload, while the previous long-poll is still open.
+const confirmedConflicts = 2
+
+// isConflict reports whether err is Telegram's 409 Conflict, returned when
+// two clients call getUpdates for the same bot (or a webhook is set).
+func isConflict(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == 409
+}
+
+// conflictBackoff stretches the wait after a 409 to the configured maximum
+// and at least one long-poll timeout, so a stale poll of a previous
+// instance has expired before the next attempt.
+func (t *TelegramChannel) conflictBackoff(wait time.Duration) time.Duration {
+	return max(wait, time.Duration(t.config.PollRetryMaxDelayMs)*time.Millisecond, pollTimeout*time.Second)
+}
+
+// retryDelay classifies a send error and returns how long to wait before
+// retrying it. Client errors such as "bot was blocked" are not transient.
+func retryDelay(err error, fallback time.Duration) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.RetryAfter > 0:
+			return time.Duration(apiErr.RetryAfter) * time.Second, true
+		case apiErr.Code == 429 || apiErr.Code >= 500:
+			return fallback, true
+		default:
+			return 0, false
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return fallback, true
+	}
+	return 0, false
+}
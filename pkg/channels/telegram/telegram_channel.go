@@ -6,12 +6,9 @@ import (
 	"genesis/pkg/api"
 	"genesis/pkg/llm"
 	"genesis/pkg/utils"
-	"io"
 	"log/slog"
 	"net"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,7 +20,18 @@ import (
 // TelegramConfig encapsulates the credentials required to authenticate with
 // the Telegram Bot API.
 type TelegramConfig struct {
-	Token string `json:"token"` // The secret BOT API string provided by @BotFather
+	Token               string  `json:"token"`                   // The secret BOT API string provided by @BotFather
+	SendRetries         int     `json:"send_retries"`            // Retries for transient send failures (429, 5xx, network). Default: 3
+	SendRetryDelayMs    int     `json:"send_retry_delay_ms"`     // Initial backoff between retries, doubled each attempt. Default: 1000
+	PollRetryDelayMs    int     `json:"poll_retry_delay_ms"`     // Initial wait after a failed poll, doubled per consecutive failure. Default: 1000
+	PollRetryMaxDelayMs int     `json:"poll_retry_max_delay_ms"` // Upper bound of the poll retry wait. Default: 60000
+	PollRetryJitter     float64 `json:"poll_retry_jitter"`       // Random fraction (0-1) added to or removed from each wait. Default: 0.2
+	Mode                string  `json:"mode"`                    // Update delivery: "polling" or "webhook". Default: polling
+	WebhookURL          string  `json:"webhook_url"`             // Public HTTPS URL Telegram posts updates to (webhook mode)
+	WebhookListen       string  `json:"webhook_listen"`          // Local address of the webhook server. Default: ":8443"
+	WebhookSecret       string  `json:"webhook_secret"`          // Secret Telegram sends with each update; other requests are rejected
+	Workers             int     `json:"workers"`                 // Goroutines processing updates concurrently, one chat per worker at a time. Default: 4
+	WorkerQueueSize     int     `json:"worker_queue_size"`       // Updates buffered per worker before intake waits. Default: 64
 }
 
 // TelegramChannel is the production implementation of gateway.Channel for
@@ -36,11 +44,30 @@ type TelegramChannel struct {
 	messageLimit int                          // Maximum character count per single message bubble
 	mediaGroups  map[string]*mediaGroupBuffer // Buffer for grouping multiple images sent together
 	httpClient   *http.Client                 // Client for downloading remote media from Telegram
+	attachments  string                       // Directory downloaded media is saved to
+	statusMsgs   map[int64]int                // Transient status message IDs per chat, removed when the work ends
 	mu           sync.Mutex                   // Protects concurrent access to internal buffers
 	stopCtx      context.Context              // Context used to forcibly abort the long-polling HTTP request
 	stopCancel   context.CancelFunc           // Function to trigger the abort
+	pollFailures int                          // Consecutive failed GetUpdates calls
+	lastPollErr  error                        // Most recent GetUpdates error
+	conflicts    int                          // Consecutive 409 Conflict responses to GetUpdates
+	webhook      *http.Server                 // Webhook server, in webhook mode
+	queues       []chan tgbotapi.Update       // Per-worker update queues; a chat always maps to the same worker
+	serveErr     error                        // Set when the webhook server stopped unexpectedly
+	nextOffset   int                          // Next update ID to request; kept across restarts
 }
 
+// unhealthyPollFailures is the number of consecutive polling failures after
+// which the channel reports itself unhealthy.
+const unhealthyPollFailures = 5
+
+// pollTimeout is the long-polling timeout of GetUpdates, in seconds.
+const pollTimeout = 60
+
+// allowedUpdates limits polling to the update types handleUpdate knows.
+var allowedUpdates = []string{"message", "edited_message", "callback_query"}
+
 // mediaGroupBuffer aggregates multiple incoming messages marked with the
 // same MediaGroupID into a single UnifiedMessage. This ensures multi-image
 // posts are processed as a single atomic context by the AI.
@@ -51,8 +78,20 @@ type mediaGroupBuffer struct {
 	timer    *time.Timer        // Debounce timer for finishing the group
 }
 
-func NewTelegramChannel(cfg TelegramConfig, msgLimit int, timeoutMs int) (api.Channel, error) {
+func NewTelegramChannel(cfg TelegramConfig, msgLimit int, timeoutMs int, attachmentsDir string) (api.Channel, error) {
 	ctx, cancel := context.WithCancel(context.Background())
+	t := &TelegramChannel{
+		config:       cfg,
+		messageLimit: msgLimit,
+		attachments:  attachmentsDir,
+		mediaGroups:  make(map[string]*mediaGroupBuffer),
+		statusMsgs:   make(map[int64]int),
+		httpClient: &http.Client{
+			Timeout: time.Duration(timeoutMs) * time.Millisecond,
+		},
+		stopCtx:    ctx,
+		stopCancel: cancel,
+	}
 
 	// Create a dedicated HTTP client for the bot so we can forcefully close it on reload
 	// By tying the DialContext to our stopCtx, active long-polling requests will be
@@ -66,11 +105,13 @@ func NewTelegramChannel(cfg TelegramConfig, msgLimit int, timeoutMs int) (api.Ch
 		Timeout: 60 * time.Second,
 		Transport: &http.Transport{
 			DialContext: func(dialCtx context.Context, network, addr string) (net.Conn, error) {
-				// We wrap the context with our stopCtx so we can arbitrarily kill the connection
+				// We wrap the context with our stopCtx so we can arbitrarily kill the connection.
+				// It is looked up per dial because a restart replaces it.
+				stopCtx := t.stopContext()
 				mergedCtx, mergedCancel := context.WithCancel(dialCtx)
 				go func() {
 					select {
-					case <-ctx.Done():
+					case <-stopCtx.Done():
 						mergedCancel()
 					case <-mergedCtx.Done():
 					}
@@ -93,17 +134,55 @@ func NewTelegramChannel(cfg TelegramConfig, msgLimit int, timeoutMs int) (api.Ch
 
 	slog.Info("Telegram bot authorized", "username", bot.Self.UserName)
 
-	return &TelegramChannel{
-		config:       cfg,
-		bot:          bot,
-		messageLimit: msgLimit,
-		mediaGroups:  make(map[string]*mediaGroupBuffer),
-		httpClient: &http.Client{
-			Timeout: time.Duration(timeoutMs) * time.Millisecond,
-		},
-		stopCtx:    ctx,
-		stopCancel: cancel,
-	}, nil
+	t.bot = bot
+	return t, nil
+}
+
+// stopContext returns the context that is cancelled when the channel stops.
+func (t *TelegramChannel) stopContext() context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stopCtx
+}
+
+// Health implements api.HealthCheckable. The channel is unhealthy after
+// unhealthyPollFailures consecutive failures to fetch updates, or once the
+// webhook server has stopped.
+func (t *TelegramChannel) Health() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.serveErr != nil {
+		return fmt.Errorf("webhook server stopped: %w", t.serveErr)
+	}
+	if t.conflicts >= confirmedConflicts {
+		return fmt.Errorf("%w: %w", errPollConflict, t.lastPollErr)
+	}
+	if t.pollFailures >= unhealthyPollFailures {
+		return fmt.Errorf("%d consecutive polling failures: %w", t.pollFailures, t.lastPollErr)
+	}
+	return nil
+}
+
+// recordPoll updates the polling failure counters used by Health. On error
+// it returns the new numbers of consecutive failures and 409 conflicts; on
+// success it returns the counts that preceded it.
+func (t *TelegramChannel) recordPoll(err error) (int, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.pollFailures++
+		t.lastPollErr = err
+		if isConflict(err) {
+			t.conflicts++
+		} else {
+			t.conflicts = 0
+		}
+		return t.pollFailures, t.conflicts
+	}
+	failures, conflicts := t.pollFailures, t.conflicts
+	t.pollFailures, t.conflicts = 0, 0
+	t.lastPollErr = nil
+	return failures, conflicts
 }
 
 // ID returns the unique platform identifier "telegram".
@@ -111,24 +190,43 @@ func (t *TelegramChannel) ID() string {
 	return "telegram"
 }
 
-// Start initiates the long-polling update loop in a background goroutine.
-// It maps platform-specific update types (text, photos, albums) into
-// the internal UnifiedMessage format.
+// Start initiates the long-polling update loop in a background goroutine,
+// after removing any registered webhook, or the webhook server in webhook
+// mode. It maps platform-specific update
+// types (text, photos, albums) into the internal UnifiedMessage format.
 func (t *TelegramChannel) Start(ctx api.ChannelContext) error {
-	offset := 0
+	// A stopped channel gets a fresh stop context so it can be restarted
+	t.mu.Lock()
+	if t.stopCtx.Err() != nil {
+		t.stopCtx, t.stopCancel = context.WithCancel(context.Background())
+	}
+	stopCtx := t.stopCtx
+	t.pollFailures, t.conflicts, t.lastPollErr = 0, 0, nil
+	t.serveErr = nil
+	t.mu.Unlock()
+
+	t.startWorkers(ctx, stopCtx)
+
+	if t.config.Mode == ModeWebhook {
+		return t.startWebhook()
+	}
+	if err := t.deleteWebhook(); err != nil {
+		slog.Warn("Failed to delete telegram webhook; polling fails while one is registered", "error", err)
+	}
 
 	// Process updates in background with manual loop to allow Context cancellation
 	go func() {
 		for {
 			select {
-			case <-t.stopCtx.Done():
+			case <-stopCtx.Done():
 				return // Gracefully exit on shutdown
 			default:
 			}
 
 			// We use WithContext to wrap the underlying request so we can cancel it mid-flight
-			reqConfig := tgbotapi.NewUpdate(offset)
-			reqConfig.Timeout = 60
+			reqConfig := tgbotapi.NewUpdate(t.offset())
+			reqConfig.Timeout = pollTimeout
+			reqConfig.AllowedUpdates = allowedUpdates
 
 			// tgbotapi uses Request(c Chattable). We need to do a custom Request wrapped with Context
 			// Since tgbotapi doesn't natively expose Context in v5 GetUpdates, we cancel the entire HTTP client
@@ -148,87 +246,135 @@ func (t *TelegramChannel) Start(ctx api.ChannelContext) error {
 			// Let's use the native GetUpdates instead of GetUpdatesChan so we have control over the offset
 			updates, err := t.bot.GetUpdates(reqConfig)
 			if err != nil {
-				select {
-				case <-t.stopCtx.Done():
+				if stopCtx.Err() != nil {
 					return // Ignore error if we are shutting down
+				}
+				failures, conflicts := t.recordPoll(err)
+				wait := t.pollBackoff(failures)
+				if conflicts > 0 {
+					wait = t.conflictBackoff(wait)
+				}
+				switch {
+				case conflicts == confirmedConflicts:
+					slog.Error("Telegram polling conflict: another bot instance is polling with this token. Stop the other instance or use a different token.", "error", err, "wait", wait)
+				case conflicts == 1:
+					slog.Warn("Telegram polling conflict, waiting for a previous poll to expire", "error", err, "wait", wait)
+				case failures == 1:
+					slog.Warn("Failed to get telegram updates, backing off", "error", err, "wait", wait)
 				default:
-					slog.Debug("Failed to get telegram updates", "error", err)
-					time.Sleep(3 * time.Second)
-					continue
+					slog.Debug("Failed to get telegram updates", "error", err, "failures", failures, "wait", wait)
+				}
+				select {
+				case <-stopCtx.Done():
+					return
+				case <-time.After(wait):
 				}
+				continue
+			}
+			if failures, _ := t.recordPoll(nil); failures > 0 {
+				slog.Info("Telegram polling recovered", "failures", failures)
 			}
 
 			for _, update := range updates {
-				if update.UpdateID >= offset {
-					offset = update.UpdateID + 1
+				// Acknowledge every update, whatever its type, so that none
+				// is delivered again by the next poll or after a restart
+				if update.UpdateID < t.offset() {
+					continue
+				}
+				t.setOffset(update.UpdateID + 1)
+				t.dispatch(stopCtx, update)
+			}
+		}
+	}()
 
-					if update.Message == nil {
-						continue
-					}
+	return nil
+}
 
-					// Init Session Context
-					session := api.SessionContext{
-						ChannelID: "telegram",
-						UserID:    strconv.FormatInt(update.Message.From.ID, 10),
-						ChatID:    strconv.FormatInt(update.Message.Chat.ID, 10),
-						Username:  update.Message.From.UserName,
-					}
+// handleUpdate dispatches a single update by type. Update types the bot
+// does not act on are logged and dropped; their offset has already been
+// acknowledged by the caller.
+func (t *TelegramChannel) handleUpdate(ctx api.ChannelContext, update tgbotapi.Update) {
+	switch {
+	case update.Message != nil:
+		t.handleMessage(ctx, update.Message)
+	case update.EditedMessage != nil:
+		// Edits would re-run a turn that was already answered
+		slog.Debug("Ignoring edited telegram message", "chat_id", update.EditedMessage.Chat.ID, "message_id", update.EditedMessage.MessageID)
+	case update.CallbackQuery != nil:
+		t.handleCallback(ctx, update.CallbackQuery)
+	default:
+		slog.Debug("Ignoring unsupported telegram update", "update_id", update.UpdateID)
+	}
+}
 
-					// Identify photos but don't download yet to avoid blocking group logic
-					var photoID string
-					if len(update.Message.Photo) > 0 {
-						photoID = update.Message.Photo[len(update.Message.Photo)-1].FileID
-					}
+// handleMessage converts an incoming message into a UnifiedMessage.
+func (t *TelegramChannel) handleMessage(ctx api.ChannelContext, m *tgbotapi.Message) {
+	if m.From == nil || m.Chat == nil {
+		return // Anonymous posts carry no user to attribute the session to
+	}
 
-					// Get content
-					content := update.Message.Text
-					if content == "" {
-						content = update.Message.Caption
-					}
+	// Init Session Context
+	session := api.SessionContext{
+		ChannelID: "telegram",
+		UserID:    strconv.FormatInt(m.From.ID, 10),
+		ChatID:    strconv.FormatInt(m.Chat.ID, 10),
+		Username:  m.From.UserName,
+		IsGroup:   m.Chat.IsGroup() || m.Chat.IsSuperGroup(),
+	}
 
-					// Handle MediaGroup (album/collection)
-					if update.Message.MediaGroupID != "" {
-						t.handleMediaGroup(ctx, update.Message.MediaGroupID, session, content, photoID)
-						continue
-					}
+	// Identify photos but don't download yet to avoid blocking group logic
+	var photoID string
+	if len(m.Photo) > 0 {
+		photoID = m.Photo[len(m.Photo)-1].FileID
+	}
 
-					// Regular message (single image or plain text)
-					if photoID != "" {
-						// Process image asynchronously to avoid blocking the update loop
-						go func(s api.SessionContext, text string, pID string) {
-							var files []api.FileAttachment
-							if file, err := t.downloadPhoto(pID); err == nil {
-								files = append(files, *file)
-							} else {
-								slog.Error("Photo download failed", "error", err)
-							}
-
-							msg := &api.UnifiedMessage{
-								Session: s,
-								Content: text,
-								Files:   files,
-							}
-							ctx.OnMessage(t.ID(), msg)
-						}(session, content, photoID)
-					} else {
-						// Process text immediately
-						msg := &api.UnifiedMessage{
-							Session: session,
-							Content: content,
-						}
-						ctx.OnMessage(t.ID(), msg)
-					}
-				}
-			}
+	// Get content
+	content := m.Text
+	if content == "" {
+		content = m.Caption
+	}
+
+	// Handle MediaGroup (album/collection)
+	if m.MediaGroupID != "" {
+		t.handleMediaGroup(ctx, m.MediaGroupID, session, content, photoID)
+		return
+	}
+
+	// Regular message (single image or plain text)
+	// Downloads run on the chat's worker, so later messages of the chat wait
+	// for the photo and arrive in order
+	msg := &api.UnifiedMessage{
+		Session: session,
+		Content: content,
+	}
+	if photoID != "" {
+		if file, err := t.downloadPhoto(photoID); err == nil {
+			msg.Files = append(msg.Files, *file)
+		} else {
+			slog.Error("Photo download failed", "error", err)
 		}
-	}()
+	}
+	ctx.OnMessage(t.ID(), msg)
+}
 
-	return nil
+// offset returns the next update ID to request.
+func (t *TelegramChannel) offset() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.nextOffset
+}
+
+// setOffset records that all updates below next have been received.
+func (t *TelegramChannel) setOffset(next int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextOffset = max(t.nextOffset, next)
 }
 
 // SendSignal implements the gateway.SignalingChannel interface
 func (t *TelegramChannel) SendSignal(session api.SessionContext, signal string) error {
-	if signal == llm.BlockTypeThinking {
+	switch signal {
+	case llm.BlockTypeThinking:
 		chatID, err := strconv.ParseInt(session.ChatID, 10, 64)
 		if err != nil {
 			return err
@@ -236,10 +382,47 @@ func (t *TelegramChannel) SendSignal(session api.SessionContext, signal string)
 		action := tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping)
 		_, err = t.bot.Send(action)
 		return err
+	case "summarizing:start":
+		return t.showStatus(session, utils.IconInfo.String()+" Compressing conversation history…")
+	case "summarizing:end":
+		return t.clearStatus(session)
 	}
 	return nil
 }
 
+// showStatus posts a transient status message that clearStatus removes later.
+func (t *TelegramChannel) showStatus(session api.SessionContext, text string) error {
+	chatID, err := strconv.ParseInt(session.ChatID, 10, 64)
+	if err != nil {
+		return err
+	}
+	sent, err := t.bot.Send(tgbotapi.NewMessage(chatID, text))
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.statusMsgs[chatID] = sent.MessageID
+	t.mu.Unlock()
+	return nil
+}
+
+// clearStatus deletes the chat's transient status message, if any.
+func (t *TelegramChannel) clearStatus(session api.SessionContext) error {
+	chatID, err := strconv.ParseInt(session.ChatID, 10, 64)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	msgID, ok := t.statusMsgs[chatID]
+	delete(t.statusMsgs, chatID)
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	_, err = t.bot.Request(tgbotapi.NewDeleteMessage(chatID, msgID))
+	return err
+}
+
 // downloadPhoto encapsulates the download logic, streaming directly to disk
 func (t *TelegramChannel) downloadPhoto(fileID string) (*api.FileAttachment, error) {
 	// Use Telegram API to get file info (contains Path)
@@ -262,50 +445,10 @@ func (t *TelegramChannel) downloadPhoto(fileID string) (*api.FileAttachment, err
 		return nil, fmt.Errorf("failed to download photo: status code %d", resp.StatusCode)
 	}
 
-	// Ensure attachments directory exists
-	attachmentsDir := "data/attachments"
-	if err := os.MkdirAll(attachmentsDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create attachments directory: %w", err)
-	}
-
-	// Telegram FileIDs are unique to the file content.
-	// We use a glob-based check to skip downloading if any extension of this file exists.
-	basePattern := fmt.Sprintf("%s/tg_%s", attachmentsDir, fileID)
-	if matches, _ := filepath.Glob(basePattern + "*"); len(matches) > 0 {
-		localPath := matches[0]
-		mimeType, _ := utils.DetectFileMimeAndExt(localPath)
-
-		// File already exists, return it directly
-		return &api.FileAttachment{
-			Filename: fileInfo.FilePath,
-			MimeType: mimeType,
-			Data:     nil, // We don't keep it in memory
-			Path:     localPath,
-		}, nil
-	}
-
-	// Create local file with extension from Telegram's path
-	ext := filepath.Ext(fileInfo.FilePath)
-	localPath := basePattern + ext
-
-	outFile, err := os.Create(localPath)
+	// Stream to disk, named by content so a photo sent twice is stored once
+	localPath, mimeType, err := utils.SaveAttachmentFrom(t.attachments, resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create local file: %w", err)
-	}
-	defer outFile.Close()
-
-	// Stream directly to disk
-	if _, err := io.Copy(outFile, resp.Body); err != nil {
-		return nil, fmt.Errorf("failed to save photo data to disk: %w", err)
-	}
-
-	// Final verification: if extension was missing, detect it now and rename
-	mimeType, detectedExt := utils.DetectFileMimeAndExt(localPath)
-	if ext == "" {
-		newPath := basePattern + detectedExt
-		if err := os.Rename(localPath, newPath); err == nil {
-			localPath = newPath
-		}
+		return nil, err
 	}
 
 	return &api.FileAttachment{
@@ -396,7 +539,16 @@ func (t *TelegramChannel) handleMediaGroup(ctx api.ChannelContext, groupID strin
 }
 
 func (t *TelegramChannel) Stop() error {
+	t.mu.Lock()
 	t.stopCancel() // Cancel our custom long-polling loop immediately
+	webhook := t.webhook
+	t.webhook = nil
+	t.mu.Unlock()
+
+	// Release the webhook port before returning so a restart can bind it again
+	if webhook != nil {
+		webhook.Close()
+	}
 
 	// Forcefully close lingering HTTP connections
 	// Note: HTTP/1.1 connections stuck in Read won't abort via CloseIdleConnections().
@@ -423,7 +575,7 @@ func (t *TelegramChannel) Send(session api.SessionContext, message string) error
 	if totalLen <= t.messageLimit {
 		// Send short message directly
 		msg := tgbotapi.NewMessage(chatID, message)
-		if _, err := t.bot.Send(msg); err != nil {
+		if err := t.sendWithRetry(msg); err != nil {
 			return fmt.Errorf("telegram send failed: %w", err)
 		}
 		return nil
@@ -437,7 +589,7 @@ func (t *TelegramChannel) Send(session api.SessionContext, message string) error
 		}
 		chunk := string(msgRunes[i:end])
 		msg := tgbotapi.NewMessage(chatID, chunk)
-		if _, err := t.bot.Send(msg); err != nil {
+		if err := t.sendWithRetry(msg); err != nil {
 			return fmt.Errorf("telegram send chunk failed at index %d: %w", i, err)
 		}
 	}
@@ -469,8 +621,7 @@ func (t *TelegramChannel) sendPhoto(session api.SessionContext, block llm.Conten
 		return fmt.Errorf("unsupported image source type: %s", block.Source.Type)
 	}
 
-	_, err = t.bot.Send(photo)
-	return err
+	return t.sendWithRetry(photo)
 }
 
 // Stream implements the streaming response protocol for Telegram.
@@ -491,17 +642,21 @@ func (t *TelegramChannel) Stream(session api.SessionContext, blocks <-chan llm.C
 		case llm.BlockTypeText, llm.BlockTypeError:
 			// Send thinking buffer when the first text block arrives if not already sent
 			if thinkingBuf.Len() > 0 && !thinkingSent {
-				thinkingMsg := "💭 Reasoning process:\n\n" + thinkingBuf.String()
+				thinkingMsg := utils.ReasoningHeader() + thinkingBuf.String()
 				if err := t.Send(session, thinkingMsg); err != nil {
 					slog.Error("Failed to send thinking", "error", err)
 				}
 				thinkingSent = true
 			}
 			textBuf.WriteString(block.Text)
+		case llm.BlockTypeTable:
+			if block.Table != nil {
+				textBuf.WriteString("\n" + block.Table.Markdown())
+			}
 		case llm.BlockTypeImage:
 			// Send current text buffer first to maintain order
 			if textBuf.Len() > 0 {
-				replyMsg := "🤖 Assistant response:\n\n" + textBuf.String()
+				replyMsg := utils.AssistantHeader() + textBuf.String()
 				if err := t.Send(session, replyMsg); err != nil {
 					slog.Error("Failed to send text before image", "error", err)
 				}
@@ -515,7 +670,7 @@ func (t *TelegramChannel) Stream(session api.SessionContext, blocks <-chan llm.C
 
 	// Send thinking process if the loop ends and it hasn't been sent yet
 	if thinkingBuf.Len() > 0 && !thinkingSent {
-		thinkingMsg := "💭 Reasoning process:\n\n" + thinkingBuf.String()
+		thinkingMsg := utils.ReasoningHeader() + thinkingBuf.String()
 		if err := t.Send(session, thinkingMsg); err != nil {
 			slog.Error("Failed to send thinking", "error", err)
 		}
@@ -523,7 +678,7 @@ func (t *TelegramChannel) Stream(session api.SessionContext, blocks <-chan llm.C
 
 	// Send assistant response (if any)
 	if textBuf.Len() > 0 {
-		replyMsg := "🤖 Assistant response:\n\n" + textBuf.String()
+		replyMsg := utils.AssistantHeader() + textBuf.String()
 		return t.Send(session, replyMsg)
 	}
 
@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Update delivery modes selectable via TelegramConfig.Mode.
+const (
+	ModePolling = "polling" // Long-poll getUpdates (default)
+	ModeWebhook = "webhook" // Receive updates as HTTPS requests from Telegram
+)
+
+// secretHeader carries TelegramConfig.WebhookSecret on every webhook request.
+const secretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// startWebhook registers the webhook with Telegram and serves it until
+// Stop. The listener is bound before returning, so a busy port or a
+// rejected URL fails Start instead of surfacing later.
+func (t *TelegramChannel) startWebhook() error {
+	u, err := url.Parse(t.config.WebhookURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid telegram webhook_url %q: an https URL is required", t.config.WebhookURL)
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		t.serveWebhook(w, r)
+	})
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ln, err := net.Listen("tcp", t.config.WebhookListen)
+	if err != nil {
+		return fmt.Errorf("telegram webhook listen failed: %w", err)
+	}
+	if err := t.setWebhook(); err != nil {
+		ln.Close()
+		return err
+	}
+
+	t.mu.Lock()
+	t.webhook = server
+	t.mu.Unlock()
+
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Telegram webhook server error", "error", err)
+			t.mu.Lock()
+			t.serveErr = err
+			t.mu.Unlock()
+		}
+	}()
+
+	slog.Info("Telegram webhook listening", "addr", ln.Addr().String(), "path", path)
+	return nil
+}
+
+// setWebhook points Telegram at the configured URL. The webhook is left in
+// place on Stop, so updates arriving during a restart are queued by
+// Telegram rather than lost.
+func (t *TelegramChannel) setWebhook() error {
+	params := tgbotapi.Params{"url": t.config.WebhookURL}
+	params.AddNonEmpty("secret_token", t.config.WebhookSecret)
+	if err := params.AddInterface("allowed_updates", allowedUpdates); err != nil {
+		return err
+	}
+	if _, err := t.bot.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("telegram setWebhook failed: %w", err)
+	}
+	return nil
+}
+
+// deleteWebhook removes a webhook left registered by an earlier run in
+// webhook mode, which makes Telegram reject every getUpdates call. Pending
+// updates are kept, so polling picks them up.
+func (t *TelegramChannel) deleteWebhook() error {
+	if _, err := t.bot.Request(tgbotapi.DeleteWebhookConfig{DropPendingUpdates: false}); err != nil {
+		return fmt.Errorf("telegram deleteWebhook failed: %w", err)
+	}
+	return nil
+}
+
+// serveWebhook decodes one update pushed by Telegram and dispatches it
+// to the workers like polled updates. Telegram redelivers updates
+// that are not answered with 2xx, so only malformed requests are rejected.
+func (t *TelegramChannel) serveWebhook(w http.ResponseWriter, r *http.Request) {
+	if t.config.WebhookSecret != "" &&
+		subtle.ConstantTimeCompare([]byte(r.Header.Get(secretHeader)), []byte(t.config.WebhookSecret)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	update, err := t.bot.HandleUpdate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	t.dispatch(r.Context(), *update)
+	w.WriteHeader(http.StatusOK)
+}
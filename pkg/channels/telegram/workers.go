@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"context"
+	"genesis/pkg/api"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// startWorkers starts the bounded pool that processes updates. Each chat is
+// pinned to one worker, so its updates are handled in order while different
+// chats proceed in parallel. When a worker's queue is full, intake waits,
+// which holds back the next poll instead of spawning more goroutines.
+// Workers exit when stopCtx is cancelled; updates still queued are dropped.
+func (t *TelegramChannel) startWorkers(ctx api.ChannelContext, stopCtx context.Context) {
+	queues := make([]chan tgbotapi.Update, max(t.config.Workers, 1))
+	for i := range queues {
+		queues[i] = make(chan tgbotapi.Update, max(t.config.WorkerQueueSize, 0))
+		go func(queue <-chan tgbotapi.Update) {
+			for {
+				select {
+				case <-stopCtx.Done():
+					return
+				case update := <-queue:
+					t.handleUpdate(ctx, update)
+				}
+			}
+		}(queues[i])
+	}
+
+	t.mu.Lock()
+	t.queues = queues
+	t.mu.Unlock()
+}
+
+// dispatch queues an update on the worker of its chat. It gives up when
+// ctx is cancelled (the channel stopped or the webhook request ended).
+func (t *TelegramChannel) dispatch(ctx context.Context, update tgbotapi.Update) {
+	t.mu.Lock()
+	queues := t.queues
+	t.mu.Unlock()
+	if len(queues) == 0 {
+		return
+	}
+
+	chatID := updateChatID(update)
+	queue := queues[uint64(chatID)%uint64(len(queues))]
+	select {
+	case queue <- update:
+	case <-ctx.Done():
+	}
+}
+
+// updateChatID returns the chat an update belongs to, or 0 if it has none.
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil && update.Message.Chat != nil:
+		return update.Message.Chat.ID
+	case update.EditedMessage != nil && update.EditedMessage.Chat != nil:
+		return update.EditedMessage.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil && update.CallbackQuery.Message.Chat != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	}
+	return 0
+}
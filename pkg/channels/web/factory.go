@@ -17,15 +17,32 @@ type WebFactory struct{}
 // Create parses the web-specific configuration and initializes a
 // WebChannel instance.
 func (f *WebFactory) Create(rawConfig jsoniter.RawMessage, sessions *llm.SessionManager, system *config.SystemConfig) (api.Channel, error) {
+	pCfg, err := parseConfig(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewWebChannel(pCfg, sessions, system.DataPath("attachments")), nil
+}
+
+// Validate implements channels.ConfigValidator.
+func (f *WebFactory) Validate(rawConfig jsoniter.RawMessage, system *config.SystemConfig) error {
+	_, err := parseConfig(rawConfig)
+	return err
+}
+
+// parseConfig decodes the channel configuration over the defaults.
+func parseConfig(rawConfig jsoniter.RawMessage) (WebConfig, error) {
 	var pCfg WebConfig
 	// Set default port
 	pCfg.Port = 9453
 
 	if err := json.Unmarshal(rawConfig, &pCfg); err != nil {
-		return nil, fmt.Errorf("failed to parse web config: %w", err)
+		return pCfg, fmt.Errorf("failed to parse web config: %w", err)
 	}
-
-	return NewWebChannel(pCfg, sessions), nil
+	if pCfg.Port < 0 || pCfg.Port > 65535 {
+		return pCfg, fmt.Errorf("invalid web port %d", pCfg.Port)
+	}
+	return pCfg, nil
 }
 
 func init() {
@@ -1,9 +1,7 @@
 package web
 
 import (
-	"crypto/sha256"
 	"encoding/base64"
-	"encoding/hex"
 	"fmt"
 	"genesis/pkg/api"
 	"genesis/pkg/llm"
@@ -38,9 +36,12 @@ type IncomingMessage struct {
 	} `json:"images"`
 }
 
+// SafeConn serializes writes to a WebSocket connection. Writes are atomic
+// per message; stream additionally keeps whole streams from interleaving.
 type SafeConn struct {
 	*websocket.Conn
-	mu sync.Mutex
+	mu     sync.Mutex
+	stream sync.Mutex // Held by the active Stream; concurrent streams queue behind it
 }
 
 func (sc *SafeConn) WriteMessage(messageType int, data []byte) error {
@@ -53,14 +54,17 @@ type WebChannel struct {
 	config      WebConfig
 	server      *http.Server
 	sessions    *llm.SessionManager  // Manager for fetching histories
+	attachments string               // Directory uploaded images are saved to
 	connections map[string]*SafeConn // Map UserID -> WS Connection
+	serveErr    error                // Set when the HTTP server stopped unexpectedly
 	mu          sync.RWMutex
 }
 
-func NewWebChannel(cfg WebConfig, sessions *llm.SessionManager) *WebChannel {
+func NewWebChannel(cfg WebConfig, sessions *llm.SessionManager, attachmentsDir string) *WebChannel {
 	return &WebChannel{
 		config:      cfg,
 		sessions:    sessions,
+		attachments: attachmentsDir,
 		connections: make(map[string]*SafeConn),
 	}
 }
@@ -83,15 +87,34 @@ func (c *WebChannel) Start(ctx api.ChannelContext) error {
 
 	slog.Info("Web API listening", "port", c.config.Port)
 
+	c.mu.Lock()
+	c.serveErr = nil
+	c.mu.Unlock()
+
+	server := c.server
 	go func() {
-		if err := c.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("Web API server error", "error", err)
+			c.mu.Lock()
+			c.serveErr = err
+			c.mu.Unlock()
 		}
 	}()
 
 	return nil
 }
 
+// Health implements api.HealthCheckable. The channel is unhealthy once its
+// HTTP server has stopped with an error (e.g., the port could not be bound).
+func (c *WebChannel) Health() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.serveErr != nil {
+		return fmt.Errorf("web server stopped: %w", c.serveErr)
+	}
+	return nil
+}
+
 func (c *WebChannel) Stop() error {
 	if c.server != nil {
 		return c.server.Close()
@@ -132,7 +155,34 @@ func (c *WebChannel) SendSignal(session api.SessionContext, signal string) error
 	return conn.WriteMessage(websocket.TextMessage, jsonData)
 }
 
-// Stream implements gateway.Channel.Stream
+// SendWithActions implements api.InteractiveChannel. The client renders the
+// actions as buttons and sends the chosen value back as a regular text message.
+func (c *WebChannel) SendWithActions(session api.SessionContext, text string, actions []api.Action) error {
+	c.mu.RLock()
+	conn, ok := c.connections[session.UserID]
+	c.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("web user %s not connected", session.UserID)
+	}
+
+	msg := map[string]any{
+		"type":    "actions",
+		"text":    text,
+		"actions": actions,
+	}
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal actions: %w", err)
+	}
+	return conn.WriteMessage(websocket.TextMessage, jsonData)
+}
+
+// Stream implements gateway.Channel.Stream. Only one stream is active per
+// connection: if the client sends again before a reply has finished, the new
+// reply waits until the previous one has sent its "done" marker, so blocks
+// of different turns never interleave on the wire. Signals and plain sends
+// are not held back.
 func (c *WebChannel) Stream(session api.SessionContext, blocks <-chan llm.ContentBlock) error {
 	c.mu.RLock()
 	conn, ok := c.connections[session.UserID]
@@ -142,6 +192,12 @@ func (c *WebChannel) Stream(session api.SessionContext, blocks <-chan llm.Conten
 		return fmt.Errorf("web user %s not connected", session.UserID)
 	}
 
+	if !conn.stream.TryLock() {
+		slog.Debug("Queueing web stream behind the active one", "user", session.UserID)
+		conn.stream.Lock()
+	}
+	defer conn.stream.Unlock()
+
 	for block := range blocks {
 		// Convert to JSON structure
 		msg := map[string]interface{}{
@@ -165,6 +221,11 @@ func (c *WebChannel) Stream(session api.SessionContext, blocks <-chan llm.Conten
 			}
 		} else {
 			msg["text"] = block.Text
+			if block.Type == llm.BlockTypeTable && block.Table != nil {
+				msg["headers"] = block.Table.Headers
+				msg["rows"] = block.Table.Rows
+				msg["html"] = block.Table.HTML()
+			}
 		}
 
 		jsonData, err := json.Marshal(msg)
@@ -257,28 +318,13 @@ func (c *WebChannel) handleWebSocket(w http.ResponseWriter, r *http.Request, ctx
 					continue
 				}
 
-				// Ensure attachments directory exists
-				attachmentsDir := "data/attachments"
-				if err := os.MkdirAll(attachmentsDir, 0755); err != nil {
-					slog.Error("Failed to create attachments dir", "error", err)
+				// Named by content hash (SHA-256), so identical images share one file
+				localPath, _, err := utils.SaveAttachment(c.attachments, data)
+				if err != nil {
+					slog.Error("Failed to save image to disk", "name", img.Name, "error", err)
 					continue
 				}
 
-				// Generate unique local path based on content hash (SHA-256)
-				hash := sha256.Sum256(data)
-				// Prefix with 8-char hex timestamp for easy expiration checks
-				_, ext := utils.DetectMimeAndExt(data)
-				localFileName := fmt.Sprintf("%s%s%s", utils.GenerateTimestampPrefix(), hex.EncodeToString(hash[:]), ext)
-				localPath := fmt.Sprintf("%s/%s", attachmentsDir, localFileName)
-
-				// Write directly to disk (if it doesn't already exist to save IO)
-				if _, err := os.Stat(localPath); os.IsNotExist(err) {
-					if err := os.WriteFile(localPath, data, 0644); err != nil {
-						slog.Error("Failed to save image to disk", "path", localPath, "error", err)
-						continue
-					}
-				}
-
 				files = append(files, api.FileAttachment{
 					Filename: img.Name,
 					MimeType: img.Mime,
@@ -1,8 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"net"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 
 	jsoniter "github.com/json-iterator/go"
 )
@@ -19,6 +26,16 @@ type Config struct {
 	// SystemPrompt is the global persona/instruction string sent to the AI
 	// as the initial system message in every conversation.
 	SystemPrompt string `json:"system_prompt"`
+	// SystemPromptFile loads the system prompt from a file (e.g., a long
+	// prompt.md), taking precedence over SystemPrompt when set. Relative
+	// paths are resolved against the working directory, and the file is
+	// watched for changes like config.json.
+	SystemPromptFile string `json:"system_prompt_file,omitempty"`
+	// SystemPromptNoTools replaces SystemPrompt when tools are unavailable
+	// (EnableTools is false or the request came through /notools), so the
+	// model is not told about tools it cannot call. When empty, SystemPrompt
+	// is used with a short note that tools are disabled.
+	SystemPromptNoTools string `json:"system_prompt_no_tools,omitempty"`
 }
 
 // DeepCopy creates a shallow copy of Config.
@@ -53,9 +70,23 @@ type SystemConfig struct {
 	// RetryDelayMs is the duration to wait (in milliseconds) between
 	// consecutive retry attempts.
 	RetryDelayMs int `json:"retry_delay_ms"`
+	// RetryStopReasons lists the normalized stop reasons of abnormal responses
+	// that are retried (e.g., "malformed_function_call"). "empty" stands for a
+	// response without any content and "*" matches every reason. Transient
+	// connection errors are retried regardless. Default: failed,
+	// malformed_function_call, unexpected_tool_call, other, unknown.
+	RetryStopReasons []string `json:"retry_stop_reasons"`
+	// MaxLLMCallsPerMessage caps the total LLM calls made for a single user
+	// message, counting retries, continuations and tool turns together.
+	// Set to 0 for no limit. Default: 20.
+	MaxLLMCallsPerMessage int `json:"max_llm_calls_per_message"`
 	// LLMTimeoutMs is the hard cutoff time (in milliseconds) for an
 	// LLM request. The context will be cancelled if exceeded.
 	LLMTimeoutMs int `json:"llm_timeout_ms"`
+	// FirstTokenTimeoutMs bounds the wait (in milliseconds) for the first chunk
+	// of a stream, including the provider handshake, independently of the total
+	// stream deadline. Timeouts are retried. Set to 0 to disable. Default: 0.
+	FirstTokenTimeoutMs int `json:"first_token_timeout_ms"`
 	// OllamaDefaultURL is the fallback endpoint used when connecting
 	// to a local Ollama instance if no specific URL is provided.
 	OllamaDefaultURL string `json:"ollama_default_url"`
@@ -65,6 +96,20 @@ type SystemConfig struct {
 	// ThinkingInitDelayMs is the time to wait (in milliseconds) after a
 	// user message before showing the "AI is thinking" status in the UI.
 	ThinkingInitDelayMs int `json:"thinking_init_delay_ms"`
+	// StreamCoalesceMs is the time window (in milliseconds) during which consecutive
+	// text/thinking deltas are merged by the provider clients before being
+	// forwarded to the engine and gateway. Merged text is flushed when the
+	// window ends even if the model pauses. Set to 0 to forward every delta
+	// as-is. Default: 50.
+	StreamCoalesceMs int `json:"stream_coalesce_ms"`
+	// StreamCoalesceChars flushes a merged delta early once it reaches this
+	// many bytes, keeping long bursts responsive. Default: 80.
+	StreamCoalesceChars int `json:"stream_coalesce_chars"`
+	// StreamFlushIntervalMs batches outgoing text at the gateway so that channels
+	// receive at most one text frame per interval (useful for Web/SSE clients).
+	// It only paces delivery; deltas are already merged per StreamCoalesceMs.
+	// Set to 0 to disable. Default: 0.
+	StreamFlushIntervalMs int `json:"stream_flush_interval_ms"`
 	// TelegramMessageLimit is the maximum character count for a single
 	// Telegram message. Longer responses will be split into multiple chunks.
 	TelegramMessageLimit int `json:"telegram_message_limit"`
@@ -74,91 +119,796 @@ type SystemConfig struct {
 	// ShowThinking determines whether the AI's internal reasoning process (thinking blocks)
 	// should be streamed and displayed to the end user.
 	ShowThinking bool `json:"show_thinking"`
-	// DebugChunks enables saving every raw LLM response chunk to the /debug
-	// folder for inspection and troubleshooting purposes.
+	// PersistThinking controls how the AI's reasoning (thinking blocks) is
+	// kept in the stored history once a turn completes; the live stream is
+	// not affected. Accepted values: "full", "truncate" (keep the first
+	// ThinkingHistoryMaxChars characters), "none" (drop it). Default: "full".
+	PersistThinking string `json:"persist_thinking"`
+	// ThinkingHistoryMaxChars is the length reasoning is cut to when
+	// PersistThinking is "truncate". Default: 500.
+	ThinkingHistoryMaxChars int `json:"thinking_history_max_chars"`
+	// DebugChunks enables saving every raw LLM response chunk to the "debug"
+	// folder of DataDir for inspection and troubleshooting purposes.
 	DebugChunks bool `json:"debug_chunks"`
 	// LogLevel sets the minimum severity for log output.
 	// Accepted values: "debug", "info", "warn", "error". Default: "info".
 	LogLevel string `json:"log_level"`
+	// LogSampleRate emits only 1 in N info/debug log records per message
+	// to keep hot-path logging affordable. Warnings and errors are never sampled.
+	// Values of 1 or less disable sampling. Default: 1.
+	LogSampleRate int `json:"log_sample_rate"`
 	// EnableTools globally toggles the tool calling (agentic) functionality.
 	// If false, the AI will not be provided with any external tools/capabilities.
 	EnableTools bool `json:"enable_tools"`
+	// NoToolsPatterns is an opt-in list of case-insensitive regular expressions.
+	// A user message matching any of them (e.g., greetings or small talk) is
+	// answered without advertising tools, avoiding needless tool calls and
+	// latency. Invalid patterns are logged and ignored. Default: empty.
+	NoToolsPatterns []string `json:"no_tools_patterns,omitempty"`
+	// ToolCacheTTLMs is how long (in milliseconds) results of tools that opt in
+	// via Cacheable() are reused within a session. None of the built-in tools
+	// opt in, so it only helps when a registered tool does. Default: 0
+	// (disabled).
+	ToolCacheTTLMs int `json:"tool_cache_ttl_ms"`
+	// DataDir is the root of everything Genesis persists: session histories,
+	// attachments, downloads, dead letters and debug chunks. Point it at a
+	// mounted volume to relocate them together. Relative paths are resolved
+	// against the working directory. Default: "data".
+	DataDir string `json:"data_dir"`
+	// SessionMaxInMemory caps how many conversation histories are cached in
+	// memory; the least recently used are saved and evicted, then reloaded
+	// from disk on their next message. Set to 0 for no cap. Default: 1000.
+	SessionMaxInMemory int `json:"session_max_in_memory"`
+	// SessionIdleTTLMs evicts histories from memory after this many
+	// milliseconds without activity. Set to 0 to disable. Default: 3600000.
+	SessionIdleTTLMs int `json:"session_idle_ttl_ms"`
+	// SessionBackups keeps this many previous versions of every session
+	// history file in the "sessions/backups" folder of DataDir, so context lost to a bad
+	// summarization or truncation can be restored. Set to 0 to disable.
+	// Default: 0.
+	SessionBackups int `json:"session_backups"`
+	// CompressSessions saves session histories gzip-compressed
+	// (history_<id>.json.gz). Both formats are read either way, and a file
+	// is converted on its next save. Default: false.
+	CompressSessions bool `json:"compress_sessions"`
+	// InlineImages keeps image data inside session history files as base64
+	// instead of externalizing it to the "attachments" folder of DataDir, so
+	// every history file is self-contained at the cost of its size. Images
+	// received as files are embedded when the history is saved. Default: false.
+	InlineImages bool `json:"inline_images"`
+	// AttachmentMaxAgeMs deletes saved attachments older than this many
+	// milliseconds, even if a history still refers to them; such references
+	// become an "[image expired]" note. Age is the time since the content was
+	// last received (the file's modification time) or, for files named with
+	// a timestamp prefix, since that time. The sweep runs hourly. Set to 0 to
+	// keep attachments. Default: 0.
+	AttachmentMaxAgeMs int `json:"attachment_max_age_ms"`
 	// HistorySummarizeThreshold is the number of messages after which summarization is triggered.
 	HistorySummarizeThreshold int `json:"history_summarize_threshold"`
 	// HistoryKeepRecentCount is the number of messages to keep in history after summarization.
 	HistoryKeepRecentCount int `json:"history_keep_recent_count"`
+	// HistoryMaxPinned caps how many messages a session can pin; pinning
+	// another unpins the oldest. Pinned messages are kept in addition to
+	// HistoryKeepRecentCount but count toward HistoryMaxChars and
+	// HistoryMaxTokens. Set to 0 for no cap. Default: 10.
+	HistoryMaxPinned int `json:"history_max_pinned"`
 	// HistoryMaxChars is the character limit for the conversation history before triggering summarization.
 	HistoryMaxChars int `json:"history_max_chars"`
 	// HistoryMaxTokens is the token limit for the conversation history before triggering summarization.
 	// This uses the actual usage reported by the LLM.
 	HistoryMaxTokens int `json:"history_max_tokens"`
+	// ChannelFailFast aborts startup when any channel fails to start, stopping
+	// those already started. When false, the failing channels are logged and
+	// skipped so the others keep serving. Default: false.
+	ChannelFailFast bool `json:"channel_fail_fast"`
+	// ChannelMinStarted is how many channels must start successfully for a
+	// partial startup to be accepted when ChannelFailFast is off. Set to 0
+	// to keep running even if every channel failed. Default: 1.
+	ChannelMinStarted int `json:"channel_min_started"`
+	// ChannelHealthCheckIntervalMs is how often (in milliseconds) channels
+	// implementing health checks are polled; unhealthy ones are restarted
+	// with exponential backoff. Set to 0 to disable. Default: 30000.
+	ChannelHealthCheckIntervalMs int `json:"channel_health_check_interval_ms"`
+	// ShowSummarizingStatus sends "summarizing:start"/"summarizing:end" signals
+	// around history summarization so channels can show a transient status
+	// instead of unexplained latency. Default: true.
+	ShowSummarizingStatus bool `json:"show_summarizing_status"`
+	// ExtractFacts runs a second LLM call during summarization that extracts
+	// durable user facts (name, preferences) into a structured list that is
+	// always prepended to the system prompt. Default: true.
+	ExtractFacts bool `json:"extract_facts"`
+	// SenderNames prefixes each user message sent to the model with the
+	// sender's display name (e.g., "[Alice]: hello") so it can tell
+	// participants apart. Accepted values: "never", "group" (only in chats
+	// the channel reports as shared by several users), "always".
+	// Default: "never".
+	SenderNames string `json:"sender_names"`
+	// GroupMode handles group chats as multi-party conversations: every user
+	// message is tagged with its sender's name regardless of SenderNames, and
+	// the system prompt explains the format and names the current speaker so
+	// the model can address individuals. Default: false.
+	GroupMode bool `json:"group_mode"`
+	// ResponsePrefix is prepended to every outgoing assistant reply (e.g., a bot signature).
+	// It is applied on output only and never stored in the conversation history.
+	ResponsePrefix string `json:"response_prefix,omitempty"`
+	// ResponseSuffix is appended to every outgoing assistant reply (e.g., a disclaimer footer).
+	// It is applied on output only and never stored in the conversation history.
+	ResponseSuffix string `json:"response_suffix,omitempty"`
+	// ChannelResponseAffixes overrides ResponsePrefix/ResponseSuffix for specific
+	// channel IDs (e.g., "telegram"). Channels not listed use the global values.
+	ChannelResponseAffixes map[string]ResponseAffix `json:"channel_response_affixes,omitempty"`
+	// ShowTurnSeparators marks the steps of replies that use tools: a header
+	// before each tool result and a label before the answer that follows.
+	// Like the affixes, they are applied on output only. Default: false.
+	ShowTurnSeparators bool `json:"show_turn_separators"`
+	// TurnSeparators is the text used when ShowTurnSeparators is enabled.
+	TurnSeparators TurnSeparators `json:"turn_separators"`
+	// ChannelTurnSeparators overrides TurnSeparators for specific channel IDs,
+	// e.g., Markdown rules for "telegram" or plain dashes for "irc".
+	ChannelTurnSeparators map[string]TurnSeparators `json:"channel_turn_separators,omitempty"`
+	// CLIMonitorMuteChannels lists channel IDs whose traffic the terminal
+	// monitor does not print. A channel writing to the same terminal (e.g., a
+	// console channel streaming replies as they are generated) already shows
+	// the conversation, and the monitor's line for a reply only appears once
+	// it has finished streaming, so it would be printed twice. Other monitors
+	// are not affected. Default: ["cli"].
+	CLIMonitorMuteChannels []string `json:"cli_monitor_mute_channels"`
+	// CLIMonitorColor controls colored terminal monitor output. "auto" colors
+	// only when writing to a terminal and the NO_COLOR environment variable
+	// is unset. Accepted values: "auto", "always", "never". Default: "auto".
+	CLIMonitorColor string `json:"cli_monitor_color"`
+	// CLIMonitorTheme overrides the terminal monitor colors per message type
+	// ("USER", "ASSISTANT", "TOOL", "SYSTEM", "ERROR") and for "TIMESTAMP".
+	// Values are color names ("red", "green", "yellow", "blue", "magenta",
+	// "cyan", "white", "gray", "bold", "none") or ANSI SGR codes (e.g.,
+	// "1;32"). Default: empty (built-in theme).
+	CLIMonitorTheme map[string]string `json:"cli_monitor_theme,omitempty"`
+	// MonitorFilter restricts the messages passed to every monitor (terminal,
+	// web dashboard, log files) to chosen channels or users, e.g. to watch a
+	// single conversation while debugging. Default: no filter.
+	MonitorFilter MonitorFilter `json:"monitor_filter"`
+	// WebMonitorPort, when non-zero, serves a live web dashboard of all
+	// monitored messages on this port (e.g., 9454). Default: 0 (disabled).
+	WebMonitorPort int `json:"web_monitor_port,omitempty"`
+	// WebMonitorHost is the interface the web dashboard listens on. The
+	// dashboard shows every user's conversation, so listening beyond the
+	// loopback interface (e.g., "0.0.0.0" or "") requires WebMonitorToken.
+	// Default: "127.0.0.1".
+	WebMonitorHost string `json:"web_monitor_host"`
+	// WebMonitorToken, when set, must be given to open the web dashboard,
+	// as "?token=" in its URL or a bearer token. /health stays open.
+	// Default: "" (no token).
+	WebMonitorToken string `json:"web_monitor_token,omitempty"`
+	// MonitorLogDir, when set, persists every monitored message as JSON lines
+	// in this directory (e.g., "data/monitor"). Default: "" (disabled).
+	MonitorLogDir string `json:"monitor_log_dir,omitempty"`
+	// MonitorLogRotation controls how often a new monitor log file is started.
+	// Accepted values: "daily", "hourly", "none". Default: "daily".
+	MonitorLogRotation string `json:"monitor_log_rotation,omitempty"`
+	// AdminUserIDs lists the users allowed to run administrative slash
+	// commands such as /loglevel, as "channel:user" (e.g.,
+	// "telegram:123456789"), so an ID only counts on its own platform. IRC
+	// user IDs are nicks, which anyone can take, so IRC users should not be
+	// listed. Default: empty (no admins).
+	AdminUserIDs []string `json:"admin_user_ids,omitempty"`
+	// LogLevelOverrideMs is how long (in milliseconds) a level set via
+	// /loglevel stays active before reverting to LogLevel. Default: 600000.
+	LogLevelOverrideMs int `json:"log_level_override_ms"`
+	// OSToolWorkDirRoot, when set, confines the OS tool's tracked working
+	// directory to this directory tree: commands start in the root and a 'cd'
+	// outside it is not followed. This is not a sandbox; commands still run
+	// with the agent's permissions and can read or write anywhere, e.g.
+	// "cd / && rm -rf x". Use OSToolReadOnly or an OS-level sandbox to
+	// restrict what commands can do. Default: "" (unrestricted).
+	OSToolWorkDirRoot string `json:"os_tool_workdir_root,omitempty"`
+	// OSToolReadOnly restricts the OS tool to non-mutating actions such as
+	// screenshot; run_command and any other writes are rejected. Default: false.
+	OSToolReadOnly bool `json:"os_tool_read_only"`
+	// OSToolShell selects the shell used by run_command per operating system,
+	// keyed by GOOS (e.g., {"linux": "sh", "windows": "cmd.exe"}). The shell
+	// must exist at startup. Default: powershell, /bin/zsh and /bin/bash for
+	// windows, darwin and linux respectively.
+	OSToolShell map[string]string `json:"os_tool_shell,omitempty"`
+	// OSToolOutputEncoding is the encoding used to transcode command output that
+	// is not valid UTF-8, as a code page number ("950", "936", "932") or label
+	// ("big5", "gbk", "shift_jis"). Default: "" (detect the console code page on
+	// Windows).
+	OSToolOutputEncoding string `json:"os_tool_output_encoding,omitempty"`
+	// DownloadDir is where the download tool saves fetched files.
+	// Default: "" (the "downloads" folder of DataDir).
+	DownloadDir string `json:"download_dir"`
+	// DownloadMaxBytes rejects downloads larger than this many bytes.
+	// Set to 0 for no limit. Default: 52428800 (50 MiB).
+	DownloadMaxBytes int64 `json:"download_max_bytes"`
+	// DownloadAllowedMIME restricts downloads to these content types. Entries
+	// ending in "/" match a family (e.g., "text/"). Default: empty (any type).
+	DownloadAllowedMIME []string `json:"download_allowed_mime,omitempty"`
+	// DownloadToolTimeoutMs bounds a single download (in milliseconds).
+	// Default: 300000.
+	DownloadToolTimeoutMs int `json:"download_tool_timeout_ms"`
+	// DBToolDSN, when set, enables the database query tool against this data
+	// source (e.g., a SQLite file path). Default: "" (disabled).
+	DBToolDSN string `json:"db_tool_dsn,omitempty"`
+	// DBToolDriver is the database/sql driver for DBToolDSN. Only the pure-Go
+	// SQLite driver is built in; "sqlite3" is accepted as an alias.
+	// Default: "sqlite".
+	DBToolDriver string `json:"db_tool_driver,omitempty"`
+	// DBToolMaxRows caps the rows returned by a single query. Default: 100.
+	DBToolMaxRows int `json:"db_tool_max_rows"`
+	// DBToolAllowWrites permits statements other than SELECT/WITH/EXPLAIN.
+	// When false, SQLite connections are opened read-only; other drivers
+	// require it to be true. Default: false (read-only).
+	DBToolAllowWrites bool `json:"db_tool_allow_writes"`
+	// ExternalTools lists plugin processes that provide additional tools over
+	// stdio using the MCP tools protocol (newline-delimited JSON-RPC). Each
+	// process is started with the agent and restarted if it exits.
+	// Default: empty.
+	ExternalTools []ExternalToolConfig `json:"external_tools,omitempty"`
+	// HTTPTools declares tools served by HTTP endpoints: each call POSTs the
+	// arguments as JSON to the tool's URL and turns the JSON reply into the
+	// tool result. Default: empty.
+	HTTPTools []HTTPToolConfig `json:"http_tools,omitempty"`
+	// ToolDirectives lets tools steer the next LLM turn by attaching a
+	// directive to their result, which the engine passes on as a note after
+	// the tool results. The model treats directives as instructions, so a
+	// tool relaying untrusted content, such as an HTTP tool whose endpoint
+	// forwards web pages, could use them for prompt injection. Enable only
+	// when every registered tool is trusted. Default: false.
+	ToolDirectives bool `json:"tool_directives"`
+	// InterruptKeywords are messages that stop the session's active run (e.g.,
+	// a long answer or tool chain) instead of starting a new one. Matching
+	// ignores case, full-width forms and surrounding punctuation, so "Stop!"
+	// and "停止。" both match. Sent while nothing runs, they are handled as
+	// ordinary messages. "/stop" always interrupts. Opt-in, since a user may
+	// mean "stop" as part of the conversation; e.g., ["stop", "cancel",
+	// "停止", "取消"]. Default: empty (only "/stop").
+	InterruptKeywords []string `json:"interrupt_keywords"`
+	// UseEmoji selects emoji status markers (❌, ⚠️, 🛠️, ...) in user-facing
+	// notices. Set to false on clients that cannot render them to use ASCII
+	// equivalents ([ERROR], [WARN], [TOOL], ...) instead. Default: true.
+	UseEmoji bool `json:"use_emoji"`
+	// MirrorTarget, when set, receives a copy of every assistant reply for
+	// auditing or supervision (e.g., an admin Telegram chat).
+	MirrorTarget *MirrorTarget `json:"mirror_target,omitempty"`
+	// RedactLogs masks emails, phone numbers, credit card numbers and API keys
+	// in message content and tool arguments before they are written to logs or
+	// broadcast to monitors. The unredacted content is still sent to the LLM.
+	RedactLogs bool `json:"redact_logs"`
+	// Moderation configures the optional content moderation hook applied to
+	// user input and assistant output.
+	Moderation ModerationConfig `json:"moderation"`
+}
+
+// ModerationConfig defines which moderation backend is used and where it is applied.
+type ModerationConfig struct {
+	// Enabled globally toggles content moderation.
+	Enabled bool `json:"enabled"`
+	// Provider selects the backend: "openai" (moderation endpoint) or "keywords" (local blocklist).
+	Provider string `json:"provider"`
+	// APIKey is used by the "openai" provider. Falls back to the OPENAI_API_KEY environment variable.
+	APIKey string `json:"api_key,omitempty"`
+	// BaseURL optionally overrides the moderation endpoint for OpenAI-compatible servers.
+	BaseURL string `json:"base_url,omitempty"`
+	// Keywords is the case-insensitive blocklist used by the "keywords" provider.
+	Keywords []string `json:"keywords,omitempty"`
+	// CheckInput enables moderation of user messages before they are sent to the LLM.
+	CheckInput bool `json:"check_input"`
+	// CheckOutput enables moderation of assistant replies before they are sent to the user.
+	// Replies are held back until the full response has been checked.
+	CheckOutput bool `json:"check_output"`
+	// RefusalMessage is the reply sent to the user when content is blocked.
+	// Empty uses a built-in message marked with the warning icon, which
+	// follows UseEmoji. Default: empty.
+	RefusalMessage string `json:"refusal_message"`
+}
+
+// MirrorTarget identifies the secondary destination that assistant replies
+// are copied to in addition to the original user.
+type MirrorTarget struct {
+	ChannelID string `json:"channel_id"` // Registered channel ID (e.g., "telegram")
+	ChatID    string `json:"chat_id"`    // Destination chat on that channel
+	UserID    string `json:"user_id"`    // Destination user (required by connection-based channels like "web")
+}
+
+// ExternalToolConfig describes how to start an external tool provider.
+type ExternalToolConfig struct {
+	Command   string            `json:"command"`              // Executable to run, looked up in PATH
+	Args      []string          `json:"args,omitempty"`       // Command-line arguments
+	Env       map[string]string `json:"env,omitempty"`        // Variables added to the agent's environment
+	Dir       string            `json:"dir,omitempty"`        // Working directory; empty means the agent's
+	TimeoutMs int               `json:"timeout_ms,omitempty"` // Bound on a single tool call; 0 means none
+}
+
+// HTTPToolConfig declares a tool backed by an HTTP endpoint.
+type HTTPToolConfig struct {
+	Name        string            `json:"name"`                 // Tool name shown to the LLM
+	Description string            `json:"description"`          // What the tool does, shown to the LLM
+	URL         string            `json:"url"`                  // Endpoint receiving the POSTed calls
+	Parameters  map[string]any    `json:"parameters,omitempty"` // JSON Schema properties of the arguments
+	Required    []string          `json:"required,omitempty"`   // Names of the mandatory arguments
+	Headers     map[string]string `json:"headers,omitempty"`    // Sent with every call; $VAR references are expanded from the environment
+	TimeoutMs   int               `json:"timeout_ms,omitempty"` // Bound on a single call; 0 means 30000
+}
+
+// MonitorFilter selects monitored messages by channel and user. A message
+// passes if it matches the include lists (an empty list includes all) and
+// none of the exclude lists.
+type MonitorFilter struct {
+	IncludeChannels []string `json:"include_channels,omitempty"` // Channel IDs to show (e.g., "telegram")
+	ExcludeChannels []string `json:"exclude_channels,omitempty"` // Channel IDs to hide
+	IncludeUsers    []string `json:"include_users,omitempty"`    // User IDs or display names to show
+	ExcludeUsers    []string `json:"exclude_users,omitempty"`    // User IDs or display names to hide
+}
+
+// IsZero reports whether the filter lets every message through.
+func (f MonitorFilter) IsZero() bool {
+	return len(f.IncludeChannels) == 0 && len(f.ExcludeChannels) == 0 &&
+		len(f.IncludeUsers) == 0 && len(f.ExcludeUsers) == 0
+}
+
+// Allows reports whether a message of the given channel and user passes
+// the filter. Users are matched by ID or display name.
+func (f MonitorFilter) Allows(channelID, userID, username string) bool {
+	isUser := func(u string) bool { return u == userID || (username != "" && u == username) }
+	if len(f.IncludeChannels) > 0 && !slices.Contains(f.IncludeChannels, channelID) {
+		return false
+	}
+	if slices.Contains(f.ExcludeChannels, channelID) {
+		return false
+	}
+	if len(f.IncludeUsers) > 0 && !slices.ContainsFunc(f.IncludeUsers, isUser) {
+		return false
+	}
+	return !slices.ContainsFunc(f.ExcludeUsers, isUser)
+}
+
+// ResponseAffix holds the prefix and suffix wrapped around assistant replies
+// for a specific channel.
+type ResponseAffix struct {
+	Prefix string `json:"prefix"` // Text inserted before the reply
+	Suffix string `json:"suffix"` // Text inserted after the reply
+}
+
+// TurnSeparators holds the text inserted between the steps of a reply that
+// uses tools. An empty field inserts nothing at that point.
+type TurnSeparators struct {
+	ToolResult  string `json:"tool_result"`  // Header before each tool result; "{tool}" is replaced by the tool name
+	FinalAnswer string `json:"final_answer"` // Label before the assistant text that follows tool results
+}
+
+// TurnSeparatorsFor resolves the separators for the given channel, falling
+// back to the global TurnSeparators. The zero value is returned when
+// ShowTurnSeparators is disabled.
+func (s *SystemConfig) TurnSeparatorsFor(channelID string) TurnSeparators {
+	if !s.ShowTurnSeparators {
+		return TurnSeparators{}
+	}
+	if sep, ok := s.ChannelTurnSeparators[channelID]; ok {
+		return sep
+	}
+	return s.TurnSeparators
+}
+
+// ResponseAffixFor resolves the reply prefix and suffix for the given channel,
+// falling back to the global ResponsePrefix/ResponseSuffix.
+func (s *SystemConfig) ResponseAffixFor(channelID string) (prefix, suffix string) {
+	if affix, ok := s.ChannelResponseAffixes[channelID]; ok {
+		return affix.Prefix, affix.Suffix
+	}
+	return s.ResponsePrefix, s.ResponseSuffix
+}
+
+// OnlyLogLevelDiffers reports whether other differs from s in LogLevel alone,
+// meaning the change can be applied at runtime without restarting services.
+func (s *SystemConfig) OnlyLogLevelDiffers(other *SystemConfig) bool {
+	a, b := *s, *other
+	if a.LogLevel == b.LogLevel {
+		return false
+	}
+	a.LogLevel, b.LogLevel = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
+// IsAdmin reports whether userKey ("channel:user", see
+// api.SessionContext.UserKey) is listed in AdminUserIDs.
+func (s *SystemConfig) IsAdmin(userKey string) bool {
+	return slices.Contains(s.AdminUserIDs, userKey)
+}
+
+// IsRetryableStopReason reports whether an abnormal response ending with the
+// given stop reason should be retried, according to RetryStopReasons.
+func (s *SystemConfig) IsRetryableStopReason(reason string) bool {
+	return slices.ContainsFunc(s.RetryStopReasons, func(r string) bool {
+		return r == "*" || strings.EqualFold(r, reason)
+	})
+}
+
+// DataPath joins elem to DataDir, the root of all persisted data. An empty
+// DataDir stands for "data".
+func (s *SystemConfig) DataPath(elem ...string) string {
+	dir := s.DataDir
+	if dir == "" {
+		dir = "data"
+	}
+	return filepath.Join(append([]string{dir}, elem...)...)
+}
+
+// DownloadPath returns DownloadDir, defaulting to the "downloads" folder of
+// DataDir.
+func (s *SystemConfig) DownloadPath() string {
+	if s.DownloadDir != "" {
+		return s.DownloadDir
+	}
+	return s.DataPath("downloads")
+}
+
+// Validate reports settings that are out of range or not among their
+// accepted values, joined into one error. Settings are otherwise used as
+// given, so Validate is advisory unless the caller refuses to start.
+func (s *SystemConfig) Validate() error {
+	var errs []error
+	oneOf := func(field, value string, accepted ...string) {
+		if !slices.Contains(accepted, value) {
+			errs = append(errs, fmt.Errorf("%s: %q is not one of %s", field, value, strings.Join(accepted, ", ")))
+		}
+	}
+	nonNegative := func(field string, value int64) {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s: must not be negative, got %d", field, value))
+		}
+	}
+
+	oneOf("log_level", strings.ToLower(s.LogLevel), "debug", "info", "warn", "warning", "error")
+	oneOf("sender_names", s.SenderNames, "never", "group", "always")
+	oneOf("persist_thinking", s.PersistThinking, "full", "truncate", "none")
+	oneOf("monitor_log_rotation", s.MonitorLogRotation, "", "daily", "hourly", "none")
+	oneOf("cli_monitor_color", s.CLIMonitorColor, "", "auto", "always", "never")
+
+	nonNegative("max_retries", int64(s.MaxRetries))
+	nonNegative("retry_delay_ms", int64(s.RetryDelayMs))
+	nonNegative("llm_timeout_ms", int64(s.LLMTimeoutMs))
+	nonNegative("first_token_timeout_ms", int64(s.FirstTokenTimeoutMs))
+	nonNegative("internal_channel_buffer", int64(s.InternalChannelBuffer))
+	nonNegative("thinking_history_max_chars", int64(s.ThinkingHistoryMaxChars))
+	nonNegative("session_max_in_memory", int64(s.SessionMaxInMemory))
+	nonNegative("session_idle_ttl_ms", int64(s.SessionIdleTTLMs))
+	nonNegative("history_max_pinned", int64(s.HistoryMaxPinned))
+	nonNegative("session_backups", int64(s.SessionBackups))
+	nonNegative("attachment_max_age_ms", int64(s.AttachmentMaxAgeMs))
+	nonNegative("download_max_bytes", s.DownloadMaxBytes)
+
+	if s.TelegramMessageLimit <= 0 {
+		errs = append(errs, fmt.Errorf("telegram_message_limit: must be positive, got %d", s.TelegramMessageLimit))
+	}
+	for _, id := range s.AdminUserIDs {
+		if channel, user, ok := strings.Cut(id, ":"); !ok || channel == "" || user == "" {
+			errs = append(errs, fmt.Errorf("admin_user_ids: %q is not of the form \"channel:user\"", id))
+		}
+	}
+	if s.WebMonitorPort < 0 || s.WebMonitorPort > 65535 {
+		errs = append(errs, fmt.Errorf("web_monitor_port: %d is not a valid port", s.WebMonitorPort))
+	}
+	if s.WebMonitorPort > 0 && s.WebMonitorToken == "" && !isLoopbackHost(s.WebMonitorHost) {
+		errs = append(errs, fmt.Errorf("web_monitor_token: required when web_monitor_host %q is not a loopback address", s.WebMonitorHost))
+	}
+	if s.MirrorTarget != nil && s.MirrorTarget.ChannelID == "" {
+		errs = append(errs, fmt.Errorf("mirror_target: channel_id is required"))
+	}
+	for i, t := range s.ExternalTools {
+		if t.Command == "" {
+			errs = append(errs, fmt.Errorf("external_tools[%d]: command is required", i))
+		}
+		nonNegative(fmt.Sprintf("external_tools[%d].timeout_ms", i), int64(t.TimeoutMs))
+	}
+	for i, t := range s.HTTPTools {
+		if t.Name == "" || t.URL == "" {
+			errs = append(errs, fmt.Errorf("http_tools[%d]: name and url are required", i))
+		}
+		nonNegative(fmt.Sprintf("http_tools[%d].timeout_ms", i), int64(t.TimeoutMs))
+	}
+	return errors.Join(errs...)
+}
+
+// isLoopbackHost reports whether host names the loopback interface.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // DeepCopy creates a full copy of SystemConfig.
 func (s *SystemConfig) DeepCopy() *SystemConfig {
 	newSys := *s
+	if s.ChannelResponseAffixes != nil {
+		newSys.ChannelResponseAffixes = make(map[string]ResponseAffix, len(s.ChannelResponseAffixes))
+		for k, v := range s.ChannelResponseAffixes {
+			newSys.ChannelResponseAffixes[k] = v
+		}
+	}
+	if s.ChannelTurnSeparators != nil {
+		newSys.ChannelTurnSeparators = make(map[string]TurnSeparators, len(s.ChannelTurnSeparators))
+		for k, v := range s.ChannelTurnSeparators {
+			newSys.ChannelTurnSeparators[k] = v
+		}
+	}
+	if s.OSToolShell != nil {
+		newSys.OSToolShell = make(map[string]string, len(s.OSToolShell))
+		for k, v := range s.OSToolShell {
+			newSys.OSToolShell[k] = v
+		}
+	}
+	if s.CLIMonitorTheme != nil {
+		newSys.CLIMonitorTheme = make(map[string]string, len(s.CLIMonitorTheme))
+		for k, v := range s.CLIMonitorTheme {
+			newSys.CLIMonitorTheme[k] = v
+		}
+	}
+	newSys.Moderation.Keywords = append([]string(nil), s.Moderation.Keywords...)
+	newSys.AdminUserIDs = append([]string(nil), s.AdminUserIDs...)
+	newSys.RetryStopReasons = append([]string(nil), s.RetryStopReasons...)
+	newSys.DownloadAllowedMIME = append([]string(nil), s.DownloadAllowedMIME...)
+	newSys.NoToolsPatterns = append([]string(nil), s.NoToolsPatterns...)
+	newSys.InterruptKeywords = append([]string(nil), s.InterruptKeywords...)
+	newSys.CLIMonitorMuteChannels = append([]string(nil), s.CLIMonitorMuteChannels...)
+	newSys.MonitorFilter.IncludeChannels = append([]string(nil), s.MonitorFilter.IncludeChannels...)
+	newSys.MonitorFilter.ExcludeChannels = append([]string(nil), s.MonitorFilter.ExcludeChannels...)
+	newSys.MonitorFilter.IncludeUsers = append([]string(nil), s.MonitorFilter.IncludeUsers...)
+	newSys.MonitorFilter.ExcludeUsers = append([]string(nil), s.MonitorFilter.ExcludeUsers...)
+	if s.ExternalTools != nil {
+		newSys.ExternalTools = make([]ExternalToolConfig, len(s.ExternalTools))
+		for i, t := range s.ExternalTools {
+			t.Args = append([]string(nil), t.Args...)
+			t.Env = maps.Clone(t.Env)
+			newSys.ExternalTools[i] = t
+		}
+	}
+	if s.HTTPTools != nil {
+		newSys.HTTPTools = make([]HTTPToolConfig, len(s.HTTPTools))
+		for i, t := range s.HTTPTools {
+			t.Parameters = deepCopyJSON(t.Parameters)
+			t.Required = append([]string(nil), t.Required...)
+			t.Headers = maps.Clone(t.Headers)
+			newSys.HTTPTools[i] = t
+		}
+	}
+	if s.MirrorTarget != nil {
+		mirror := *s.MirrorTarget
+		newSys.MirrorTarget = &mirror
+	}
 	return &newSys
 }
 
+// deepCopyJSON clones a decoded JSON object, including nested objects and
+// arrays.
+func deepCopyJSON(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = deepCopyJSONValue(v)
+	}
+	return out
+}
+
+func deepCopyJSONValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return deepCopyJSON(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = deepCopyJSONValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 // DefaultSystemConfig returns a SystemConfig pointer initialized with hardcoded safe defaults.
 func DefaultSystemConfig() *SystemConfig {
 	return &SystemConfig{
 		MaxRetries:                3,
 		RetryDelayMs:              500,
+		MaxLLMCallsPerMessage:     20,
 		LLMTimeoutMs:              600000,
 		OllamaDefaultURL:          "http://localhost:11434/v1",
 		InternalChannelBuffer:     100,
 		ThinkingInitDelayMs:       500,
+		StreamCoalesceMs:          50,
+		StreamCoalesceChars:       80,
 		TelegramMessageLimit:      4000,
 		DownloadTimeoutMs:         10000,
 		ShowThinking:              true,
+		PersistThinking:           "full",
+		ThinkingHistoryMaxChars:   500,
 		LogLevel:                  "info",
+		LogSampleRate:             1,
+		LogLevelOverrideMs:        600000,
+		UseEmoji:                  true,
+		DataDir:                   "data",
+		DownloadMaxBytes:          50 << 20,
+		DownloadToolTimeoutMs:     300000,
+		DBToolDriver:              "sqlite",
+		DBToolMaxRows:             100,
 		EnableTools:               true,
+		SessionMaxInMemory:        1000,
+		SessionIdleTTLMs:          3600000,
 		HistorySummarizeThreshold: 10,
 		HistoryKeepRecentCount:    5,
+		HistoryMaxPinned:          10,
 		HistoryMaxChars:           10000,
 		HistoryMaxTokens:          4000,
+		ShowSummarizingStatus:     true,
+		ChannelMinStarted:         1,
+		ExtractFacts:              true,
+		SenderNames:               "never",
+		MonitorLogRotation:        "daily",
+		WebMonitorHost:            "127.0.0.1",
+		CLIMonitorMuteChannels:    []string{"cli"},
+		CLIMonitorColor:           "auto",
+		RetryStopReasons: []string{
+			"failed",
+			"malformed_function_call",
+			"unexpected_tool_call",
+			"other",
+			"unknown",
+		},
+		OSToolShell: map[string]string{
+			"windows": "powershell",
+			"darwin":  "/bin/zsh",
+			"linux":   "/bin/bash",
+		},
+		ChannelHealthCheckIntervalMs: 30000,
+		TurnSeparators: TurnSeparators{
+			ToolResult:  "── {tool} ──",
+			FinalAnswer: "── Answer ──",
+		},
+		Moderation: ModerationConfig{
+			Provider:    "keywords",
+			CheckInput:  true,
+			CheckOutput: true,
+		},
 	}
 }
 
-// Load reads and parses the JSON configuration files and returns configuration objects.
-func Load() (*Config, *SystemConfig, error) {
-	appPath := "config.json"
-	if _, err := os.Stat(appPath); os.IsNotExist(err) {
-		return nil, nil, fmt.Errorf("config file '%s' not found. please create one", appPath)
+// Default locations of the configuration files, relative to the working
+// directory.
+const (
+	DefaultConfigPath       = "config.json"
+	DefaultSystemConfigPath = "system.json"
+)
+
+// Environment variables overriding the configuration file locations. They
+// let containers and multi-instance setups relocate the files without flags.
+const (
+	EnvConfigPath       = "GENESIS_CONFIG"
+	EnvSystemConfigPath = "GENESIS_SYSTEM_CONFIG"
+)
+
+// Paths locates the configuration files.
+type Paths struct {
+	Config string // Application config (config.json)
+	System string // Engine settings (system.json)
+}
+
+// DefaultPaths returns the configuration file locations from the
+// environment, falling back to the defaults in the working directory. A
+// default JSON file that does not exist is replaced by a YAML or TOML one
+// with the same name, if present.
+func DefaultPaths() Paths {
+	paths := Paths{Config: formatAlternative(DefaultConfigPath), System: formatAlternative(DefaultSystemConfigPath)}
+	if p := os.Getenv(EnvConfigPath); p != "" {
+		paths.Config = p
 	}
+	if p := os.Getenv(EnvSystemConfigPath); p != "" {
+		paths.System = p
+	}
+	return paths
+}
 
-	appFile, err := os.ReadFile(appPath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+// formatAlternative returns the ".yaml", ".yml" or ".toml" sibling of a
+// missing JSON file, or path itself.
+func formatAlternative(path string) string {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return path
+	}
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range []string{".yaml", ".yml", ".toml"} {
+		if _, err := os.Stat(stem + ext); err == nil {
+			return stem + ext
+		}
 	}
+	return path
+}
 
-	var cfg Config
-	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(appFile, &cfg); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+// Load reads and parses the configuration files and returns configuration
+// objects. Files are JSON unless their extension is ".yaml", ".yml" or
+// ".toml". When the application config file does not exist, it is built
+// from the environment by LoadFromEnv instead.
+func Load(paths Paths) (*Config, *SystemConfig, error) {
+	cfg, err := loadAppConfig(paths.Config)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if err := cfg.Validate(); err != nil {
 		return nil, nil, err
 	}
 
-	sysCfg := LoadSystemConfig("system.json")
+	if cfg.SystemPromptFile != "" {
+		prompt, err := os.ReadFile(cfg.SystemPromptFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read system prompt file: %w", err)
+		}
+		cfg.SystemPrompt = strings.TrimSpace(string(prompt))
+	}
 
-	return &cfg, sysCfg, nil
+	sysCfg := LoadSystemConfig(paths.System)
+
+	return cfg, sysCfg, nil
+}
+
+// loadAppConfig parses the application config file at path, falling back to
+// the environment if the file does not exist.
+func loadAppConfig(path string) (*Config, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		cfg, envErr := LoadFromEnv()
+		if envErr != nil {
+			return nil, fmt.Errorf("config file '%s' not found and no configuration in the environment (%v). please create one", path, envErr)
+		}
+		return cfg, nil
+	}
+
+	appFile, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	appFile, err = toJSON(path, appFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var cfg Config
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(appFile, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return &cfg, nil
 }
 
 // LoadSystemConfig attempts to load system settings, returns defaults if it fails
 func LoadSystemConfig(path string) *SystemConfig {
+	cfg, err := ParseSystemConfig(path)
+	if err != nil {
+		return DefaultSystemConfig()
+	}
+	return cfg
+}
+
+// ParseSystemConfig reads system settings over the defaults like
+// LoadSystemConfig, but reports a file that cannot be parsed instead of
+// falling back. A missing file yields the defaults.
+func ParseSystemConfig(path string) (*SystemConfig, error) {
 	cfg := DefaultSystemConfig()
 
 	file, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
 	if err != nil {
-		return cfg
+		return nil, fmt.Errorf("failed to read system config: %w", err)
 	}
 
+	file, err = toJSON(path, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse system config: %w", err)
+	}
 	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(file, cfg); err != nil {
-		return cfg
+		return nil, fmt.Errorf("failed to parse system config: %w", err)
 	}
-
-	return cfg
+	return cfg, nil
 }
@@ -0,0 +1,234 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v3"
+)
+
+// configSource is this package's config.go. Its doc comments are the
+// reference documentation of every setting, so generated examples and
+// schemas are read from it and never drift from the code.
+//
+//go:embed config.go
+var configSource string
+
+// acceptedValuesRegex extracts the quoted choices of an "Accepted values:"
+// sentence in a field comment.
+var (
+	acceptedValuesRegex = regexp.MustCompile(`Accepted values:([^.]*(?:\.\S[^.]*)*)`)
+	quotedRegex         = regexp.MustCompile(`"([^"]*)"`)
+)
+
+var rawMessageType = reflect.TypeOf(jsoniter.RawMessage{})
+
+// ExportDocs writes an annotated example and a JSON Schema of both
+// configuration files into dir: config.example.yaml, config.schema.json,
+// system.example.yaml and system.schema.json. The examples hold every
+// setting with its documentation and default and load as they are; the
+// schemas enable editor completion, e.g. via "$schema" in a JSON file.
+func ExportDocs(dir string) error {
+	docs, err := fieldDocs()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name  string
+		title string
+		value any
+	}{
+		{"config", "Genesis application config", Config{Channels: map[string]jsoniter.RawMessage{}, LLM: jsoniter.RawMessage("[]")}},
+		{"system", "Genesis system settings", *DefaultSystemConfig()},
+	}
+	for _, f := range files {
+		v := reflect.ValueOf(f.value)
+
+		var example strings.Builder
+		fmt.Fprintf(&example, "# %s, generated from the defaults.\n", f.title)
+		writeExample(&example, v, docs, 0)
+		if err := os.WriteFile(filepath.Join(dir, f.name+".example.yaml"), []byte(example.String()), 0644); err != nil {
+			return err
+		}
+
+		schema := schemaFor(v, docs)
+		schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		schema["title"] = f.title
+		// Lets a config file reference the schema without failing validation
+		schema["properties"].(map[string]any)["$schema"] = map[string]any{"type": "string"}
+		data, err := jsoniter.ConfigCompatibleWithStandardLibrary.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, f.name+".schema.json"), append(data, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldDocs maps "Type.Field" to the field's doc comment in config.go.
+func fieldDocs() (map[string]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make(map[string]string)
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return false
+		}
+		for _, field := range st.Fields.List {
+			text := strings.TrimSpace(field.Doc.Text())
+			if text == "" {
+				text = strings.TrimSpace(field.Comment.Text())
+			}
+			for _, name := range field.Names {
+				docs[spec.Name.Name+"."+name.Name] = text
+			}
+		}
+		return false
+	})
+	return docs, nil
+}
+
+// jsonName returns the JSON key of a struct field, or "" if it is not
+// serialized.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" || !f.IsExported() {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// writeExample writes the fields of struct v as YAML, each preceded by its
+// documentation. Nested structs are expanded; other values are written as
+// YAML, with raw JSON settings parsed first.
+func writeExample(sb *strings.Builder, v reflect.Value, docs map[string]string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name := jsonName(f)
+		if name == "" {
+			continue
+		}
+
+		sb.WriteString("\n")
+		for _, line := range strings.Split(docs[t.Name()+"."+f.Name], "\n") {
+			if line != "" {
+				fmt.Fprintf(sb, "%s# %s\n", indent, line)
+			}
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			fmt.Fprintf(sb, "%s%s:\n", indent, name)
+			writeExample(sb, fv, docs, depth+1)
+			continue
+		}
+
+		value := fv.Interface()
+		if fv.Type() == rawMessageType {
+			var decoded any
+			if err := jsoniter.Unmarshal(fv.Bytes(), &decoded); err == nil {
+				value = decoded
+			}
+		}
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			data = []byte("null\n")
+		}
+		text := strings.TrimSuffix(string(data), "\n")
+		if strings.Contains(text, "\n") {
+			fmt.Fprintf(sb, "%s%s:\n%s%s\n", indent, name, indent+"  ", strings.ReplaceAll(text, "\n", "\n"+indent+"  "))
+		} else {
+			fmt.Fprintf(sb, "%s%s: %s\n", indent, name, text)
+		}
+	}
+}
+
+// schemaFor describes the type of v as JSON Schema. Struct fields carry
+// their documentation, non-zero defaults and, where the documentation lists
+// accepted values, an enum.
+func schemaFor(v reflect.Value, docs map[string]string) map[string]any {
+	t := v.Type()
+	switch {
+	case t == rawMessageType:
+		return map[string]any{}
+	case t.Kind() == reflect.Pointer:
+		elem := reflect.Zero(t.Elem())
+		if !v.IsNil() {
+			elem = v.Elem()
+		}
+		return schemaFor(elem, docs)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(reflect.Zero(t.Elem()), docs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(reflect.Zero(t.Elem()), docs)}
+	case reflect.Struct:
+		props := make(map[string]any)
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name := jsonName(f)
+			if name == "" {
+				continue
+			}
+			prop := schemaFor(v.Field(i), docs)
+			if doc := docs[t.Name()+"."+f.Name]; doc != "" {
+				prop["description"] = strings.ReplaceAll(doc, "\n", " ")
+				if m := acceptedValuesRegex.FindStringSubmatch(doc); m != nil && prop["type"] == "string" {
+					var enum []string
+					for _, q := range quotedRegex.FindAllStringSubmatch(m[1], -1) {
+						enum = append(enum, q[1])
+					}
+					if len(enum) > 0 {
+						prop["enum"] = enum
+					}
+				}
+			}
+			if fv := v.Field(i); !fv.IsZero() && fv.Type() != rawMessageType {
+				prop["default"] = fv.Interface()
+			}
+			props[name] = prop
+		}
+		return map[string]any{"type": "object", "properties": props}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Environment variables describing the application config when no config
+// file exists, so containers can run without one. LoadFromEnv documents how
+// they map to Config.
+const (
+	EnvLLM                 = "GENESIS_LLM"          // Whole "llm" section as JSON
+	EnvLLMType             = "GENESIS_LLM_TYPE"     // Provider of a single LLM group (e.g., "gemini")
+	EnvLLMModels           = "GENESIS_LLM_MODELS"   // Comma-separated models of that group
+	EnvLLMAPIKeys          = "GENESIS_LLM_API_KEYS" // Comma-separated API keys of that group
+	EnvLLMBaseURL          = "GENESIS_LLM_BASE_URL" // Custom endpoint of that group
+	EnvSystemPrompt        = "GENESIS_SYSTEM_PROMPT"
+	EnvSystemPromptFile    = "GENESIS_SYSTEM_PROMPT_FILE"
+	EnvSystemPromptNoTools = "GENESIS_SYSTEM_PROMPT_NO_TOOLS"
+	EnvChannelPrefix       = "GENESIS_CHANNEL_" // GENESIS_CHANNEL_<NAME>[_<SETTING>]
+)
+
+// LoadFromEnv builds the application config from environment variables. It
+// is the fallback of Load when the config file does not exist.
+//
+// The LLM section is either GENESIS_LLM, holding the JSON of a config
+// file's "llm" value, or a single provider group described by
+// GENESIS_LLM_TYPE, GENESIS_LLM_MODELS, GENESIS_LLM_API_KEYS and
+// GENESIS_LLM_BASE_URL. GENESIS_SYSTEM_PROMPT, GENESIS_SYSTEM_PROMPT_FILE and
+// GENESIS_SYSTEM_PROMPT_NO_TOOLS set the prompts.
+//
+// Channels are enabled by GENESIS_CHANNEL_<NAME>, holding the JSON of the
+// channel's settings, and/or GENESIS_CHANNEL_<NAME>_<SETTING> for a single
+// setting, which overrides the JSON. Names and settings are lowercased, so
+// GENESIS_CHANNEL_TELEGRAM_TOKEN sets "token" of "telegram". Setting values
+// that are valid JSON (numbers, booleans, arrays, quoted strings) are used
+// as such; anything else is a string.
+//
+// An error is returned if no LLM is configured through the environment.
+func LoadFromEnv() (*Config, error) {
+	cfg := Config{
+		SystemPrompt:        os.Getenv(EnvSystemPrompt),
+		SystemPromptFile:    os.Getenv(EnvSystemPromptFile),
+		SystemPromptNoTools: os.Getenv(EnvSystemPromptNoTools),
+	}
+
+	switch {
+	case os.Getenv(EnvLLM) != "":
+		cfg.LLM = jsoniter.RawMessage(os.Getenv(EnvLLM))
+		if !jsoniter.Valid(cfg.LLM) {
+			return nil, fmt.Errorf("%s is not valid JSON", EnvLLM)
+		}
+	case os.Getenv(EnvLLMType) != "":
+		group := map[string]any{
+			"type":   os.Getenv(EnvLLMType),
+			"models": splitList(os.Getenv(EnvLLMModels)),
+		}
+		if keys := splitList(os.Getenv(EnvLLMAPIKeys)); len(keys) > 0 {
+			group["api_keys"] = keys
+		}
+		if url := os.Getenv(EnvLLMBaseURL); url != "" {
+			group["base_url"] = url
+		}
+		data, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal([]any{group})
+		if err != nil {
+			return nil, err
+		}
+		cfg.LLM = data
+	default:
+		return nil, fmt.Errorf("neither %s nor %s is set", EnvLLM, EnvLLMType)
+	}
+
+	channels, err := channelsFromEnv(os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	cfg.Channels = channels
+	return &cfg, nil
+}
+
+// channelsFromEnv collects the GENESIS_CHANNEL_* variables of environ into
+// channel settings.
+func channelsFromEnv(environ []string) (map[string]jsoniter.RawMessage, error) {
+	type single struct{ name, setting, value string }
+	settings := make(map[string]map[string]any)
+	var singles []single
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(key, EnvChannelPrefix)
+		if !ok {
+			continue
+		}
+		name, setting, isSingle := strings.Cut(strings.ToLower(rest), "_")
+		if name == "" || (isSingle && setting == "") {
+			continue
+		}
+		if settings[name] == nil {
+			settings[name] = make(map[string]any)
+		}
+		if isSingle {
+			// Applied after every channel JSON, whatever the order of environ
+			singles = append(singles, single{name, setting, value})
+			continue
+		}
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		s := settings[name]
+		if err := jsoniter.Unmarshal([]byte(value), &s); err != nil {
+			return nil, fmt.Errorf("%s is not a JSON object: %w", key, err)
+		}
+	}
+	for _, s := range singles {
+		var v any
+		if err := jsoniter.Unmarshal([]byte(s.value), &v); err != nil {
+			v = s.value
+		}
+		settings[s.name][s.setting] = v
+	}
+
+	channels := make(map[string]jsoniter.RawMessage, len(settings))
+	for name, s := range settings {
+		data, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("channel %q: %w", name, err)
+		}
+		channels[name] = data
+	}
+	return channels, nil
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
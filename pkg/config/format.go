@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v3"
+)
+
+// toJSON converts a configuration file to JSON according to its extension,
+// so every format decodes into the same structs. Channel and LLM settings
+// stay jsoniter.RawMessage and reach their factories as JSON, whatever the
+// file was written in. Files without a recognized extension are read as JSON.
+func toJSON(path string, data []byte) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		doc, err := jsonCompatible(doc)
+		if err != nil {
+			return nil, err
+		}
+		return jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(doc)
+	case ".toml":
+		var doc map[string]any
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(doc)
+	default:
+		return data, nil
+	}
+}
+
+// jsonCompatible rewrites YAML mappings with non-string keys (e.g., "1: x"
+// or "true: y") into string-keyed maps, which JSON requires.
+func jsonCompatible(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			conv, err := jsonCompatible(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = conv
+		}
+		return v, nil
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, elem := range v {
+			conv, err := jsonCompatible(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(k)] = conv
+		}
+		return m, nil
+	case []any:
+		for i, elem := range v {
+			conv, err := jsonCompatible(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = conv
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,67 @@
+package gateway
+
+import "log/slog"
+
+// replyState tracks the configured reply affixes across the replies of the
+// runs of one session. An agentic turn reaches the channel as several
+// streams (answer segments, tool rounds, retries, continuations), but the
+// user sees them as one reply: the prefix goes before its first text and
+// the suffix after its last.
+type replyState struct {
+	prefixed      bool // The prefix has been sent
+	suffixPending bool // Text was sent, so the suffix is due when the runs end
+}
+
+// activeReply returns the reply state of session's active runs, or nil if
+// none is running.
+func (g *GatewayManager) activeReply(session SessionContext) *replyState {
+	g.runsMu.Lock()
+	defer g.runsMu.Unlock()
+	return g.replies[sessionKey(session)]
+}
+
+// claimPrefix reports whether the prefix should be sent before the text of
+// the current stream. Streams outside runs always carry it.
+func (g *GatewayManager) claimPrefix(reply *replyState) bool {
+	if reply == nil {
+		return true
+	}
+	g.runsMu.Lock()
+	defer g.runsMu.Unlock()
+	if reply.prefixed {
+		return false
+	}
+	reply.prefixed = true
+	return true
+}
+
+// deferSuffix reports whether the suffix is left to finishReply rather than
+// appended to the current stream. Streams outside runs carry it themselves.
+func (g *GatewayManager) deferSuffix(reply *replyState) bool {
+	if reply == nil {
+		return false
+	}
+	g.runsMu.Lock()
+	defer g.runsMu.Unlock()
+	reply.suffixPending = true
+	return true
+}
+
+// finishReply sends the suffix of a reply once the runs of its session have
+// ended, after all their segments have been delivered.
+func (g *GatewayManager) finishReply(session SessionContext, reply *replyState) {
+	if !reply.suffixPending || g.sysCfg == nil {
+		return
+	}
+	_, suffix := g.sysCfg.ResponseAffixFor(session.ChannelID)
+	c, ok := g.GetChannel(session.ChannelID)
+	if suffix == "" || !ok {
+		return
+	}
+
+	unlock := g.lockDelivery(session)
+	defer unlock()
+	if err := c.Send(session, suffix); err != nil {
+		slog.Error("Failed to send reply suffix", "channel", session.ChannelID, "error", err)
+	}
+}
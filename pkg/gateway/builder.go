@@ -1,10 +1,13 @@
 package gateway
 
 import (
+	"errors"
 	"fmt"
 	"genesis/pkg/api"
 	"genesis/pkg/config"
 	"genesis/pkg/monitor"
+	"log/slog"
+	"time"
 )
 
 // GatewayBuilder provides a fluent builder pattern interface for constructing
@@ -14,7 +17,7 @@ import (
 // as instances — the Builder simply assembles and starts them.
 type GatewayBuilder struct {
 	gw             *GatewayManager                                 // The GatewayManager instance being constructed
-	monitor        monitor.Monitor                                 // Monitoring implementation to be injected
+	monitors       []monitor.Monitor                               // Monitoring implementations to be injected
 	systemConfig   *config.SystemConfig                            // Technical parameters for the gateway
 	handlerBuilder func(api.MessageResponder) api.MessageProcessor // Unified strategy to construct and wire the message handler
 	channels       []api.Channel                                   // Pre-built channel instances to register
@@ -29,10 +32,20 @@ func NewGatewayBuilder() *GatewayBuilder {
 	}
 }
 
-// WithMonitor injects a monitoring implementation into the builder.
-// This monitor will be started automatically during the Build() process.
-func (b *GatewayBuilder) WithMonitor(m monitor.Monitor) *GatewayBuilder {
-	b.monitor = m
+// WithMonitor injects one or more monitoring implementations into the builder.
+// It may be called repeatedly; all monitors receive every message and are
+// started automatically during the Build() process.
+func (b *GatewayBuilder) WithMonitor(m ...monitor.Monitor) *GatewayBuilder {
+	b.monitors = append(b.monitors, m...)
+	return b
+}
+
+// WithEventSink registers receivers of the gateway's structured events
+// (see api.Event), e.g. for alerting or analytics integrations.
+func (b *GatewayBuilder) WithEventSink(s ...api.EventSink) *GatewayBuilder {
+	for _, sink := range s {
+		b.gw.RegisterEventSink(sink)
+	}
 	return b
 }
 
@@ -77,9 +90,19 @@ func (b *GatewayBuilder) Build() (*GatewayManager, error) {
 	}
 
 	// 1. Initialize and start the monitoring service
-	if b.monitor != nil {
-		b.gw.SetMonitor(b.monitor)
-		if err := b.monitor.Start(); err != nil {
+	if len(b.monitors) > 0 {
+		var m monitor.Monitor = b.monitors[0]
+		if len(b.monitors) > 1 {
+			m = monitor.NewMultiMonitor(b.monitors...)
+		}
+		if b.systemConfig != nil && !b.systemConfig.MonitorFilter.IsZero() {
+			filter := b.systemConfig.MonitorFilter
+			m = monitor.NewFilteredMonitor(m, func(msg monitor.MonitorMessage) bool {
+				return filter.Allows(msg.ChannelID, msg.UserID, msg.Username)
+			})
+		}
+		b.gw.SetMonitor(m)
+		if err := m.Start(); err != nil {
 			return nil, fmt.Errorf("failed to start monitor: %w", err)
 		}
 	}
@@ -102,9 +125,28 @@ func (b *GatewayBuilder) Build() (*GatewayManager, error) {
 		b.agentEngine.SetResponder(b.gw)
 	}
 
-	// 5. Start all registered channels
+	// 5. Start all registered channels; unless fail-fast is requested, a
+	// channel with bad credentials does not keep the others offline
 	if err := b.gw.StartAll(); err != nil {
-		return nil, fmt.Errorf("failed to start channels: %w", err)
+		minStarted := 1
+		if b.systemConfig != nil {
+			if b.systemConfig.ChannelFailFast {
+				return nil, fmt.Errorf("failed to start channels: %w", err)
+			}
+			minStarted = b.systemConfig.ChannelMinStarted
+		}
+
+		var startErr *StartError
+		if !errors.As(err, &startErr) || len(startErr.Started) < minStarted {
+			b.gw.StopAll()
+			return nil, fmt.Errorf("failed to start channels (need at least %d running): %w", minStarted, err)
+		}
+		slog.Warn("Continuing with the channels that started", "started", startErr.Started, "error", err)
+	}
+
+	// 6. Watch channel health and restart channels that die
+	if b.systemConfig != nil && b.systemConfig.ChannelHealthCheckIntervalMs > 0 {
+		b.gw.StartHealthChecks(time.Duration(b.systemConfig.ChannelHealthCheckIntervalMs) * time.Millisecond)
 	}
 
 	return b.gw, nil
@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"genesis/pkg/config"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// deadLetterMu serializes appends so concurrent failures never interleave lines.
+var deadLetterMu sync.Mutex
+
+// deadLetter is a single undeliverable reply, written as one JSON line.
+type deadLetter struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Session   SessionContext `json:"session"`
+	Content   string         `json:"content"`
+	Error     string         `json:"error"`
+}
+
+// deadLetterDir is where replies that could not be delivered are recorded:
+// the "deadletter" folder of the data directory.
+func (g *GatewayManager) deadLetterDir() string {
+	if g.sysCfg == nil {
+		return (&config.SystemConfig{}).DataPath("deadletter")
+	}
+	return g.sysCfg.DataPath("deadletter")
+}
+
+// writeDeadLetter appends an undeliverable reply to a daily JSONL file in
+// dir so operators can audit "the bot didn't answer" reports.
+func writeDeadLetter(dir string, session SessionContext, content string, sendErr error) {
+	entry := deadLetter{
+		Timestamp: time.Now(),
+		Session:   session,
+		Content:   content,
+		Error:     sendErr.Error(),
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Error("Failed to encode dead letter", "error", err)
+		return
+	}
+
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.Error("Failed to create dead letter directory", "error", err)
+		return
+	}
+	path := filepath.Join(dir, entry.Timestamp.Format("2006-01-02")+".jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		slog.Error("Failed to open dead letter file", "error", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		slog.Error("Failed to write dead letter", "error", err)
+		return
+	}
+	slog.Warn("Reply undeliverable, recorded in dead letter log", "channel", session.ChannelID, "chat", session.ChatID, "file", path)
+}
@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// deliveryLock orders the replies streamed to one recipient. The turn is
+// held by a whole run (see BeginRun) or, outside runs, by a single reply,
+// and is handed to waiters in arrival order. mu serializes the streams sent
+// within a turn. Fields other than mu are protected by
+// GatewayManager.deliveryMu.
+type deliveryLock struct {
+	mu      sync.Mutex
+	held    bool            // The turn is taken
+	byRun   bool            // The turn is held by a run rather than a single reply
+	waiters []*deliveryWait // Queued for the turn, oldest first
+	refs    int             // Holders and waiters; the lock is dropped from the map at zero
+}
+
+// deliveryWait is a place in the queue for a recipient's turn.
+type deliveryWait struct {
+	ready chan struct{} // Closed when the turn is handed over
+	byRun bool
+}
+
+// deliveryKey identifies the recipient of session's replies.
+func deliveryKey(session SessionContext) string {
+	return sessionKey(session) + "_" + session.UserID
+}
+
+// acquireDelivery waits for the recipient's turn, in arrival order, and
+// returns the function passing it on. It fails only if ctx is done first.
+func (g *GatewayManager) acquireDelivery(ctx context.Context, session SessionContext, byRun bool) (func(), error) {
+	key := deliveryKey(session)
+
+	g.deliveryMu.Lock()
+	l := g.delivery[key]
+	if l == nil {
+		l = &deliveryLock{}
+		g.delivery[key] = l
+	}
+	l.refs++
+	if !l.held {
+		l.held, l.byRun = true, byRun
+		g.deliveryMu.Unlock()
+		return func() { g.releaseDelivery(key, l) }, nil
+	}
+	w := &deliveryWait{ready: make(chan struct{}), byRun: byRun}
+	l.waiters = append(l.waiters, w)
+	g.deliveryMu.Unlock()
+
+	select {
+	case <-w.ready:
+		return func() { g.releaseDelivery(key, l) }, nil
+	case <-ctx.Done():
+	}
+
+	g.deliveryMu.Lock()
+	if i := slices.Index(l.waiters, w); i >= 0 {
+		l.waiters = slices.Delete(l.waiters, i, i+1)
+		g.dropDeliveryLocked(key, l)
+		g.deliveryMu.Unlock()
+		return nil, context.Cause(ctx)
+	}
+	g.deliveryMu.Unlock()
+	// The turn was handed over meanwhile; pass it on
+	g.releaseDelivery(key, l)
+	return nil, context.Cause(ctx)
+}
+
+// releaseDelivery hands the turn to the oldest waiter, if any.
+func (g *GatewayManager) releaseDelivery(key string, l *deliveryLock) {
+	g.deliveryMu.Lock()
+	defer g.deliveryMu.Unlock()
+	if len(l.waiters) > 0 {
+		next := l.waiters[0]
+		l.waiters = l.waiters[1:]
+		l.byRun = next.byRun
+		close(next.ready)
+	} else {
+		l.held, l.byRun = false, false
+	}
+	g.dropDeliveryLocked(key, l)
+}
+
+// dropDeliveryLocked releases a reference to l, removing it from the map
+// once unused. The caller must hold g.deliveryMu.
+func (g *GatewayManager) dropDeliveryLocked(key string, l *deliveryLock) {
+	l.refs--
+	if l.refs == 0 {
+		delete(g.delivery, key)
+	}
+}
+
+// lockDelivery waits until the reply about to be streamed to the recipient
+// of session may start and returns the function releasing it. While a run
+// holds the recipient's turn, streams to the recipient belong to that run
+// and only wait for each other; otherwise the reply takes the turn itself.
+// Together with channels returning from Stream only once the blocks are
+// delivered, this makes every reply a barrier: a segment of an agentic turn
+// (assistant text, tool results, the next answer) is fully flushed before
+// the next one starts, and the replies of overlapping runs do not
+// interleave.
+func (g *GatewayManager) lockDelivery(session SessionContext) func() {
+	key := deliveryKey(session)
+
+	g.deliveryMu.Lock()
+	if l := g.delivery[key]; l != nil && l.byRun {
+		l.refs++
+		g.deliveryMu.Unlock()
+		l.mu.Lock()
+		return func() {
+			l.mu.Unlock()
+			g.deliveryMu.Lock()
+			g.dropDeliveryLocked(key, l)
+			g.deliveryMu.Unlock()
+		}
+	}
+	g.deliveryMu.Unlock()
+
+	// A background context never ends, so acquiring cannot fail
+	release, _ := g.acquireDelivery(context.Background(), session, false)
+	return release
+}
@@ -0,0 +1,175 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"genesis/pkg/api"
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingChannel delivers blocks slowly and records their text in
+// delivery order. started is signaled when a stream delivers its first block.
+type recordingChannel struct {
+	mu      sync.Mutex
+	log     []string
+	started chan struct{}
+}
+
+func newRecordingChannel() *recordingChannel {
+	return &recordingChannel{started: make(chan struct{}, 10)}
+}
+
+func (c *recordingChannel) ID() string                            { return "rec" }
+func (c *recordingChannel) Start(api.ChannelContext) error        { return nil }
+func (c *recordingChannel) Stop() error                           { return nil }
+func (c *recordingChannel) Send(api.SessionContext, string) error { return nil }
+
+func (c *recordingChannel) Stream(_ api.SessionContext, blocks <-chan llm.ContentBlock) error {
+	first := true
+	for b := range blocks {
+		time.Sleep(5 * time.Millisecond)
+		c.mu.Lock()
+		c.log = append(c.log, b.Text)
+		c.mu.Unlock()
+		if first {
+			c.started <- struct{}{}
+			first = false
+		}
+	}
+	return nil
+}
+
+func (c *recordingChannel) delivered() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.log)
+}
+
+// textBlocks returns a closed channel holding a text block per string.
+func textBlocks(texts ...string) chan llm.ContentBlock {
+	ch := make(chan llm.ContentBlock, len(texts))
+	for _, text := range texts {
+		ch <- llm.NewTextBlock(text)
+	}
+	close(ch)
+	return ch
+}
+
+func newDeliveryTestGateway(t *testing.T) (*GatewayManager, *recordingChannel, api.SessionContext) {
+	t.Helper()
+	sysCfg := config.DefaultSystemConfig()
+	sysCfg.StreamFlushIntervalMs = 0
+	g := NewGatewayManager().WithSystemConfig(sysCfg)
+	c := newRecordingChannel()
+	g.Register(c)
+	return g, c, api.SessionContext{ChannelID: "rec", UserID: "u1", ChatID: "c1"}
+}
+
+func TestStreamReplyDeliversConsecutiveRepliesInOrder(t *testing.T) {
+	g, c, session := newDeliveryTestGateway(t)
+
+	for _, reply := range [][]string{{"step1"}, {"tool-out"}, {"step2"}, {"tool-out"}, {"final"}} {
+		if err := g.StreamReply(session, textBlocks(reply...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"step1", "tool-out", "step2", "tool-out", "final"}
+	if got := c.delivered(); !slices.Equal(got, want) {
+		t.Fatalf("delivered %q, want %q", got, want)
+	}
+}
+
+func TestStreamReplySerializesOverlappingReplies(t *testing.T) {
+	g, c, session := newDeliveryTestGateway(t)
+
+	// The first reply is still streaming when the second one starts
+	first := make(chan llm.ContentBlock)
+	firstDone := make(chan error, 1)
+	go func() { firstDone <- g.StreamReply(session, first) }()
+	first <- llm.NewTextBlock("a1")
+	<-c.started
+
+	secondDone := make(chan error, 1)
+	go func() { secondDone <- g.StreamReply(session, textBlocks("b1", "b2")) }()
+	time.Sleep(20 * time.Millisecond)
+	first <- llm.NewTextBlock("a2")
+	close(first)
+
+	for _, done := range []chan error{firstDone, secondDone} {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"a1", "a2", "b1", "b2"}
+	if got := c.delivered(); !slices.Equal(got, want) {
+		t.Fatalf("delivered %q, want %q", got, want)
+	}
+}
+
+func TestBeginRunDeliversOverlappingRunsInTurn(t *testing.T) {
+	g, c, session := newDeliveryTestGateway(t)
+	ctx := context.Background()
+
+	_, doneA := g.BeginRun(ctx, session)
+	if err := g.StreamReply(session, textBlocks("a-step1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Runs B and C start while A is between segments and queue in order
+	var wg sync.WaitGroup
+	for _, name := range []string{"b", "c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, done := g.BeginRun(ctx, session)
+			defer done()
+			g.StreamReply(session, textBlocks(name+"-step1"))
+			g.StreamReply(session, textBlocks(name+"-step2"))
+		}()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := g.StreamReply(session, textBlocks("a-step2")); err != nil {
+		t.Fatal(err)
+	}
+	doneA()
+	wg.Wait()
+
+	want := []string{"a-step1", "a-step2", "b-step1", "b-step2", "c-step1", "c-step2"}
+	if got := c.delivered(); !slices.Equal(got, want) {
+		t.Fatalf("delivered %q, want %q", got, want)
+	}
+}
+
+func TestBeginRunQueuedRunCanBeInterrupted(t *testing.T) {
+	g, _, session := newDeliveryTestGateway(t)
+
+	_, doneA := g.BeginRun(context.Background(), session)
+	defer doneA()
+
+	queued := make(chan context.Context)
+	go func() {
+		ctx, done := g.BeginRun(context.Background(), session)
+		defer done()
+		queued <- ctx
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	if n := g.CancelRuns(session); n != 2 {
+		t.Fatalf("cancelled %d runs, want 2", n)
+	}
+	select {
+	case ctx := <-queued:
+		if !errors.Is(context.Cause(ctx), api.ErrRunCancelled) {
+			t.Fatalf("queued run context cause = %v, want ErrRunCancelled", context.Cause(ctx))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued run still waiting for its turn after being interrupted")
+	}
+}
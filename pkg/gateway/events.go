@@ -0,0 +1,56 @@
+package gateway
+
+import (
+	"genesis/pkg/api"
+	"genesis/pkg/monitor"
+	"time"
+)
+
+// RegisterEventSink adds a sink that receives every event emitted through
+// the gateway from now on.
+func (g *GatewayManager) RegisterEventSink(s api.EventSink) {
+	g.sinksMu.Lock()
+	defer g.sinksMu.Unlock()
+	g.sinks = append(g.sinks, s)
+}
+
+// Emit delivers an event to all registered sinks, stamping it with the
+// current time if unset and redacting its content. It implements
+// api.EventEmitter.
+func (g *GatewayManager) Emit(ev api.Event) {
+	g.sinksMu.RLock()
+	sinks := g.sinks
+	g.sinksMu.RUnlock()
+	if len(sinks) == 0 {
+		return
+	}
+
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+	ev.Content = g.redact(ev.Content)
+	for _, s := range sinks {
+		s.OnEvent(ev)
+	}
+}
+
+// emitReply reports the outcome of a streamed reply: delivery failures and
+// error output as error events, and delivered replies and notices as
+// reply_sent. Tool output is reported by the engine as tool_executed.
+func (g *GatewayManager) emitReply(session SessionContext, messageType, text, errText string, sendErr error) {
+	switch {
+	case sendErr != nil:
+		g.Emit(api.Event{Type: api.EventError, Session: session, Content: text, Error: "delivery failed: " + sendErr.Error()})
+		return
+	case text == "":
+	case messageType == monitor.MessageTypeAssistant:
+		g.Emit(api.Event{Type: api.EventReplySent, Session: session, Role: "assistant", Content: text})
+	case messageType == monitor.MessageTypeSystem:
+		g.Emit(api.Event{Type: api.EventReplySent, Session: session, Role: "system", Content: text})
+	case messageType == monitor.MessageTypeError:
+		g.Emit(api.Event{Type: api.EventError, Session: session, Content: text})
+	}
+	if errText != "" {
+		g.Emit(api.Event{Type: api.EventError, Session: session, Content: errText})
+	}
+}
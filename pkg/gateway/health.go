@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"context"
+	"genesis/pkg/api"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+const (
+	channelRestartBaseDelay = 5 * time.Second // Wait before the second restart attempt
+	channelRestartMaxDelay  = 5 * time.Minute // Upper bound of the restart backoff
+)
+
+// ChannelHealth is the health report of a single channel.
+type ChannelHealth struct {
+	Healthy     bool      `json:"healthy"`
+	Error       string    `json:"error,omitempty"`        // Last health check failure
+	Restarts    int       `json:"restarts"`               // Automatic restarts since startup
+	LastRestart time.Time `json:"last_restart,omitempty"` // Time of the most recent restart
+}
+
+// channelHealthState tracks restart backoff for one channel.
+type channelHealthState struct {
+	ChannelHealth
+	failures    int       // Consecutive failed checks since the channel was last healthy
+	nextAttempt time.Time // Earliest time of the next restart
+}
+
+// StartHealthChecks polls every api.HealthCheckable channel at the given
+// interval and restarts unhealthy ones (Stop, then Start) with exponential
+// backoff. It runs until StopAll is called.
+func (g *GatewayManager) StartHealthChecks(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.healthMu.Lock()
+	g.stopHealth = cancel
+	g.healthMu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				g.checkChannels(ctx)
+			}
+		}
+	}()
+}
+
+// checkChannels runs one round of health checks.
+func (g *GatewayManager) checkChannels(ctx context.Context) {
+	g.mu.RLock()
+	channels := maps.Clone(g.channels)
+	g.mu.RUnlock()
+
+	for _, id := range slices.Sorted(maps.Keys(channels)) {
+		hc, ok := channels[id].(api.HealthCheckable)
+		if !ok || ctx.Err() != nil {
+			continue
+		}
+
+		err := hc.Health()
+
+		g.healthMu.Lock()
+		state := g.health[id]
+		if state == nil {
+			state = &channelHealthState{}
+			g.health[id] = state
+		}
+		if err == nil {
+			state.Healthy, state.Error, state.failures = true, "", 0
+			g.healthMu.Unlock()
+			continue
+		}
+
+		state.Healthy, state.Error = false, err.Error()
+		now := time.Now()
+		if now.Before(state.nextAttempt) {
+			g.healthMu.Unlock()
+			continue
+		}
+		state.failures++
+		state.Restarts++
+		state.LastRestart = now
+		attempt := state.failures
+		// Cap the shift so the delay cannot overflow after many attempts
+		delay := min(channelRestartBaseDelay<<min(attempt-1, 16), channelRestartMaxDelay)
+		state.nextAttempt = now.Add(delay)
+		g.healthMu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("Channel unhealthy, restarting", "id", id, "error", err, "attempt", attempt, "next_retry_after", delay)
+		if err := hc.Stop(); err != nil {
+			slog.Error("Error stopping unhealthy channel", "id", id, "error", err)
+		}
+		if err := hc.Start(g); err != nil {
+			slog.Error("Channel restart failed", "id", id, "error", err)
+		} else {
+			slog.Info("Channel restarted", "id", id)
+		}
+	}
+}
+
+// HealthReport returns the health of every registered channel and whether
+// all of them are healthy. Channels without health checks count as healthy.
+func (g *GatewayManager) HealthReport() (map[string]ChannelHealth, bool) {
+	g.mu.RLock()
+	channels := maps.Clone(g.channels)
+	g.mu.RUnlock()
+
+	g.healthMu.Lock()
+	defer g.healthMu.Unlock()
+
+	report := make(map[string]ChannelHealth, len(channels))
+	allHealthy := true
+	for id, c := range channels {
+		h := ChannelHealth{Healthy: true}
+		if state := g.health[id]; state != nil {
+			h = state.ChannelHealth
+		}
+		// Report the live status rather than the last polled one
+		if hc, ok := c.(api.HealthCheckable); ok {
+			if err := hc.Health(); err != nil {
+				h.Healthy, h.Error = false, err.Error()
+			} else {
+				h.Healthy, h.Error = true, ""
+			}
+		}
+		allHealthy = allHealthy && h.Healthy
+		report[id] = h
+	}
+	return report, allHealthy
+}
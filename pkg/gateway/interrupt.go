@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"context"
+	"genesis/pkg/api"
+	"genesis/pkg/utils"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/width"
+)
+
+// stopCommand interrupts the active run regardless of InterruptKeywords.
+const stopCommand = "/stop"
+
+// run is an active run registered with BeginRun.
+type run struct {
+	cancel context.CancelCauseFunc
+}
+
+// BeginRun registers a run for session and returns its context, which is
+// cancelled with api.ErrRunCancelled if the user interrupts it. The returned
+// function must be called when the run finishes; once the session's last run
+// has, it sends the reply suffix. Runs take turns delivering to a recipient:
+// BeginRun waits until the earlier runs for the same user and chat have
+// finished, or until the run is interrupted while queued. It implements
+// api.RunTracker.
+func (g *GatewayManager) BeginRun(ctx context.Context, session SessionContext) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	r := &run{cancel: cancel}
+	key := sessionKey(session)
+
+	g.runsMu.Lock()
+	if g.runs[key] == nil {
+		g.runs[key] = make(map[*run]bool)
+		g.replies[key] = &replyState{}
+	}
+	g.runs[key][r] = true
+	g.runsMu.Unlock()
+
+	// The run's replies are delivered in one turn; a run interrupted while
+	// queued goes on without it, its context already cancelled
+	releaseDelivery, err := g.acquireDelivery(ctx, session, true)
+	if err != nil {
+		releaseDelivery = func() {}
+	}
+
+	return ctx, func() {
+		var reply *replyState
+		g.runsMu.Lock()
+		delete(g.runs[key], r)
+		if len(g.runs[key]) == 0 {
+			delete(g.runs, key)
+			reply = g.replies[key]
+			delete(g.replies, key)
+		}
+		g.runsMu.Unlock()
+		cancel(nil)
+		if reply != nil {
+			g.finishReply(session, reply)
+		}
+		releaseDelivery()
+	}
+}
+
+// CancelRuns interrupts the active runs of session and returns how many
+// there were.
+func (g *GatewayManager) CancelRuns(session SessionContext) int {
+	g.runsMu.Lock()
+	defer g.runsMu.Unlock()
+	runs := g.runs[sessionKey(session)]
+	for r := range runs {
+		r.cancel(api.ErrRunCancelled)
+	}
+	return len(runs)
+}
+
+// interrupt handles a message asking to stop the session's active run,
+// "/stop" or one of InterruptKeywords. It reports whether the message was
+// consumed; keywords sent while nothing runs are left to the handler as
+// ordinary messages.
+func (g *GatewayManager) interrupt(ctx context.Context, msg *UnifiedMessage) bool {
+	isCommand := strings.TrimSpace(msg.Content) == stopCommand
+	if !isCommand && !g.isInterruptKeyword(msg.Content) {
+		return false
+	}
+
+	if n := g.CancelRuns(msg.Session); n > 0 {
+		slog.InfoContext(ctx, "Run interrupted by the user", "channel", msg.Session.ChannelID, "runs", n)
+		g.SendReply(msg.Session, utils.IconInfo.String()+" Stopped.")
+		return true
+	}
+	if isCommand {
+		g.SendReply(msg.Session, utils.IconInfo.String()+" Nothing to stop.")
+		return true
+	}
+	return false
+}
+
+// isInterruptKeyword reports whether content consists of one of the
+// configured interrupt keywords.
+func (g *GatewayManager) isInterruptKeyword(content string) bool {
+	if g.sysCfg == nil || len(g.sysCfg.InterruptKeywords) == 0 {
+		return false
+	}
+	word := normalizeKeyword(content)
+	if word == "" {
+		return false
+	}
+	return slices.ContainsFunc(g.sysCfg.InterruptKeywords, func(k string) bool {
+		return normalizeKeyword(k) == word
+	})
+}
+
+// normalizeKeyword folds text for keyword comparison across locales: case
+// is folded by Unicode rules, full-width forms (common with CJK input
+// methods) become their narrow equivalents, and surrounding whitespace and
+// punctuation such as "!" or "。" are dropped.
+func normalizeKeyword(text string) string {
+	text = width.Fold.String(text)
+	text = cases.Fold().String(text)
+	return strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+}
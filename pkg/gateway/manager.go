@@ -1,12 +1,16 @@
 package gateway
 
 import (
+	"context"
 	"fmt"
 	"genesis/pkg/api"
 	"genesis/pkg/config"
 	"genesis/pkg/llm"
 	"genesis/pkg/monitor"
+	"genesis/pkg/utils"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -16,17 +20,34 @@ import (
 // communication channels and unifies message routing for both input and output.
 // It implements the api.ChannelContext interface to receive callbacks from channels.
 type GatewayManager struct {
-	channels   map[string]api.Channel // Registry of active channel instances indexed by ID
-	msgHandler api.MessageHandler     // Callback for business logic processing
-	monitor    monitor.Monitor        // Interface for broadcasting message logs to monitoring tools
-	sysCfg     *config.SystemConfig   // Technical parameters for the gateway engine
-	mu         sync.RWMutex           // Mutex protecting the concurrent access to the channels map
+	channels   map[string]api.Channel         // Registry of active channel instances indexed by ID
+	msgHandler api.MessageHandler             // Callback for business logic processing
+	monitor    monitor.Monitor                // Interface for broadcasting message logs to monitoring tools
+	sysCfg     *config.SystemConfig           // Technical parameters for the gateway engine
+	mu         sync.RWMutex                   // Mutex protecting the concurrent access to the channels map
+	roles      map[string]string              // Pending "role:*" signal per session, consumed by the next StreamReply
+	rolesMu    sync.Mutex                     // Mutex protecting the roles map
+	health     map[string]*channelHealthState // Health check and restart state per channel
+	stopHealth context.CancelFunc             // Stops the health check loop, if running
+	healthMu   sync.Mutex                     // Mutex protecting health and stopHealth
+	sinks      []api.EventSink                // Receivers of emitted events
+	sinksMu    sync.RWMutex                   // Mutex protecting sinks
+	runs       map[string]map[*run]bool       // Active runs per session, registered with BeginRun
+	replies    map[string]*replyState         // Affix state of the reply of each session's active runs
+	runsMu     sync.Mutex                     // Mutex protecting runs and replies
+	delivery   map[string]*deliveryLock       // Per-recipient locks ordering streamed replies
+	deliveryMu sync.Mutex                     // Mutex protecting delivery
 }
 
 // NewGatewayManager initializes a new GatewayManager instance.
 func NewGatewayManager() *GatewayManager {
 	return &GatewayManager{
 		channels: make(map[string]api.Channel),
+		roles:    make(map[string]string),
+		health:   make(map[string]*channelHealthState),
+		runs:     make(map[string]map[*run]bool),
+		replies:  make(map[string]*replyState),
+		delivery: make(map[string]*deliveryLock),
 	}
 }
 
@@ -55,6 +76,13 @@ func (g *GatewayManager) Register(c api.Channel) {
 	g.channels[c.ID()] = c
 }
 
+// unregister removes a channel from the manager.
+func (g *GatewayManager) unregister(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.channels, id)
+}
+
 // GetChannel retrieves a specifically registered api.Channel instance by its ID.
 // This is commonly used for high-level routing or proactive messaging.
 func (g *GatewayManager) GetChannel(id string) (api.Channel, bool) {
@@ -64,24 +92,88 @@ func (g *GatewayManager) GetChannel(id string) (api.Channel, bool) {
 	return c, ok
 }
 
-// StartAll iterates through all registered channels and invokes their
-// Start() method, passing the manager itself as the ChannelContext.
+// StartError is returned by StartAll when one or more channels failed to
+// start. It lists both outcomes so callers can decide whether a partial
+// startup is acceptable.
+type StartError struct {
+	Started []string         // IDs of the channels that started, in start order
+	Failed  map[string]error // Start error per failed channel ID
+}
+
+func (e *StartError) Error() string {
+	msgs := make([]string, 0, len(e.Failed))
+	for _, id := range slices.Sorted(maps.Keys(e.Failed)) {
+		msgs = append(msgs, fmt.Sprintf("failed to start channel %s: %v", id, e.Failed[id]))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap exposes the individual start errors to errors.Is/As.
+func (e *StartError) Unwrap() []error {
+	return slices.Collect(maps.Values(e.Failed))
+}
+
+// StartAll invokes Start() on every registered channel, passing the manager
+// itself as the ChannelContext. Every channel is attempted and failures are
+// returned as a *StartError; channels that failed are unregistered so the
+// rest of the gateway keeps serving. With SystemConfig.ChannelFailFast it
+// stops at the first failure and shuts down and unregisters the channels
+// already started.
 func (g *GatewayManager) StartAll() error {
+	// Channels may connect over the network in Start, so they are started
+	// without the lock; messages from channels already started are served
+	// meanwhile
 	g.mu.RLock()
-	defer g.mu.RUnlock()
+	channels := maps.Clone(g.channels)
+	g.mu.RUnlock()
 
-	for id, c := range g.channels {
+	failFast := g.sysCfg != nil && g.sysCfg.ChannelFailFast
+
+	result := &StartError{Failed: make(map[string]error)}
+	for _, id := range slices.Sorted(maps.Keys(channels)) {
+		c := channels[id]
 		slog.Info("Starting channel", "id", id)
 		if err := c.Start(g); err != nil {
-			return fmt.Errorf("failed to start channel %s: %w", id, err)
+			slog.Error("Channel failed to start", "id", id, "error", err)
+			result.Failed[id] = err
+			g.unregister(id)
+
+			if failFast {
+				// Unregistered as well, so StopAll does not stop them again
+				for _, startedID := range slices.Backward(result.Started) {
+					if err := channels[startedID].Stop(); err != nil {
+						slog.Error("Error stopping channel", "id", startedID, "error", err)
+					}
+					g.unregister(startedID)
+				}
+				result.Started = nil
+				return result
+			}
+			continue
 		}
+		slog.Info("Channel started", "id", id)
+		result.Started = append(result.Started, id)
+	}
+
+	if len(result.Failed) > 0 {
+		slog.Warn("Some channels failed to start", "started", result.Started, "failed", slices.Sorted(maps.Keys(result.Failed)))
+		return result
 	}
+	slog.Info("All channels started", "started", result.Started)
 	return nil
 }
 
-// StopAll gracefully shuts down all registered channels to release system
-// resources like network listeners or API long-polling workers.
+// StopAll gracefully shuts down all registered channels and the monitor to
+// release system resources like network listeners or API long-polling workers.
 func (g *GatewayManager) StopAll() {
+	// Stop health checks first so they cannot restart channels being stopped
+	g.healthMu.Lock()
+	if g.stopHealth != nil {
+		g.stopHealth()
+		g.stopHealth = nil
+	}
+	g.healthMu.Unlock()
+
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
@@ -91,21 +183,43 @@ func (g *GatewayManager) StopAll() {
 			slog.Error("Error stopping channel", "id", id, "error", err)
 		}
 	}
+
+	// Release monitor resources (e.g., dashboard listeners) before a reload
+	if g.monitor != nil {
+		if err := g.monitor.Stop(); err != nil {
+			slog.Error("Error stopping monitor", "error", err)
+		}
+	}
 }
 
 // SendReply is a convenience wrapper around StreamReply for sending simple
 // text messages. It packages the content into a single ContentBlock and
 // delegates to Stream, ensuring all replies follow one unified code path.
+// Direct replies are notices from the system rather than model output, so
+// they are reported to the monitor as SYSTEM (or ERROR for notices carrying the
+// error marker in either its emoji or ASCII form).
 func (g *GatewayManager) SendReply(session SessionContext, content string) error {
 	ch := make(chan llm.ContentBlock, 1)
 	ch <- llm.ContentBlock{Type: llm.BlockTypeText, Text: content}
 	close(ch)
-	return g.StreamReply(session, ch)
+
+	messageType := monitor.MessageTypeSystem
+	if strings.HasPrefix(content, utils.IconError.Emoji()) || strings.HasPrefix(content, utils.IconError.ASCII()) {
+		messageType = monitor.MessageTypeError
+	}
+	return g.streamReply(session, ch, messageType)
 }
 
 // SendSignal transmits a control signal (tipically for UI updates like
 // typing indicators) to the target channel if it supports SignalingChannel.
 func (g *GatewayManager) SendSignal(session SessionContext, signal string) error {
+	// Remember role switches so the following stream is attributed correctly in the monitor
+	if role, ok := strings.CutPrefix(signal, "role:"); ok {
+		g.rolesMu.Lock()
+		g.roles[sessionKey(session)] = role
+		g.rolesMu.Unlock()
+	}
+
 	c, ok := g.GetChannel(session.ChannelID)
 	if !ok {
 		return fmt.Errorf("channel %s not found", session.ChannelID)
@@ -121,9 +235,61 @@ func (g *GatewayManager) SendSignal(session SessionContext, signal string) error
 	return nil
 }
 
+// SendWithActions sends text with quick-reply actions if the target channel
+// supports api.InteractiveChannel. Other channels receive the text followed
+// by the choices, which the user can answer by typing them.
+func (g *GatewayManager) SendWithActions(session SessionContext, text string, actions []api.Action) error {
+	c, ok := g.GetChannel(session.ChannelID)
+	if !ok {
+		return fmt.Errorf("channel %s not found", session.ChannelID)
+	}
+
+	if ic, ok := c.(api.InteractiveChannel); ok {
+		err := ic.SendWithActions(session, text, actions)
+		if err == nil {
+			g.mirrorReply(session, text)
+			g.Emit(api.Event{Type: api.EventReplySent, Session: session, Role: "system", Content: text})
+			if g.monitor != nil {
+				g.monitor.OnMessage(monitor.MonitorMessage{
+					Timestamp:   time.Now(),
+					MessageType: monitor.MessageTypeSystem,
+					ChannelID:   session.ChannelID,
+					UserID:      session.UserID,
+					Username:    session.Username,
+					Content:     g.redact(text),
+				})
+			}
+			return nil
+		}
+		// The platform may reject the buttons (e.g., an oversized value); the text list still works
+		slog.Warn("Failed to send actions, falling back to text", "channel", session.ChannelID, "error", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(text)
+	for _, a := range actions {
+		sb.WriteString("\n• " + a.Value)
+		if a.Label != a.Value {
+			sb.WriteString(" (" + a.Label + ")")
+		}
+	}
+	return g.SendReply(session, sb.String())
+}
+
 // StreamReply handles multi-block streaming content. It wraps the provided
 // blocks channel to concurrently forward data while aggregating text for the monitor.
 func (g *GatewayManager) StreamReply(session SessionContext, blocks <-chan llm.ContentBlock) error {
+	// Streams announced with "role:system" carry tool results rather than assistant text
+	messageType := monitor.MessageTypeAssistant
+	if g.takeRole(session) == "system" {
+		messageType = monitor.MessageTypeTool
+	}
+	return g.streamReply(session, blocks, messageType)
+}
+
+// streamReply forwards blocks to the session's channel and reports the
+// aggregated text to the monitor under the given message type.
+func (g *GatewayManager) streamReply(session SessionContext, blocks <-chan llm.ContentBlock, messageType string) error {
 	c, ok := g.GetChannel(session.ChannelID)
 	if !ok {
 		return fmt.Errorf("channel %s not found", session.ChannelID)
@@ -136,52 +302,199 @@ func (g *GatewayManager) StreamReply(session SessionContext, blocks <-chan llm.C
 	}
 	wrappedBlocks := make(chan llm.ContentBlock, buffer)
 	var sb strings.Builder
+	var errSb strings.Builder
 
+	// Output-only branding/compliance wrapping; never reaches the history.
+	// Within a run it wraps the whole reply rather than every stream.
+	var prefix, suffix string
+	var reply *replyState
+	if g.sysCfg != nil && messageType == monitor.MessageTypeAssistant {
+		prefix, suffix = g.sysCfg.ResponseAffixFor(session.ChannelID)
+		if prefix != "" || suffix != "" {
+			reply = g.activeReply(session)
+		}
+	}
+
+	wrapperDone := make(chan struct{})
 	go func() {
+		defer close(wrapperDone)
 		defer close(wrappedBlocks)
+		hasText := false
 		for block := range blocks {
 			// Aggregate text blocks only for monitoring historical summary
 			if block.Type == llm.BlockTypeText {
+				if !hasText && prefix != "" && g.claimPrefix(reply) {
+					wrappedBlocks <- llm.NewTextBlock(prefix)
+				}
+				hasText = true
 				sb.WriteString(block.Text)
+			} else if block.Type == llm.BlockTypeTable {
+				// The plain-text rendering keeps the monitor columns aligned
+				sb.WriteString("\n" + block.Text)
+			} else if block.Type == llm.BlockTypeError {
+				errSb.WriteString(block.Text)
 			}
 			wrappedBlocks <- block
 		}
-		// Finalize the monitor entry once the stream is fully drained
+		if hasText && suffix != "" && !g.deferSuffix(reply) {
+			wrappedBlocks <- llm.NewTextBlock(suffix)
+		}
+		// Finalize the monitor entry once the stream is fully drained; monitors
+		// see each reply once, as a whole, after the channel has streamed it
 		if sb.Len() > 0 && g.monitor != nil {
 			g.monitor.OnMessage(monitor.MonitorMessage{
 				Timestamp:   time.Now(),
-				MessageType: "ASSISTANT",
+				MessageType: messageType,
+				ChannelID:   session.ChannelID,
+				UserID:      session.UserID,
+				Username:    session.Username,
+				Content:     g.redact(sb.String()),
+			})
+		}
+		if errSb.Len() > 0 && g.monitor != nil {
+			g.monitor.OnMessage(monitor.MonitorMessage{
+				Timestamp:   time.Now(),
+				MessageType: monitor.MessageTypeError,
 				ChannelID:   session.ChannelID,
+				UserID:      session.UserID,
 				Username:    session.Username,
-				Content:     sb.String(),
+				Content:     g.redact(strings.TrimSpace(errSb.String())),
 			})
 		}
+		if sb.Len() > 0 && messageType == monitor.MessageTypeAssistant {
+			g.mirrorReply(session, sb.String())
+		}
 	}()
 
-	return c.Stream(session, wrappedBlocks)
+	out := wrappedBlocks
+
+	// Optionally batch outgoing frames to spare clients that render every block
+	if g.sysCfg != nil && g.sysCfg.StreamFlushIntervalMs > 0 {
+		batched := make(chan llm.ContentBlock, buffer)
+		go func(in <-chan llm.ContentBlock) {
+			defer close(batched)
+			llm.CoalesceBlocks(in, batched, time.Duration(g.sysCfg.StreamFlushIntervalMs)*time.Millisecond, 0)
+		}(wrappedBlocks)
+		out = batched
+	}
+
+	unlock := g.lockDelivery(session)
+	err := c.Stream(session, out)
+	unlock()
+
+	// A channel may give up mid-stream (rate limit, disconnect). Keep draining
+	// so the producer never blocks on a channel nobody reads anymore.
+	go func() {
+		for range out {
+		}
+	}()
+
+	if err == nil {
+		// Report the reply before returning, so events follow delivery order
+		<-wrapperDone
+		g.emitReply(session, messageType, sb.String(), strings.TrimSpace(errSb.String()), nil)
+		return nil
+	}
+
+	// Report the full reply once the producer is done; an undelivered one is
+	// recorded so nothing vanishes silently
+	go func() {
+		<-wrapperDone
+		if sb.Len() > 0 {
+			writeDeadLetter(g.deadLetterDir(), session, sb.String(), err)
+		}
+		g.emitReply(session, messageType, sb.String(), strings.TrimSpace(errSb.String()), err)
+	}()
+
+	return fmt.Errorf("%w: %v", api.ErrChannelUnreachable, err)
+}
+
+// takeRole returns and clears the pending role signal for a session.
+func (g *GatewayManager) takeRole(session SessionContext) string {
+	g.rolesMu.Lock()
+	defer g.rolesMu.Unlock()
+	key := sessionKey(session)
+	role := g.roles[key]
+	delete(g.roles, key)
+	return role
+}
+
+// sessionKey identifies a conversation across channels.
+func sessionKey(session SessionContext) string {
+	return session.ChannelID + "_" + session.ChatID
+}
+
+// mirrorReply forwards a copy of a finished assistant reply to the configured
+// MirrorTarget. Replies already addressed to the mirror itself are skipped.
+func (g *GatewayManager) mirrorReply(session SessionContext, content string) {
+	if g.sysCfg == nil || g.sysCfg.MirrorTarget == nil {
+		return
+	}
+	target := g.sysCfg.MirrorTarget
+	if session.ChannelID == target.ChannelID && session.ChatID == target.ChatID {
+		return
+	}
+
+	c, ok := g.GetChannel(target.ChannelID)
+	if !ok {
+		slog.Warn("Mirror channel not found", "channel", target.ChannelID)
+		return
+	}
+
+	mirrorSession := SessionContext{
+		ChannelID: target.ChannelID,
+		UserID:    target.UserID,
+		ChatID:    target.ChatID,
+		Username:  "mirror",
+	}
+	header := fmt.Sprintf("[%s/%s] %s\n", session.ChannelID, session.ChatID, session.Username)
+	if err := c.Send(mirrorSession, header+content); err != nil {
+		slog.Error("Failed to mirror reply", "channel", target.ChannelID, "error", err)
+	}
 }
 
 // OnMessage implements the ChannelContext interface. It receives standardized
 // messages from channels, logs them, broadcasts to monitor, and forwards to handler.
 func (g *GatewayManager) OnMessage(channelID string, msg *UnifiedMessage) {
+	// Assign a trace ID that follows the request through handler, engine and LLM clients
+	if msg.DebugID == "" {
+		msg.DebugID = utils.GenerateID()
+	}
+	ctx := llm.WithDebugID(context.Background(), msg.DebugID)
+
 	// Structured logging for inbound user communications
-	slog.Debug("Message received", "channel", channelID, "user", msg.Session.Username, "user_id", msg.Session.UserID, "content", msg.Content)
+	slog.DebugContext(ctx, "Message received", "channel", channelID, "user", msg.Session.Username, "user_id", msg.Session.UserID, "content", g.redact(msg.Content))
 
 	// Broadcast the user message to the monitor for real-time observation
 	if g.monitor != nil {
 		g.monitor.OnMessage(monitor.MonitorMessage{
 			Timestamp:   time.Now(),
-			MessageType: "USER",
+			MessageType: monitor.MessageTypeUser,
 			ChannelID:   channelID,
+			UserID:      msg.Session.UserID,
 			Username:    msg.Session.Username,
-			Content:     msg.Content,
+			Content:     g.redact(msg.Content),
 		})
 	}
+	g.Emit(api.Event{Type: api.EventMessageReceived, Session: msg.Session, Content: msg.Content})
+
+	if g.interrupt(ctx, msg) {
+		return
+	}
 
 	if g.msgHandler != nil {
 		// Forward message to the business logic handler (e.g., ChatHandler)
 		g.msgHandler(msg)
 	} else {
-		slog.Warn("No message handler set")
+		slog.WarnContext(ctx, "No message handler set")
+	}
+}
+
+// redact masks PII in content destined for logs and monitors when
+// SystemConfig.RedactLogs is enabled. It never alters the message itself.
+func (g *GatewayManager) redact(content string) string {
+	if g.sysCfg == nil || !g.sysCfg.RedactLogs {
+		return content
 	}
+	return utils.RedactPII(content)
 }
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"genesis/pkg/api"
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+	"runtime"
+	"testing"
+)
+
+// benchTokens is the number of tiny text blocks streamed per operation.
+const benchTokens = 10000
+
+// discardChannel is a channel that accepts and drops every reply.
+type discardChannel struct{}
+
+func (discardChannel) ID() string                            { return "bench" }
+func (discardChannel) Start(api.ChannelContext) error        { return nil }
+func (discardChannel) Stop() error                           { return nil }
+func (discardChannel) Send(api.SessionContext, string) error { return nil }
+
+func (discardChannel) Stream(_ api.SessionContext, blocks <-chan llm.ContentBlock) error {
+	for range blocks {
+	}
+	return nil
+}
+
+// BenchmarkStreamReply measures the gateway forwarding path for a reply of
+// benchTokens tiny text blocks, reporting allocations per token.
+func BenchmarkStreamReply(b *testing.B) {
+	sysCfg := config.DefaultSystemConfig()
+	g := NewGatewayManager().WithSystemConfig(sysCfg)
+	g.Register(discardChannel{})
+	session := api.SessionContext{ChannelID: "bench", UserID: "u1", ChatID: "c1"}
+	block := llm.NewTextBlock("a")
+
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for range b.N {
+		blocks := make(chan llm.ContentBlock, sysCfg.InternalChannelBuffer)
+		go func() {
+			defer close(blocks)
+			for range benchTokens {
+				blocks <- block
+			}
+		}()
+		if err := g.StreamReply(session, blocks); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+
+	tokens := float64(b.N * benchTokens)
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/tokens, "allocs/token")
+	b.ReportMetric(tokens/b.Elapsed().Seconds(), "tokens/s")
+}
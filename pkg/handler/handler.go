@@ -54,19 +54,25 @@ func (h *ChatHandler) OnMessage(msg *api.UnifiedMessage) {
 			msg.DebugID = utils.GenerateID()
 		}
 
-		ctx := context.WithValue(context.Background(), llm.DebugDirContextKey, msg.DebugID)
+		ctx := llm.WithDebugID(context.Background(), msg.DebugID)
+		if rt, ok := h.responder.(api.RunTracker); ok {
+			var done func()
+			ctx, done = rt.BeginRun(ctx, msg.Session)
+			defer done()
+		}
 		start := time.Now()
 
 		fmt.Println()
-		slog.InfoContext(ctx, "Message received", "channel", msg.Session.ChannelID, "user", msg.Session.Username, "content", msg.Content, "files", len(msg.Files))
+		slog.InfoContext(ctx, "Message received", "channel", msg.Session.ChannelID, "user", msg.Session.Username, "chars", len([]rune(msg.Content)), "files", len(msg.Files))
 
-		sessionID := fmt.Sprintf("%s_%s", msg.Session.ChannelID, msg.Session.ChatID)
+		sessionID := h.sessions.ActiveSession(fmt.Sprintf("%s_%s", msg.Session.ChannelID, msg.Session.ChatID))
 		history, err := h.sessions.GetHistory(sessionID)
 		if err != nil {
 			slog.ErrorContext(ctx, "Failed to resolve session history", "session", sessionID, "error", err)
-			h.responder.SendReply(msg.Session, "❌ Error loading history.")
+			h.responder.SendReply(msg.Session, utils.IconError.String()+" Error loading history.")
 			return
 		}
+		history.AddParticipant(msg.Session.UserKey())
 
 		// Simply delegate the message, logic, slash commands and summarization to the AgentEngine
 		h.engine.HandleMessage(ctx, msg, history)
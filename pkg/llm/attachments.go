@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"genesis/pkg/utils"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// attachmentSweepInterval is how often StartAttachmentJanitor sweeps.
+const attachmentSweepInterval = time.Hour
+
+// expiredImageText replaces image blocks whose file no longer exists.
+const expiredImageText = "[image expired]"
+
+// StartAttachmentJanitor deletes attachments older than maxAge now and then
+// hourly, until ctx is done. A maxAge of 0 or less disables it.
+func (sm *SessionManager) StartAttachmentJanitor(ctx context.Context, maxAge time.Duration) {
+	if maxAge <= 0 || sm.attachments == "" {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(attachmentSweepInterval)
+		defer ticker.Stop()
+		for {
+			if n, err := sm.SweepAttachments(maxAge); err != nil {
+				slog.Warn("Attachment sweep failed", "error", err)
+			} else if n > 0 {
+				slog.Info("Expired attachments deleted", "count", n, "max_age", maxAge)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SweepAttachments deletes every file in the attachments directory older
+// than maxAge and returns how many were removed. Unlike RemoveAttachments
+// it does not consult histories: age is read from the timestamp prefix of
+// the file name (see utils.GenerateTimestampPrefix) or, for content-named
+// files (see utils.SaveAttachment), the modification time. Cached histories
+// then have their references to removed files replaced by a note; histories
+// on disk are fixed when they are loaded.
+func (sm *SessionManager) SweepAttachments(maxAge time.Duration) (int, error) {
+	if sm.attachments == "" {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(sm.attachments)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name := entry.Name()
+		var expired bool
+		if len(name) > 8 && name[8] == '_' {
+			if _, err := utils.GetTimeFromID(name); err == nil {
+				expired = utils.IsOlderThan(name, maxAge)
+			}
+		} else if info, err := entry.Info(); err == nil {
+			expired = time.Since(info.ModTime()) > maxAge
+		}
+		if !expired {
+			continue
+		}
+		if err := os.Remove(filepath.Join(sm.attachments, name)); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to delete expired attachment", "file", name, "error", err)
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		sm.mu.RLock()
+		histories := make([]*ChatHistory, 0, len(sm.histories))
+		for _, e := range sm.histories {
+			histories = append(histories, e.history)
+		}
+		sm.mu.RUnlock()
+		for _, h := range histories {
+			h.DropMissingImages()
+		}
+	}
+	return removed, nil
+}
+
+// RemoveAttachments deletes the given attachment files unless a session,
+// cached or on disk, still refers to them. Attachments are named by content
+// and shared between sessions, so an image dropped from one history may
+// still be part of another. A file received again while the sessions are
+// scanned is kept as well.
+func (sm *SessionManager) RemoveAttachments(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	start := time.Now()
+	referenced := make(map[string]bool)
+	err := sm.eachSession(func(_ string, h *ChatHistory) {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		for path := range imagePaths(h.Messages) {
+			referenced[path] = true
+		}
+	})
+	if err != nil {
+		slog.Warn("Attachments kept, sessions could not be scanned", "error", err)
+		return
+	}
+
+	for _, path := range paths {
+		if referenced[path] {
+			continue
+		}
+		// SaveAttachment refreshes the modification time of reused files
+		if info, err := os.Stat(path); err != nil || info.ModTime().After(start) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to delete discarded attachment", "path", path, "error", err)
+			continue
+		}
+		slog.Debug("Deleted discarded attachment", "path", path)
+	}
+}
+
+// DropMissingImages replaces image blocks referring to a file that no longer
+// exists, e.g. one deleted by SweepAttachments, with a text note, so the
+// conversation stays valid for providers. It returns the number of blocks
+// replaced.
+func (h *ChatHistory) DropMissingImages() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	replaced := 0
+	for i := range h.Messages {
+		for j := range h.Messages[i].Content {
+			block := &h.Messages[i].Content[j]
+			if block.Type != BlockTypeImage || block.Source == nil || block.Source.Type != "file" || block.Source.Path == "" {
+				continue
+			}
+			if _, err := os.Stat(block.Source.Path); os.IsNotExist(err) {
+				*block = ContentBlock{Type: BlockTypeText, Text: expiredImageText}
+				replaced++
+			}
+		}
+	}
+	return replaced
+}
@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ModelCapabilities describes what a model can handle, so the engine can
+// avoid requests the provider would reject (e.g., images sent to a
+// text-only model). Providers report defaults via CapabilityReporter and
+// the "capabilities" block of a provider group overrides them.
+type ModelCapabilities struct {
+	Model      string `json:"model,omitempty"`       // Model name, for display
+	Vision     bool   `json:"vision"`                // Accepts image input
+	Tools      bool   `json:"tools"`                 // Supports function calling
+	Reasoning  bool   `json:"reasoning"`             // Supports thinking/reasoning output
+	MaxContext int    `json:"max_context,omitempty"` // Context window in tokens; 0 if unknown
+	// AlternatingRoles reports that the provider rejects consecutive user or
+	// assistant messages, which history edits, undo or bursts of messages
+	// can produce. Such runs are merged before the request is sent.
+	AlternatingRoles bool `json:"alternating_roles"`
+}
+
+// CapabilityReporter is implemented by clients that know their model's
+// capabilities.
+type CapabilityReporter interface {
+	Capabilities() ModelCapabilities
+}
+
+// DefaultCapabilities is assumed for clients that do not report their own.
+// It is permissive, so undeclared models behave as before.
+func DefaultCapabilities() ModelCapabilities {
+	return ModelCapabilities{Vision: true, Tools: true, Reasoning: true}
+}
+
+// CapabilitiesOf resolves the capabilities of client. Decorators exposing
+// Unwrap are looked through until a CapabilityReporter is found.
+func CapabilitiesOf(client LLMClient) ModelCapabilities {
+	for client != nil {
+		if cr, ok := client.(CapabilityReporter); ok {
+			return cr.Capabilities()
+		}
+		u, ok := client.(interface{ Unwrap() LLMClient })
+		if !ok {
+			break
+		}
+		client = u.Unwrap()
+	}
+	return DefaultCapabilities()
+}
+
+// SupportsTools reports whether tools should be advertised to client.
+func SupportsTools(client LLMClient) bool {
+	return CapabilitiesOf(client).Tools
+}
+
+// capabilityClient decorates a client with its resolved capabilities and
+// keeps unsupported input away from the model: tool definitions are dropped
+// for models without function calling, images are replaced by a text
+// placeholder for models without vision and consecutive same-role messages
+// are merged for providers requiring alternation, so such a model can sit in
+// a fallback chain next to more capable ones.
+type capabilityClient struct {
+	LLMClient
+	caps ModelCapabilities
+}
+
+// NewCapabilityClient wraps client so that it reports caps and only
+// receives input those capabilities allow.
+func NewCapabilityClient(client LLMClient, caps ModelCapabilities) LLMClient {
+	return &capabilityClient{LLMClient: client, caps: caps}
+}
+
+// Capabilities returns the resolved capabilities.
+func (c *capabilityClient) Capabilities() ModelCapabilities {
+	return c.caps
+}
+
+// Unwrap returns the decorated client.
+func (c *capabilityClient) Unwrap() LLMClient {
+	return c.LLMClient
+}
+
+// StreamChat starts the stream, leaving out tools and images the model
+// cannot use and merging messages the provider would reject as out of turn.
+func (c *capabilityClient) StreamChat(ctx context.Context, messages []Message, availableTools []Tool) (<-chan StreamChunk, error) {
+	if !c.caps.Tools && len(availableTools) > 0 {
+		slog.DebugContext(ctx, "Model does not support tools, dropping tool definitions", "provider", c.Provider(), "model", c.caps.Model, "tools", len(availableTools))
+		availableTools = nil
+	}
+	if !c.caps.Vision {
+		var stripped int
+		messages, stripped = stripImages(messages)
+		if stripped > 0 {
+			slog.InfoContext(ctx, "Model does not support images, sending placeholders", "provider", c.Provider(), "model", c.caps.Model, "images", stripped)
+		}
+	}
+	if c.caps.AlternatingRoles {
+		var merged int
+		messages, merged = MergeConsecutiveRoles(messages)
+		if merged > 0 {
+			slog.DebugContext(ctx, "Merged consecutive same-role messages", "provider", c.Provider(), "model", c.caps.Model, "merged", merged)
+		}
+	}
+	return c.LLMClient.StreamChat(ctx, messages, availableTools)
+}
+
+// MergeConsecutiveRoles merges each run of consecutive user or assistant
+// messages into its first message, concatenating their content blocks and
+// tool calls in order, and returns how many messages were merged away.
+// System and tool messages are never merged. Merged messages are copied, so
+// the caller's history is never modified.
+func MergeConsecutiveRoles(messages []Message) ([]Message, int) {
+	var out []Message
+	merged := 0
+	for i, m := range messages {
+		if i == 0 || (m.Role != "user" && m.Role != "assistant") || m.Role != messages[i-1].Role {
+			if out != nil {
+				out = append(out, m)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]Message, 0, len(messages)), messages[:i]...)
+		}
+
+		prev := &out[len(out)-1]
+		prev.Content = slices.Concat(prev.Content, m.Content)
+		prev.ToolCalls = slices.Concat(prev.ToolCalls, m.ToolCalls)
+		prev.Pinned = prev.Pinned || m.Pinned
+		if m.Usage != nil {
+			prev.Usage = m.Usage
+		}
+		merged++
+	}
+	if out == nil {
+		return messages, 0
+	}
+	return out, merged
+}
+
+// stripImages replaces image blocks with a "[image omitted: name]" text
+// block. Messages without images are shared with the input; the others are
+// copied, so the caller's history is never modified.
+func stripImages(messages []Message) ([]Message, int) {
+	var out []Message
+	stripped := 0
+	for i, m := range messages {
+		if !slices.ContainsFunc(m.Content, func(b ContentBlock) bool { return b.Type == BlockTypeImage }) {
+			if out != nil {
+				out = append(out, m)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]Message, 0, len(messages)), messages[:i]...)
+		}
+
+		content := make([]ContentBlock, len(m.Content))
+		for j, b := range m.Content {
+			if b.Type == BlockTypeImage {
+				b = NewTextBlock(fmt.Sprintf("[image omitted: %s]", imageName(b.Source)))
+				stripped++
+			}
+			content[j] = b
+		}
+		m.Content = content
+		out = append(out, m)
+	}
+	if out == nil {
+		return messages, 0
+	}
+	return out, stripped
+}
+
+// imageName picks a human-readable name for an image placeholder.
+func imageName(src *ImageSource) string {
+	switch {
+	case src == nil:
+		return "image"
+	case src.Path != "":
+		return filepath.Base(src.Path)
+	case src.URL != "" && !strings.HasPrefix(src.URL, "data:"):
+		return path.Base(src.URL)
+	case src.MediaType != "":
+		return src.MediaType
+	default:
+		return "image"
+	}
+}
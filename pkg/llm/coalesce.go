@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"genesis/pkg/config"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default coalescing limits, used when no SystemConfig is available. They
+// match the StreamCoalesceChars and StreamCoalesceMs defaults: small enough
+// to preserve a streaming feel while cutting per-token allocations and
+// channel sends by an order of magnitude for fast providers.
+const (
+	DefaultCoalesceBytes = 80
+	DefaultCoalesceDelay = 50 * time.Millisecond
+)
+
+// ChunkCoalescer merges consecutive text or thinking deltas into a single
+// StreamChunk before sending it on the output channel. Buffered content is
+// flushed when it reaches maxBytes (if positive), once maxDelay has elapsed
+// since the first buffered delta even if the stream pauses, when the delta
+// type changes, or before any other chunk (tool calls, errors, final) is
+// sent so ordering is always preserved. A maxDelay of 0 forwards every delta
+// as-is.
+//
+// A ChunkCoalescer is meant to be owned by the single goroutine producing a
+// stream; it flushes from a timer on its own, so the producer must call
+// Flush or Send before closing the output channel.
+type ChunkCoalescer struct {
+	out      chan<- StreamChunk
+	maxBytes int
+	maxDelay time.Duration
+
+	mu        sync.Mutex // Guards the buffer and orders sends with timer flushes
+	blockType string
+	buf       strings.Builder
+	timer     *time.Timer // Flushes the buffer once maxDelay has elapsed
+}
+
+// NewChunkCoalescer creates a coalescer writing to out.
+func NewChunkCoalescer(out chan<- StreamChunk, maxBytes int, maxDelay time.Duration) *ChunkCoalescer {
+	return &ChunkCoalescer{
+		out:      out,
+		maxBytes: maxBytes,
+		maxDelay: maxDelay,
+	}
+}
+
+// NewStreamCoalescer creates a coalescer writing to out with the limits of
+// SystemConfig.StreamCoalesceChars and StreamCoalesceMs, or the defaults if
+// sys is nil. Provider clients use it for their streams.
+func NewStreamCoalescer(out chan<- StreamChunk, sys *config.SystemConfig) *ChunkCoalescer {
+	if sys == nil {
+		return NewChunkCoalescer(out, DefaultCoalesceBytes, DefaultCoalesceDelay)
+	}
+	return NewChunkCoalescer(out, sys.StreamCoalesceChars, time.Duration(sys.StreamCoalesceMs)*time.Millisecond)
+}
+
+// AddText buffers a text delta.
+func (c *ChunkCoalescer) AddText(delta string) {
+	c.add(BlockTypeText, delta)
+}
+
+// AddThinking buffers a thinking delta.
+func (c *ChunkCoalescer) AddThinking(delta string) {
+	c.add(BlockTypeThinking, delta)
+}
+
+func (c *ChunkCoalescer) add(blockType, delta string) {
+	if delta == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.blockType != blockType {
+		c.flushLocked()
+		c.blockType = blockType
+	}
+	if c.buf.Len() == 0 && c.maxDelay > 0 {
+		c.timer = time.AfterFunc(c.maxDelay, c.Flush)
+	}
+	c.buf.WriteString(delta)
+
+	if c.maxDelay <= 0 || (c.maxBytes > 0 && c.buf.Len() >= c.maxBytes) {
+		c.flushLocked()
+	}
+}
+
+// Send flushes any buffered delta and then forwards chunk unchanged.
+func (c *ChunkCoalescer) Send(chunk StreamChunk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+	c.out <- chunk
+}
+
+// Flush emits the buffered delta, if any, as a single chunk.
+func (c *ChunkCoalescer) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
+
+// flushLocked emits the buffered delta. Caller must hold mu.
+func (c *ChunkCoalescer) flushLocked() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if c.buf.Len() == 0 {
+		return
+	}
+	c.out <- StreamChunk{
+		ContentBlocks: []ContentBlock{{
+			Type: c.blockType,
+			Text: c.buf.String(),
+		}},
+	}
+	c.buf.Reset()
+}
+
+// CoalesceBlocks forwards blocks from in to out, merging consecutive text or
+// thinking deltas of the same type. The merged block is flushed every
+// interval, once it reaches maxChars (if positive), or as soon as a block of
+// another type arrives, so ordering is preserved. It returns after in is closed and any
+// pending delta has been flushed.
+func CoalesceBlocks(in <-chan ContentBlock, out chan<- ContentBlock, interval time.Duration, maxChars int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pending ContentBlock
+	var buf strings.Builder
+
+	flush := func() {
+		if buf.Len() == 0 {
+			return
+		}
+		pending.Text = buf.String()
+		out <- pending
+		buf.Reset()
+	}
+
+	for {
+		select {
+		case b, ok := <-in:
+			if !ok {
+				flush()
+				return
+			}
+
+			if b.Type != BlockTypeText && b.Type != BlockTypeThinking {
+				flush()
+				out <- b
+				continue
+			}
+
+			if buf.Len() > 0 && pending.Type != b.Type {
+				flush()
+			}
+			if buf.Len() == 0 {
+				pending = b
+			}
+			buf.WriteString(b.Text)
+
+			if maxChars > 0 && buf.Len() >= maxChars {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
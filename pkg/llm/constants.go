@@ -14,4 +14,5 @@ const (
 	BlockTypeThinking = "thinking" // Internal reasoning/chain-of-thought
 	BlockTypeImage    = "image"    // Binary image data
 	BlockTypeError    = "error"    // Error message displayed to user
+	BlockTypeTable    = "table"    // Structured rows; Text holds a plain-text fallback
 )
@@ -28,12 +28,12 @@ func NewStreamDebugger(ctx context.Context, provider string, cfg *config.SystemC
 	}
 
 	// Base debug dir
-	debugDir := filepath.Join("debug", "chunks", provider)
+	debugDir := cfg.DataPath("debug", "chunks", provider)
 
 	// If session ID is in context, nest under it
 	if val := ctx.Value(DebugDirContextKey); val != nil {
 		if dirStr, ok := val.(string); ok && dirStr != "" {
-			debugDir = filepath.Join("debug", "chunks", dirStr, provider)
+			debugDir = cfg.DataPath("debug", "chunks", dirStr, provider)
 		}
 	}
 
@@ -47,6 +47,13 @@ func (g *GeminiClient) Provider() string {
 	return "gemini"
 }
 
+// Capabilities reports the provider defaults: Gemini models are multimodal
+// and support function calling and thinking, and expect user and model turns
+// to alternate.
+func (g *GeminiClient) Capabilities() llm.ModelCapabilities {
+	return llm.ModelCapabilities{Model: g.model, Vision: true, Tools: true, Reasoning: true, AlternatingRoles: true}
+}
+
 // formatModality formats ModalityTokenCount array for logging
 func formatModality(details []*genai.ModalityTokenCount) string {
 	if len(details) == 0 {
@@ -73,18 +80,9 @@ func (g *GeminiClient) StreamChat(ctx context.Context, messages []llm.Message, a
 				Name:        t.Name(),
 				Description: t.Description(),
 			}
-			params := t.Parameters()
-			if params != nil {
+			if t.Parameters() != nil {
 				// Gemini (via genai SDK) also expects a full JSON Schema object
-				fullSchema := map[string]any{
-					"type":       "object",
-					"properties": params,
-				}
-				if required := t.RequiredParameters(); len(required) > 0 {
-					fullSchema["required"] = required
-				}
-
-				schemaB, _ := json.Marshal(fullSchema)
+				schemaB, _ := json.Marshal(llm.ToolSchema(t))
 				var schema genai.Schema
 				json.Unmarshal(schemaB, &schema)
 				fd.Parameters = &schema
@@ -143,11 +141,16 @@ func (g *GeminiClient) StreamChat(ctx context.Context, messages []llm.Message, a
 
 		started := false
 		var lastUsage *llm.LLMUsage
+		var calls toolCallAccumulator
 
 		// StreamDebugger handles file creation and lifecycle
 		debugger := llm.NewStreamDebugger(ctx, g.Provider(), g.sysConfig)
 		defer debugger.Close()
 
+		// Merge per-token deltas into fewer chunks to reduce allocations and channel sends
+		out := llm.NewStreamCoalescer(chunkCh, g.sysConfig)
+		defer out.Flush()
+
 		for resp, err := range iter {
 			// Save raw packet
 			if resp != nil {
@@ -164,7 +167,7 @@ func (g *GeminiClient) StreamChat(ctx context.Context, messages []llm.Message, a
 						startResultCh <- err
 					} else {
 						// Stream interrupted, notify user
-						chunkCh <- llm.NewErrorChunk(fmt.Sprintf("Stream interrupted: %v", err), err, true)
+						out.Send(llm.NewErrorChunk(fmt.Sprintf("Stream interrupted: %v", err), err, true))
 					}
 					break
 				}
@@ -198,7 +201,6 @@ func (g *GeminiClient) StreamChat(ctx context.Context, messages []llm.Message, a
 
 				if candidate.Content != nil {
 					var blocks []llm.ContentBlock
-					var toolCalls []llm.ToolCall
 
 					for _, part := range candidate.Content.Parts {
 						if part.Text != "" {
@@ -218,49 +220,37 @@ func (g *GeminiClient) StreamChat(ctx context.Context, messages []llm.Message, a
 						}
 
 						if part.FunctionCall != nil {
-							// Tool call
-							argsB, _ := json.Marshal(part.FunctionCall.Args)
-
-							// Capture thought_signature into ProviderMetadata for persistence
-							// In Google GenAI SDK, ThoughtSignature is a field of Part, not FunctionCall
-							var providerMetadata map[string]any
-							if len(part.ThoughtSignature) > 0 {
-								providerMetadata = map[string]any{
-									"thought_signature": part.ThoughtSignature,
-								}
-							}
-
-							toolCalls = append(toolCalls, llm.ToolCall{
-								ID:   "", // Gemini stream IDs are sometimes missing here
-								Name: part.FunctionCall.Name,
-								Function: llm.FunctionCall{
-									Name:      part.FunctionCall.Name,
-									Arguments: string(argsB),
-								},
-								ProviderMetadata: providerMetadata,
-								// Save original FunctionCall for reconstruction (includes ID, etc.)
-								Meta: map[string]any{
-									"gemini_function_call":     part.FunctionCall,
-									"gemini_thought_signature": part.ThoughtSignature,
-								},
-							})
-							slog.DebugContext(ctx, "Tool call", "provider", g.Provider(), "name", part.FunctionCall.Name, "args", string(argsB))
+							// Tool call; arguments may be split across parts, so
+							// calls are accumulated and emitted when the stream ends
+							calls.add(part.FunctionCall, part.ThoughtSignature)
+							slog.DebugContext(ctx, "Tool call part", "provider", g.Provider(), "name", part.FunctionCall.Name, "partial_args", len(part.FunctionCall.PartialArgs))
 						}
 					}
 
-					if len(blocks) > 0 || len(toolCalls) > 0 {
-						chunkCh <- llm.StreamChunk{
-							ContentBlocks: blocks,
-							ToolCalls:     toolCalls,
+					for _, block := range blocks {
+						if block.Type == llm.BlockTypeThinking {
+							out.AddThinking(block.Text)
+						} else {
+							out.AddText(block.Text)
 						}
 					}
 				}
 			}
 		}
 
+		if calls.open != nil {
+			slog.WarnContext(ctx, "Dropping incomplete tool call", "provider", g.Provider(), "name", calls.open.fc.Name)
+		}
+		if toolCalls := calls.toolCalls(); len(toolCalls) > 0 {
+			for _, tc := range toolCalls {
+				slog.DebugContext(ctx, "Tool call", "provider", g.Provider(), "name", tc.Name, "args", tc.Function.Arguments)
+			}
+			out.Send(llm.StreamChunk{ToolCalls: toolCalls})
+		}
+
 		// Send final chunk (with usage stats)
 		if lastUsage != nil {
-			chunkCh <- llm.NewFinalChunk(lastUsage.StopReason, lastUsage)
+			out.Send(llm.NewFinalChunk(lastUsage.StopReason, lastUsage))
 			llm.LogUsage(g.model, lastUsage)
 		}
 	}()
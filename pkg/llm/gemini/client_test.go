@@ -0,0 +1,71 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+	"genesis/pkg/tools"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// requestFunction returns the function declaration with the given name
+// from a captured generateContent request.
+func requestFunction(t *testing.T, body map[string]any, name string) map[string]any {
+	t.Helper()
+	list, _ := body["tools"].([]any)
+	for _, item := range list {
+		tool, _ := item.(map[string]any)
+		decls, _ := tool["functionDeclarations"].([]any)
+		for _, d := range decls {
+			if fd, ok := d.(map[string]any); ok && fd["name"] == name {
+				return fd
+			}
+		}
+	}
+	t.Fatalf("function %q not in request, got tools %v", name, body["tools"])
+	return nil
+}
+
+// captureRequest streams a chat with the OS tool against a server that
+// records the request and rejects it, and returns the recorded body.
+func captureRequest(t *testing.T) map[string]any {
+	t.Helper()
+	bodies := make(chan map[string]any, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		bodies <- body
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":400,"message":"rejected by test server","status":"INVALID_ARGUMENT"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", srv.URL)
+
+	c := NewGeminiClient("test-key", "test-model", false, nil, config.DefaultSystemConfig())
+	messages := []llm.Message{{Role: "user", Content: []llm.ContentBlock{llm.NewTextBlock("hi")}}}
+	if ch, err := c.StreamChat(context.Background(), messages, []llm.Tool{tools.NewOSTool(nil)}); err == nil {
+		for range ch {
+		}
+	}
+	return <-bodies
+}
+
+func TestStreamChatSendsTools(t *testing.T) {
+	fd := requestFunction(t, captureRequest(t), "os_control")
+	params, _ := fd["parameters"].(map[string]any)
+	props, _ := params["properties"].(map[string]any)
+	if _, ok := props["action"]; !ok {
+		t.Errorf("parameters lack the action property: %v", params)
+	}
+	if required, _ := params["required"].([]any); len(required) != 1 || required[0] != "action" {
+		t.Errorf("required = %v, want [action]", params["required"])
+	}
+}
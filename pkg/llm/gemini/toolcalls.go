@@ -0,0 +1,146 @@
+package gemini
+
+import (
+	"encoding/json"
+	"genesis/pkg/llm"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// toolCallAccumulator merges function-call parts across stream chunks.
+// Gemini normally sends each call complete in one part, but with argument
+// streaming a call arrives as several parts (WillContinue set) carrying
+// PartialArgs fragments. Parts are matched by call ID, or, when the ID is
+// missing, to the call that announced it would continue. Calls are emitted
+// in arrival order once the stream ends.
+type toolCallAccumulator struct {
+	calls []*pendingCall
+	open  *pendingCall // Call whose last part had WillContinue set
+}
+
+type pendingCall struct {
+	fc        genai.FunctionCall
+	signature []byte
+}
+
+// add merges a function-call part into the accumulated calls.
+func (a *toolCallAccumulator) add(fc *genai.FunctionCall, signature []byte) {
+	pc := a.match(fc)
+	if pc == nil {
+		pc = &pendingCall{fc: genai.FunctionCall{ID: fc.ID, Name: fc.Name}}
+		a.calls = append(a.calls, pc)
+	}
+
+	if pc.fc.Name == "" {
+		pc.fc.Name = fc.Name
+	}
+	if len(pc.signature) == 0 {
+		pc.signature = signature
+	}
+	for k, v := range fc.Args {
+		if pc.fc.Args == nil {
+			pc.fc.Args = make(map[string]any)
+		}
+		pc.fc.Args[k] = v
+	}
+	for _, pa := range fc.PartialArgs {
+		if pa != nil {
+			pc.applyPartial(pa)
+		}
+	}
+
+	a.open = nil
+	if fc.WillContinue != nil && *fc.WillContinue {
+		a.open = pc
+	}
+}
+
+// match finds the call a part continues, or nil if it starts a new call.
+func (a *toolCallAccumulator) match(fc *genai.FunctionCall) *pendingCall {
+	if fc.ID != "" {
+		for _, pc := range a.calls {
+			if pc.fc.ID == fc.ID {
+				return pc
+			}
+		}
+	}
+	if a.open != nil && (fc.Name == "" || fc.Name == a.open.fc.Name) && (fc.ID == "" || a.open.fc.ID == "") {
+		return a.open
+	}
+	return nil
+}
+
+// applyPartial sets the argument addressed by a PartialArg JSON path.
+// String fragments for the same path are concatenated. Only object member
+// paths ("$.a.b") are supported; array indices are kept as literal keys.
+func (pc *pendingCall) applyPartial(pa *genai.PartialArg) {
+	path := strings.Split(strings.TrimPrefix(strings.TrimPrefix(pa.JsonPath, "$"), "."), ".")
+	if len(path) == 0 || path[0] == "" {
+		return
+	}
+
+	if pc.fc.Args == nil {
+		pc.fc.Args = make(map[string]any)
+	}
+	obj := pc.fc.Args
+	for _, key := range path[:len(path)-1] {
+		next, ok := obj[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			obj[key] = next
+		}
+		obj = next
+	}
+
+	key := path[len(path)-1]
+	switch {
+	case pa.NumberValue != nil:
+		obj[key] = *pa.NumberValue
+	case pa.BoolValue != nil:
+		obj[key] = *pa.BoolValue
+	case pa.NULLValue != "":
+		obj[key] = nil
+	default:
+		prev, _ := obj[key].(string)
+		obj[key] = prev + pa.StringValue
+	}
+}
+
+// toolCalls converts the accumulated calls to llm.ToolCalls. A call still
+// waiting for more parts (the stream ended early) is incomplete and dropped.
+func (a *toolCallAccumulator) toolCalls() []llm.ToolCall {
+	calls := make([]llm.ToolCall, 0, len(a.calls))
+	for _, pc := range a.calls {
+		if pc == a.open {
+			continue
+		}
+		fc := pc.fc
+		argsB, _ := json.Marshal(fc.Args)
+
+		// Capture thought_signature into ProviderMetadata for persistence
+		// In Google GenAI SDK, ThoughtSignature is a field of Part, not FunctionCall
+		var providerMetadata map[string]any
+		if len(pc.signature) > 0 {
+			providerMetadata = map[string]any{
+				"thought_signature": pc.signature,
+			}
+		}
+
+		calls = append(calls, llm.ToolCall{
+			ID:   fc.ID,
+			Name: fc.Name,
+			Function: llm.FunctionCall{
+				Name:      fc.Name,
+				Arguments: string(argsB),
+			},
+			ProviderMetadata: providerMetadata,
+			// Save the merged FunctionCall for reconstruction (includes ID, etc.)
+			Meta: map[string]any{
+				"gemini_function_call":     &fc,
+				"gemini_thought_signature": pc.signature,
+			},
+		})
+	}
+	return calls
+}
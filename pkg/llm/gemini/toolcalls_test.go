@@ -0,0 +1,67 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+// toolCallFixture is a recorded sequence of function-call parts and the
+// tool calls they must accumulate to.
+type toolCallFixture struct {
+	Parts []*genai.Part `json:"parts"`
+	Want  []struct {
+		ID        string         `json:"id"`
+		Name      string         `json:"name"`
+		Args      map[string]any `json:"args"`
+		Signature []byte         `json:"signature"`
+	} `json:"want"`
+}
+
+func TestToolCallAccumulatorFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "toolcalls", "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no fixtures found: %v", err)
+	}
+
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fx toolCallFixture
+			if err := json.Unmarshal(data, &fx); err != nil {
+				t.Fatal(err)
+			}
+
+			var acc toolCallAccumulator
+			for _, part := range fx.Parts {
+				acc.add(part.FunctionCall, part.ThoughtSignature)
+			}
+			got := acc.toolCalls()
+
+			if len(got) != len(fx.Want) {
+				t.Fatalf("got %d calls, want %d: %+v", len(got), len(fx.Want), got)
+			}
+			for i, want := range fx.Want {
+				tc := got[i]
+				if tc.ID != want.ID || tc.Name != want.Name || tc.Function.Name != want.Name {
+					t.Errorf("call %d = %s/%s, want %s/%s", i, tc.ID, tc.Name, want.ID, want.Name)
+				}
+				wantArgs, _ := json.Marshal(want.Args)
+				if tc.Function.Arguments != string(wantArgs) {
+					t.Errorf("call %d arguments = %s, want %s", i, tc.Function.Arguments, wantArgs)
+				}
+				if sig, _ := tc.Meta["gemini_thought_signature"].([]byte); !bytes.Equal(sig, want.Signature) {
+					t.Errorf("call %d signature = %q, want %q", i, sig, want.Signature)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package llm
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"genesis/pkg/utils"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,9 +19,19 @@ import (
 // It acts as the "short-term memory" for a single conversation session,
 // accumulating messages from all roles (user, system, assistant, tool).
 type ChatHistory struct {
-	Summary  string       `json:"summary,omitempty"` // Condensed summary of earlier conversation
-	Messages []Message    `json:"messages"`          // Chronological message history
-	mu       sync.RWMutex // Protects concurrent access
+	SessionID    string       `json:"session_id,omitempty"`   // Session the history belongs to, set by SessionManager
+	Participants []string     `json:"participants,omitempty"` // Users who wrote in this session, as "channel:user"
+	Summary      string       `json:"summary,omitempty"`      // Condensed summary of earlier conversation
+	Facts        []Fact       `json:"facts,omitempty"`        // Durable user facts extracted alongside the summary
+	Messages     []Message    `json:"messages"`               // Chronological message history
+	mu           sync.RWMutex // Protects concurrent access
+}
+
+// Fact is a single named piece of information about the user (e.g., name,
+// preferred language) that must be remembered verbatim across summaries.
+type Fact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 // NewChatHistory initializes a fresh ChatHistory manager with an empty message set.
@@ -74,6 +88,38 @@ func (h *ChatHistory) GetMessagesForUI() []Message {
 	return msgs
 }
 
+// GetSessionID returns the ID of the session the history belongs to, or ""
+// for a history not managed by a SessionManager.
+func (h *ChatHistory) GetSessionID() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.SessionID
+}
+
+// setSessionID records the session the history belongs to.
+func (h *ChatHistory) setSessionID(sessionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.SessionID = sessionID
+}
+
+// AddParticipant records that a user wrote in this session. Keys are
+// compared exactly and stored once.
+func (h *ChatHistory) AddParticipant(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if key != "" && !slices.Contains(h.Participants, key) {
+		h.Participants = append(h.Participants, key)
+	}
+}
+
+// HasParticipant reports whether the user wrote in this session.
+func (h *ChatHistory) HasParticipant(key string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return slices.Contains(h.Participants, key)
+}
+
 // GetSummary returns the current conversation summary.
 func (h *ChatHistory) GetSummary() string {
 	h.mu.RLock()
@@ -88,15 +134,56 @@ func (h *ChatHistory) SetSummary(summary string) {
 	h.Summary = summary
 }
 
+// GetFacts returns a copy of the extracted user facts.
+func (h *ChatHistory) GetFacts() []Fact {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	cp := make([]Fact, len(h.Facts))
+	copy(cp, h.Facts)
+	return cp
+}
+
+// MergeFacts upserts facts by case-insensitive name, so a newer value
+// replaces an older one. A fact with an empty value deletes the entry.
+func (h *ChatHistory) MergeFacts(facts []Fact) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, f := range facts {
+		name := strings.TrimSpace(f.Name)
+		value := strings.TrimSpace(f.Value)
+		if name == "" {
+			continue
+		}
+
+		idx := slices.IndexFunc(h.Facts, func(existing Fact) bool {
+			return strings.EqualFold(existing.Name, name)
+		})
+		switch {
+		case idx >= 0 && value == "":
+			h.Facts = slices.Delete(h.Facts, idx, idx+1)
+		case idx >= 0:
+			h.Facts[idx].Value = value
+		case value != "":
+			h.Facts = append(h.Facts, Fact{Name: name, Value: value})
+		}
+	}
+}
+
 // TruncateHistory keeps only the most recent N messages.
-// If the first message is a system message, it is always preserved.
-// It also deletes any local files associated with discarded image blocks.
-func (h *ChatHistory) TruncateHistory(keep int) {
+// If the first message is a system message, it is always preserved, and so
+// are pinned messages, which stay in their original order before the recent
+// window. Pinned messages are kept in addition to the N recent ones; callers
+// bound their number with LimitPinned. It returns the files of discarded image blocks that no kept
+// message refers to. Attachments are shared between sessions, so they are
+// left for SessionManager.RemoveAttachments to delete.
+func (h *ChatHistory) TruncateHistory(keep int) []string {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	if len(h.Messages) <= keep {
-		return
+		return nil
 	}
 
 	// Preserve system message at index 0 if present
@@ -106,37 +193,157 @@ func (h *ChatHistory) TruncateHistory(keep int) {
 		sysMsg = &tmp
 	}
 
-	// Capture discarded messages for GC
-	discardedMsgs := h.Messages[:len(h.Messages)-keep]
+	// Capture discarded messages for GC, sparing pinned ones
+	var discardedMsgs, pinnedMsgs []Message
+	for _, msg := range h.Messages[:len(h.Messages)-keep] {
+		if msg.Pinned {
+			pinnedMsgs = append(pinnedMsgs, msg)
+		} else {
+			discardedMsgs = append(discardedMsgs, msg)
+		}
+	}
 
 	// Truncate
-	h.Messages = h.Messages[len(h.Messages)-keep:]
+	h.Messages = append(pinnedMsgs, h.Messages[len(h.Messages)-keep:]...)
 
 	// Re-prepend system message if it was removed by truncation
 	if sysMsg != nil && (len(h.Messages) == 0 || h.Messages[0].Role != "system") {
 		h.Messages = append([]Message{*sysMsg}, h.Messages...)
 	}
 
-	// Execute Garbage Collection on discarded attachments
-	for _, msg := range discardedMsgs {
-		// Skip the system message we just preserved
-		if sysMsg != nil && msg.ID == sysMsg.ID {
-			continue
+	// Files are named by content, so a discarded image may also be part of a
+	// kept message; those files stay
+	kept := imagePaths(h.Messages)
+	var orphaned []string
+	for path := range imagePaths(discardedMsgs) {
+		if !kept[path] {
+			orphaned = append(orphaned, path)
 		}
+	}
+	slices.Sort(orphaned)
+	return orphaned
+}
+
+// imagePaths returns the files referenced by image blocks of msgs.
+func imagePaths(msgs []Message) map[string]bool {
+	paths := make(map[string]bool)
+	for _, msg := range msgs {
 		for _, block := range msg.Content {
 			if block.Type == BlockTypeImage && block.Source != nil && block.Source.Type == "file" && block.Source.Path != "" {
-				err := os.Remove(block.Source.Path)
-				if err != nil && !os.IsNotExist(err) {
-					// We don't have slog imported in this file yet perhaps, let's just use fmt.Fprintf or import it.
-					// Actually, os.Remove error is fine to ignore or just print if we can't import slog.
-					// Let's assume we can use it or we will fix imports next.
-					fmt.Printf("[GC] Failed to delete expired attachment %s: %v\n", block.Source.Path, err)
-				} else if err == nil {
-					fmt.Printf("[GC] Deleted expired attachment %s\n", block.Source.Path)
-				}
+				paths[block.Source.Path] = true
+			}
+		}
+	}
+	return paths
+}
+
+// ElideThinking shortens the thinking blocks of the message with the given ID
+// and all later ones to at most maxChars runes, marking the cut. A maxChars
+// of 0 drops thinking, except from messages that would be left without
+// content, which keep an empty marker instead.
+func (h *ChatHistory) ElideThinking(fromID string, maxChars int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	from := slices.IndexFunc(h.Messages, func(m Message) bool { return m.ID == fromID })
+	if from < 0 {
+		return
+	}
+	for i := range h.Messages[from:] {
+		msg := &h.Messages[from+i]
+		if !slices.ContainsFunc(msg.Content, func(b ContentBlock) bool { return b.Type == BlockTypeThinking }) {
+			continue
+		}
+
+		hasOther := slices.ContainsFunc(msg.Content, func(b ContentBlock) bool { return b.Type != BlockTypeThinking })
+		if maxChars <= 0 && (hasOther || len(msg.ToolCalls) > 0) {
+			msg.Content = slices.DeleteFunc(msg.Content, func(b ContentBlock) bool { return b.Type == BlockTypeThinking })
+			continue
+		}
+
+		msg.Content = truncateThinking(msg.Content, max(maxChars, 0))
+	}
+}
+
+// truncateThinking limits the thinking of a message to maxChars runes in
+// total, spent in order, and marks the cut. Each run of streamed thinking
+// deltas becomes one block but stays in place, so models that think, answer
+// and think again keep their reasoning where it happened. Thinking after the
+// cut is dropped.
+func truncateThinking(blocks []ContentBlock, maxChars int) []ContentBlock {
+	var content []ContentBlock
+	var run strings.Builder
+	budget, cut := maxChars, false
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		thinking := []rune(run.String())
+		run.Reset()
+		if len(thinking) > budget {
+			thinking = append(thinking[:budget], []rune(" …[reasoning truncated]")...)
+			cut = true
+		} else {
+			budget -= len(thinking)
+		}
+		content = append(content, NewThinkingBlock(strings.TrimSpace(string(thinking))))
+	}
+
+	for _, b := range blocks {
+		if b.Type == BlockTypeThinking {
+			if !cut {
+				run.WriteString(b.Text)
 			}
+			continue
+		}
+		flush()
+		content = append(content, b)
+	}
+	flush()
+	return content
+}
+
+// PinLast pins the most recent message with the given role and returns it.
+// It reports false if no such message exists.
+func (h *ChatHistory) PinLast(role string) (Message, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := len(h.Messages) - 1; i >= 0; i-- {
+		if h.Messages[i].Role == role {
+			h.Messages[i].Pinned = true
+			return h.Messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// LimitPinned unpins the oldest pinned messages until at most max remain
+// and returns how many were unpinned. A max of 0 or less disables the limit.
+func (h *ChatHistory) LimitPinned(max int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if max <= 0 {
+		return 0
+	}
+	excess := -max
+	for _, msg := range h.Messages {
+		if msg.Pinned {
+			excess++
+		}
+	}
+	unpinned := 0
+	for i := range h.Messages {
+		if unpinned >= excess {
+			break
+		}
+		if h.Messages[i].Pinned {
+			h.Messages[i].Pinned = false
+			unpinned++
 		}
 	}
+	return unpinned
 }
 
 // EnsureSystemMessage makes sure a system message with the given content is at the
@@ -180,21 +387,10 @@ func (h *ChatHistory) ProcessImages(attachmentsDir string) error {
 		for j := range h.Messages[i].Content {
 			block := &h.Messages[i].Content[j]
 			if block.Type == BlockTypeImage && block.Source != nil && len(block.Source.Data) > 0 {
-				// Calculate hash for stable filename
-				hash := sha256.Sum256(block.Source.Data)
-				// Prefix with 8-char hex timestamp for easy expiration checks
-				filename := fmt.Sprintf("%s%s", utils.GenerateTimestampPrefix(), hex.EncodeToString(hash[:]))
-
-				// Detect actual extension from bytes
-				_, ext := utils.DetectMimeAndExt(block.Source.Data)
-
-				fullPath := filepath.Join(attachmentsDir, filename+ext)
-
-				// Save if not already exists
-				if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-					if err := os.WriteFile(fullPath, block.Source.Data, 0644); err != nil {
-						return fmt.Errorf("failed to save image %s: %w", fullPath, err)
-					}
+				// Named by content hash, so identical images share one file
+				fullPath, _, err := utils.SaveAttachment(attachmentsDir, block.Source.Data)
+				if err != nil {
+					return err
 				}
 
 				// Update source to reference file
@@ -207,24 +403,86 @@ func (h *ChatHistory) ProcessImages(attachmentsDir string) error {
 	return nil
 }
 
+// InlineImages is the counterpart of ProcessImages: it reads images stored
+// as files into the history, which then serializes them as base64 and no
+// longer depends on the attachments directory. Files that cannot be read
+// stay referenced by path.
+func (h *ChatHistory) InlineImages() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := range h.Messages {
+		for j := range h.Messages[i].Content {
+			block := &h.Messages[i].Content[j]
+			if block.Type != BlockTypeImage || block.Source == nil || block.Source.Type != "file" {
+				continue
+			}
+			if err := block.Source.LoadData(); err != nil {
+				slog.Warn("Image kept as a file reference", "error", err)
+				continue
+			}
+			block.Source.Path = ""
+		}
+	}
+}
+
 // Save serializes the entire conversation history to a JSON file.
 // It uses a read lock to ensure the data is consistent during serialization.
+// The file is replaced atomically, so a crash mid-write never leaves a
+// truncated history behind. Paths ending in ".gz" are gzip-compressed.
 func (h *ChatHistory) Save(filePath string) error {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	// ChatHistory can be marshaled directly — the unexported `mu` field
-	// is automatically excluded by the JSON encoder.
-	data, err := json.MarshalIndent(h, "", "  ")
+	data, err := json.MarshalIndent(historyFile{
+		SchemaVersion: HistorySchemaVersion,
+		SessionID:     h.SessionID,
+		Participants:  h.Participants,
+		Summary:       h.Summary,
+		Facts:         h.Facts,
+		Messages:      h.Messages,
+	}, "", "  ")
+	h.mu.RUnlock()
 	if err != nil {
 		return err
 	}
+	if strings.HasSuffix(filePath, ".gz") {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
 
-	return os.WriteFile(filePath, data, 0644)
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
 }
 
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
 // Load deserializes conversation history from a JSON file.
-// If the file does not exist, it does nothing and returns nil.
+// If the file does not exist, it does nothing and returns nil. Gzip
+// files are recognized by their magic bytes, whatever their extension.
 // This operation uses a write lock to replace the existing in-memory history.
 func (h *ChatHistory) Load(filePath string) error {
 	h.mu.Lock()
@@ -237,19 +495,33 @@ func (h *ChatHistory) Load(filePath string) error {
 		}
 		return err
 	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", filePath, err)
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
 
-	var result struct {
-		Summary  string    `json:"summary"`
-		Messages []Message `json:"messages"`
+	// Files written by older versions are upgraded in memory; the next Save
+	// persists the current format
+	data, err = migrateHistory(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filePath, err)
 	}
+
+	var result historyFile
 	if err := json.Unmarshal(data, &result); err != nil {
-		// Fallback for older format (straight array of messages)
-		if err := json.Unmarshal(data, &result.Messages); err != nil {
-			return err
-		}
+		return err
 	}
 
+	h.SessionID = result.SessionID
+	h.Participants = result.Participants
 	h.Summary = result.Summary
+	h.Facts = result.Facts
 	h.Messages = result.Messages
 	return nil
 }
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// HistorySchemaVersion is the version of the history file format written
+// by ChatHistory.Save. Bump it together with a new entry in
+// historyMigrations whenever the stored layout changes incompatibly.
+//
+//	0: bare JSON array of messages
+//	1: object with summary, facts and messages
+//	2: version 1 plus schema_version
+const HistorySchemaVersion = 2
+
+// historyFile is the on-disk layout of a ChatHistory.
+type historyFile struct {
+	SchemaVersion int       `json:"schema_version"`
+	SessionID     string    `json:"session_id,omitempty"`
+	Participants  []string  `json:"participants,omitempty"`
+	Summary       string    `json:"summary,omitempty"`
+	Facts         []Fact    `json:"facts,omitempty"`
+	Messages      []Message `json:"messages"`
+}
+
+// historyMigrations upgrades a history file from the keyed version to the
+// next one. Loading applies them in sequence up to HistorySchemaVersion.
+var historyMigrations = map[int]func(data []byte) ([]byte, error){
+	0: migrateHistoryV0,
+	1: migrateHistoryV1,
+}
+
+// migrateHistoryV0 wraps a bare message array into the object layout.
+func migrateHistoryV0(data []byte) ([]byte, error) {
+	var messages []jsoniter.RawMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]any{"messages": messages})
+}
+
+// migrateHistoryV1 stamps the version; the layout is otherwise unchanged.
+func migrateHistoryV1(data []byte) ([]byte, error) {
+	var doc map[string]jsoniter.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc["schema_version"] = jsoniter.RawMessage("2")
+	return json.Marshal(doc)
+}
+
+// historyVersion detects the schema version of a history file.
+func historyVersion(data []byte) (int, error) {
+	switch jsoniter.Get(data).ValueType() {
+	case jsoniter.ArrayValue:
+		return 0, nil
+	case jsoniter.ObjectValue:
+		var head struct {
+			SchemaVersion *int `json:"schema_version"`
+		}
+		if err := json.Unmarshal(data, &head); err != nil {
+			return 0, err
+		}
+		if head.SchemaVersion == nil {
+			return 1, nil
+		}
+		return *head.SchemaVersion, nil
+	default:
+		return 0, fmt.Errorf("unrecognized history file")
+	}
+}
+
+// migrateHistory upgrades a history file to HistorySchemaVersion. Files
+// written by a newer version are rejected rather than misread, since saving
+// them back would drop the fields this version does not know.
+func migrateHistory(data []byte) ([]byte, error) {
+	version, err := historyVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version > HistorySchemaVersion {
+		return nil, fmt.Errorf("history schema version %d is newer than supported version %d", version, HistorySchemaVersion)
+	}
+	for ; version < HistorySchemaVersion; version++ {
+		migrate, ok := historyMigrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no history migration from schema version %d", version)
+		}
+		if data, err = migrate(data); err != nil {
+			return nil, fmt.Errorf("history migration from schema version %d: %w", version, err)
+		}
+	}
+	return data, nil
+}
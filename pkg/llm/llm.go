@@ -12,9 +12,23 @@ import (
 // json is used internally in the llm package for JSON processing, unifying on json-iterator
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
-// DebugDirContextKey is the key used in context to pass the debug archive folder name
+// DebugDirContextKey is the key used in context to pass the debug archive folder name.
+// The same value doubles as the request's trace ID printed on every log line.
 const DebugDirContextKey = "llm_debug_dir"
 
+// WithDebugID returns a copy of ctx carrying the request's trace/debug ID.
+func WithDebugID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, DebugDirContextKey, id)
+}
+
+// DebugIDFromContext extracts the trace/debug ID from ctx, or "" if absent.
+func DebugIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(DebugDirContextKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
 // LLMUsage encapsulates detailed token consumption metrics for an LLM request.
 // It is used for monitoring costs, debugging context limits, and
 // general observability of the model's performance.
@@ -65,7 +79,10 @@ type LLMClient interface {
 	// Parameters:
 	//   - ctx: Operation context for cancellation and timeouts.
 	//   - messages: Slice of conversation history to provide as context.
-	//   - availableTools: Optional tool definitions (schema) for agentic capabilities.
+	//   - availableTools: Optional tool definitions for agentic capabilities. This is the
+	//     canonical contract: callers always pass provider-agnostic []Tool and each client
+	//     converts them into its native schema (including RequiredParameters). Callers must
+	//     never branch on Provider() to pre-format tools.
 	// Returns:
 	//   - A channel emitting StreamChunks or an error if initialization fails.
 	StreamChat(ctx context.Context, messages []Message, availableTools []Tool) (<-chan StreamChunk, error)
@@ -128,6 +145,44 @@ func (f *FallbackClient) StreamChat(ctx context.Context, messages []Message, ava
 	return nil, fmt.Errorf("all fallback providers failed. Last error: %v", lastErr)
 }
 
+// Timeout returns the longest deadline among the wrapped clients, so an
+// outer deadline never cuts off a slower fallback. It returns zero if any
+// client imposes no deadline.
+func (f *FallbackClient) Timeout() time.Duration {
+	var longest time.Duration
+	for _, client := range f.Clients {
+		tc, ok := client.(TimedClient)
+		if !ok || tc.Timeout() <= 0 {
+			return 0
+		}
+		longest = max(longest, tc.Timeout())
+	}
+	return longest
+}
+
+// Capabilities merges the capabilities of the wrapped clients: a feature is
+// reported if any client supports it, since each client is decorated to
+// drop what its own model cannot handle. AlternatingRoles is reported if any
+// client requires it, each merging messages for itself. MaxContext is the smallest known
+// window, as any client in the chain may end up serving the request.
+func (f *FallbackClient) Capabilities() ModelCapabilities {
+	var caps ModelCapabilities
+	for i, client := range f.Clients {
+		c := CapabilitiesOf(client)
+		if i == 0 {
+			caps.Model = c.Model
+		}
+		caps.Vision = caps.Vision || c.Vision
+		caps.Tools = caps.Tools || c.Tools
+		caps.Reasoning = caps.Reasoning || c.Reasoning
+		caps.AlternatingRoles = caps.AlternatingRoles || c.AlternatingRoles
+		if c.MaxContext > 0 && (caps.MaxContext == 0 || c.MaxContext < caps.MaxContext) {
+			caps.MaxContext = c.MaxContext
+		}
+	}
+	return caps
+}
+
 func (f *FallbackClient) Provider() string {
 	if len(f.Clients) > 0 {
 		return f.Clients[0].Provider()
@@ -3,6 +3,7 @@ package llm
 import (
 	"fmt"
 	"log/slog"
+	"maps"
 	"time"
 
 	"genesis/pkg/config"
@@ -42,18 +43,11 @@ func NewFromConfig(rawLLM jsoniter.RawMessage, system *config.SystemConfig) (LLM
 	for _, group := range groups {
 		slog.Info("Loading LLM group", "type", group.Type, "models", len(group.Models))
 
-		factory, ok := GetProviderFactory(group.Type)
-		if !ok {
-			slog.Warn("Unknown provider type", "type", group.Type)
-			continue
-		}
-
-		clients, err := factory.Create(group, system)
+		clients, err := createGroup(group, system)
 		if err != nil {
 			slog.Error("Failed to create clients", "type", group.Type, "error", err)
 			continue
 		}
-
 		allAtomicClients = append(allAtomicClients, clients...)
 	}
 
@@ -79,3 +73,89 @@ func NewFromConfig(rawLLM jsoniter.RawMessage, system *config.SystemConfig) (LLM
 
 	return finalClient, nil
 }
+
+// createGroup creates the clients of one provider group, wrapped with the
+// group's capabilities and stream deadline. Factories only build clients,
+// so nothing is contacted.
+func createGroup(group ProviderGroupConfig, system *config.SystemConfig) ([]LLMClient, error) {
+	factory, ok := GetProviderFactory(group.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider type %q", group.Type)
+	}
+
+	// Do not request thinking from models declared without reasoning
+	var declared struct {
+		Reasoning *bool `json:"reasoning"`
+	}
+	if len(group.Capabilities) > 0 {
+		if err := jsoniter.Unmarshal(group.Capabilities, &declared); err != nil {
+			return nil, fmt.Errorf("invalid capabilities: %w", err)
+		}
+	}
+	if declared.Reasoning != nil && !*declared.Reasoning {
+		if effort, ok := group.Options["thinking_effort"].(string); ok && effort != "off" {
+			slog.Warn("Models declared without reasoning, ignoring thinking_effort", "type", group.Type, "thinking_effort", effort)
+			group.Options = maps.Clone(group.Options)
+			group.Options["thinking_effort"] = "off"
+		}
+	}
+
+	clients, err := factory.Create(group, system)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the group's stream deadline, falling back to the global one
+	timeoutMs := group.TimeoutMs
+	if timeoutMs == 0 {
+		timeoutMs = system.LLMTimeoutMs
+	}
+	wrapped := make([]LLMClient, 0, len(clients))
+	for _, c := range clients {
+		caps := CapabilitiesOf(c)
+		if len(group.Capabilities) > 0 {
+			// Validated above, so only the declared fields are overlaid here
+			jsoniter.Unmarshal(group.Capabilities, &caps)
+		}
+		c = NewCapabilityClient(c, caps)
+		wrapped = append(wrapped, NewTimedClient(c, time.Duration(timeoutMs)*time.Millisecond))
+	}
+	return wrapped, nil
+}
+
+// GroupCheck is the outcome of creating one provider group in CheckConfig.
+type GroupCheck struct {
+	Type    string   // Provider type of the group
+	Models  []string // Models configured in the group
+	Clients int      // Number of clients created
+	Err     error    // Why the group would be skipped, if it would
+}
+
+// CheckConfig creates the clients of every provider group like
+// NewFromConfig, without contacting any provider, and reports per group
+// what would be loaded. It fails if the section cannot be parsed or no
+// client would be available.
+func CheckConfig(rawLLM jsoniter.RawMessage, system *config.SystemConfig) ([]GroupCheck, error) {
+	if rawLLM == nil {
+		return nil, fmt.Errorf("missing 'llm' config")
+	}
+	var groups []ProviderGroupConfig
+	if err := jsoniter.Unmarshal(rawLLM, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse 'llm' config: %v", err)
+	}
+
+	var checks []GroupCheck
+	total := 0
+	for _, group := range groups {
+		clients, err := createGroup(group, system)
+		if err == nil && len(clients) == 0 {
+			err = fmt.Errorf("no clients created (check models and api_keys)")
+		}
+		checks = append(checks, GroupCheck{Type: group.Type, Models: group.Models, Clients: len(clients), Err: err})
+		total += len(clients)
+	}
+	if total == 0 {
+		return checks, fmt.Errorf("no LLM clients could be initialized")
+	}
+	return checks, nil
+}
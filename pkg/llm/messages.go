@@ -4,13 +4,15 @@ import (
 	"encoding/base64"
 	"fmt"
 	"genesis/pkg/utils"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
 )
 
 // Tool represents the minimal interface required for an LLM provider to
-// understand and format a tool for its API.
+// understand and format a tool for its API. Formatting into a provider's
+// native schema happens exclusively inside each LLMClient implementation.
 type Tool interface {
 	Name() string
 	Description() string
@@ -18,6 +20,40 @@ type Tool interface {
 	RequiredParameters() []string
 }
 
+// ToolSchema builds the full JSON Schema object for a tool's parameters,
+// as expected by every supported provider. Clients must use it instead of
+// assembling the schema themselves so "required" is never dropped.
+func ToolSchema(t Tool) map[string]any {
+	properties := t.Parameters()
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if required := checkRequiredParameters(t, properties); len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// checkRequiredParameters returns the tool's required parameter names that
+// are actually declared in properties. Undeclared names are logged and
+// dropped, since providers reject schemas that require unknown fields.
+func checkRequiredParameters(t Tool, properties map[string]any) []string {
+	required := t.RequiredParameters()
+	valid := make([]string, 0, len(required))
+	for _, name := range required {
+		if _, ok := properties[name]; !ok {
+			slog.Warn("Tool requires an undeclared parameter, ignoring", "tool", t.Name(), "parameter", name)
+			continue
+		}
+		valid = append(valid, name)
+	}
+	return valid
+}
+
 // Message represents a single unit of conversation in a multi-modal chat history.
 // It maps to common LLM interaction formats like OpenAI or Gemini messages,
 // supporting roles, content blocks, and tool-related metadata.
@@ -45,6 +81,10 @@ type Message struct {
 	// Usage provides token metrics and termination metadata for this specific
 	// exchange, typically populated for assistant responses.
 	Usage *LLMUsage `json:"usage,omitempty"`
+
+	// Pinned marks a message (e.g., a user preference) that must survive
+	// summarization and truncation verbatim, regardless of its position.
+	Pinned bool `json:"pinned,omitempty"`
 }
 
 // ToolCall represents a specific request from the LLM to execute an external tool.
@@ -77,14 +117,18 @@ type FunctionCall struct {
 // It abstracts different data formats like text, internal reasoning (thinking),
 // errors, or multi-modal sources like images.
 type ContentBlock struct {
-	// Type specifies the content format: "text", "thinking", "error", or "image".
+	// Type specifies the content format: "text", "thinking", "error", "image", or "table".
 	Type string `json:"type"`
 
-	// Text contains the string content for "text", "thinking", or "error" types.
+	// Text contains the string content for "text", "thinking", or "error" types,
+	// and the plain-text rendering for "table" types.
 	Text string `json:"text,omitempty"`
 
 	// Source points to binary or remote data for "image" type blocks.
 	Source *ImageSource `json:"source,omitempty"`
+
+	// Table holds the structured data for "table" type blocks.
+	Table *Table `json:"table,omitempty"`
 }
 
 // ImageSource defines the raw data or reference for an image content block.
@@ -228,12 +272,13 @@ func (m *Message) AddContentBlock(block ContentBlock) {
 	m.Content = append(m.Content, block)
 }
 
-// GetTextContent aggregates and returns all text from "text" blocks in the message.
+// GetTextContent aggregates and returns all text from "text" blocks (and the
+// plain-text rendering of "table" blocks) in the message.
 // It filters out thinking, image, or error blocks.
 func (m *Message) GetTextContent() string {
 	var sb strings.Builder
 	for _, block := range m.Content {
-		if block.Type == BlockTypeText {
+		if block.Type == BlockTypeText || block.Type == BlockTypeTable {
 			sb.WriteString(block.Text)
 		}
 	}
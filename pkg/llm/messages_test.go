@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"slices"
+	"testing"
+)
+
+// schemaTool is a Tool with fixed parameters.
+type schemaTool struct {
+	params   map[string]any
+	required []string
+}
+
+func (t schemaTool) Name() string                 { return "schema_tool" }
+func (t schemaTool) Description() string          { return "test tool" }
+func (t schemaTool) Parameters() map[string]any   { return t.params }
+func (t schemaTool) RequiredParameters() []string { return t.required }
+
+func TestToolSchemaIncludesRequired(t *testing.T) {
+	schema := ToolSchema(schemaTool{
+		params:   map[string]any{"path": map[string]any{"type": "string"}, "mode": map[string]any{"type": "string"}},
+		required: []string{"path"},
+	})
+	if schema["type"] != "object" {
+		t.Errorf("type = %v, want object", schema["type"])
+	}
+	if required, _ := schema["required"].([]string); !slices.Equal(required, []string{"path"}) {
+		t.Errorf("required = %v, want [path]", schema["required"])
+	}
+}
+
+func TestToolSchemaDropsUndeclaredRequired(t *testing.T) {
+	schema := ToolSchema(schemaTool{
+		params:   map[string]any{"path": map[string]any{"type": "string"}},
+		required: []string{"path", "missing"},
+	})
+	if required, _ := schema["required"].([]string); !slices.Equal(required, []string{"path"}) {
+		t.Errorf("required = %v, want [path]", schema["required"])
+	}
+}
+
+func TestToolSchemaWithoutParameters(t *testing.T) {
+	schema := ToolSchema(schemaTool{})
+	if props, ok := schema["properties"].(map[string]any); !ok || len(props) != 0 {
+		t.Errorf("properties = %v, want an empty object", schema["properties"])
+	}
+	if _, ok := schema["required"]; ok {
+		t.Errorf("required = %v, want it omitted", schema["required"])
+	}
+}
@@ -0,0 +1,124 @@
+package mock
+
+import (
+	"context"
+	"genesis/pkg/llm"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockClient is an offline LLMClient that streams synthetic responses
+// without contacting any provider. It backs the streaming benchmarks and is
+// not part of the autoloaded providers, so release binaries do not ship it;
+// importing the package registers it as the "mock" provider type.
+//
+// Behaviour is controlled by the unified options map:
+//   - "chunks":     number of text chunks to emit (default: echo mode)
+//   - "chunk_text": text of every emitted chunk (default: "a")
+//   - "delay_ms":   delay between chunks in milliseconds (default: 0)
+//
+// In echo mode (no "chunks" option) the last user message is streamed back
+// word by word.
+type MockClient struct {
+	model     string
+	chunks    int
+	chunkText string
+	delay     time.Duration
+
+	mu        sync.Mutex
+	lastTools []llm.Tool // Tools passed to the latest StreamChat
+}
+
+// NewMockClient creates a MockClient from unified provider options.
+func NewMockClient(model string, options map[string]any) *MockClient {
+	c := &MockClient{
+		model:     model,
+		chunkText: "a",
+	}
+	if n, ok := options["chunks"].(float64); ok {
+		c.chunks = int(n)
+	}
+	if t, ok := options["chunk_text"].(string); ok && t != "" {
+		c.chunkText = t
+	}
+	if d, ok := options["delay_ms"].(float64); ok {
+		c.delay = time.Duration(d) * time.Millisecond
+	}
+	return c
+}
+
+func (c *MockClient) Provider() string {
+	return "mock"
+}
+
+// Capabilities reports that the mock accepts any input; it ignores tools
+// and images and never produces thinking.
+func (c *MockClient) Capabilities() llm.ModelCapabilities {
+	return llm.ModelCapabilities{Model: c.model, Vision: true, Tools: true}
+}
+
+// LastTools returns the tools offered to the model by the latest StreamChat,
+// letting tests check what the engine advertises.
+func (c *MockClient) LastTools() []llm.Tool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastTools
+}
+
+func (c *MockClient) IsTransientError(err error) bool {
+	return false
+}
+
+// StreamChat implements llm.LLMClient.StreamChat
+func (c *MockClient) StreamChat(ctx context.Context, messages []llm.Message, availableTools []llm.Tool) (<-chan llm.StreamChunk, error) {
+	slog.InfoContext(ctx, "Streaming", "provider", c.Provider(), "model", c.model)
+	c.mu.Lock()
+	c.lastTools = availableTools
+	c.mu.Unlock()
+
+	var pieces []string
+	if c.chunks > 0 {
+		pieces = make([]string, c.chunks)
+		for i := range pieces {
+			pieces[i] = c.chunkText
+		}
+	} else {
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].Role == "user" {
+				pieces = strings.SplitAfter(messages[i].GetTextContent(), " ")
+				break
+			}
+		}
+	}
+
+	chunkCh := make(chan llm.StreamChunk, 100)
+	go func() {
+		defer close(chunkCh)
+		for _, p := range pieces {
+			if c.delay > 0 {
+				time.Sleep(c.delay)
+			}
+			select {
+			case chunkCh <- llm.NewTextChunk(p):
+			case <-ctx.Done():
+				// The consumer may be gone, so the error is only sent if still read
+				select {
+				case chunkCh <- llm.NewErrorChunk("Stream cancelled", ctx.Err(), true):
+				default:
+				}
+				return
+			}
+		}
+		select {
+		case chunkCh <- llm.NewFinalChunk(llm.StopReasonStop, &llm.LLMUsage{
+			CompletionTokens: len(pieces),
+			TotalTokens:      len(pieces),
+		}):
+		case <-ctx.Done():
+		}
+	}()
+
+	return chunkCh, nil
+}
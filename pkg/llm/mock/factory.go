@@ -0,0 +1,22 @@
+package mock
+
+import (
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+)
+
+// MockFactory handles creation of Mock Clients
+type MockFactory struct{}
+
+// Create implements ProviderFactory
+func (f *MockFactory) Create(cfg llm.ProviderGroupConfig, sys *config.SystemConfig) ([]llm.LLMClient, error) {
+	var clients []llm.LLMClient
+	for _, model := range cfg.Models {
+		clients = append(clients, NewMockClient(model, cfg.Options))
+	}
+	return clients, nil
+}
+
+func init() {
+	llm.RegisterProvider("mock", &MockFactory{})
+}
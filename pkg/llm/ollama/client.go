@@ -5,6 +5,7 @@ import (
 	"genesis/pkg/config"
 	"genesis/pkg/llm"
 	"genesis/pkg/llm/openailm"
+	"strings"
 )
 
 // OllamaClient is now a wrapper around the generic OpenAI client
@@ -13,17 +14,23 @@ type OllamaClient struct {
 	client *openailm.Client
 }
 
+// defaultBaseURL is used when neither the group nor SystemConfig.OllamaDefaultURL sets one.
+const defaultBaseURL = "http://localhost:11434/v1"
+
 // NewOllamaClient creates an Ollama client using the OpenAI compatibility layer
 func NewOllamaClient(model string, baseURL string, options map[string]any, sys *config.SystemConfig) (*OllamaClient, error) {
 	// Ollama APIs are compatible with OpenAI.
 	apiKey := "ollama"
 
+	if baseURL == "" && sys != nil {
+		baseURL = sys.OllamaDefaultURL
+	}
 	if baseURL == "" {
-		baseURL = "http://localhost:11434/v1"
-	} else {
-		if !containsV1(baseURL) {
-			baseURL = baseURL + "/v1"
-		}
+		baseURL = defaultBaseURL
+	}
+	// The native API lives under /api; the OpenAI-compatible one under /v1
+	if trimmed := strings.TrimRight(baseURL, "/"); strings.HasSuffix(trimmed, "/api") {
+		baseURL = strings.TrimSuffix(trimmed, "/api") + "/v1"
 	}
 
 	client, err := openailm.NewClient("ollama", apiKey, model, baseURL, options, sys)
@@ -36,14 +43,17 @@ func NewOllamaClient(model string, baseURL string, options map[string]any, sys *
 	}, nil
 }
 
-func containsV1(url string) bool {
-	return len(url) >= 3 && url[len(url)-3:] == "/v1"
-}
+// Compile-time check that the client satisfies the []llm.Tool based contract.
+var _ llm.LLMClient = (*OllamaClient)(nil)
 
 func (o *OllamaClient) Provider() string {
 	return "ollama"
 }
 
+func (o *OllamaClient) Capabilities() llm.ModelCapabilities {
+	return o.client.Capabilities()
+}
+
 func (o *OllamaClient) IsTransientError(err error) bool {
 	return o.client.IsTransientError(err)
 }
@@ -0,0 +1,56 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+	"genesis/pkg/tools"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamChatSendsTools(t *testing.T) {
+	bodies := make(chan map[string]any, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		bodies <- body
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"rejected by test server"}}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewOllamaClient("test-model", srv.URL, nil, config.DefaultSystemConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []llm.Message{{Role: "user", Content: []llm.ContentBlock{llm.NewTextBlock("hi")}}}
+	ch, err := c.StreamChat(context.Background(), messages, []llm.Tool{tools.NewOSTool(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range ch {
+	}
+
+	body := <-bodies
+	list, _ := body["tools"].([]any)
+	for _, item := range list {
+		tool, ok := item.(map[string]any)
+		if !ok || tool["name"] != "os_control" {
+			continue
+		}
+		params, _ := tool["parameters"].(map[string]any)
+		if required, _ := params["required"].([]any); len(required) != 1 || required[0] != "action" {
+			t.Errorf("required = %v, want [action]", params["required"])
+		}
+		return
+	}
+	t.Fatalf("os_control not in request, got tools %v", body["tools"])
+}
@@ -0,0 +1,54 @@
+package openailm
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// endpointSuffixes are request paths users sometimes paste into base_url
+// along with the API root.
+var endpointSuffixes = []string{"/chat/completions", "/completions", "/responses", "/models"}
+
+// NormalizeBaseURL turns a user-supplied base URL into the API root expected
+// by the SDK. It adds a missing http:// scheme, drops trailing slashes and
+// pasted endpoint paths (e.g., ".../v1/chat/completions"), and appends "/v1"
+// when no path is given. Custom paths such as "/api/v1" or "/v1beta/openai"
+// are kept as they are.
+func NormalizeBaseURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", raw, err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid base URL %q: missing host", raw)
+	}
+
+	// Collapse duplicate slashes and strip trailing ones
+	p := u.Path
+	for strings.Contains(p, "//") {
+		p = strings.ReplaceAll(p, "//", "/")
+	}
+	p = strings.TrimRight(p, "/")
+	for _, suffix := range endpointSuffixes {
+		if strings.HasSuffix(p, suffix) {
+			p = strings.TrimSuffix(p, suffix)
+			break
+		}
+	}
+	if p == "" {
+		p = "/v1"
+	}
+
+	u.Path = p
+	u.RawPath = ""
+	return u.String(), nil
+}
@@ -29,14 +29,24 @@ type Client struct {
 	options   map[string]any
 }
 
-// NewClient creates a new OpenAI client
+// NewClient creates a new OpenAI client. A non-empty baseURL is normalized
+// with NormalizeBaseURL and the effective URL is logged.
 func NewClient(provider string, apiKey string, model string, baseURL string, options map[string]any, sys *config.SystemConfig) (*Client, error) {
 	opts := []option.RequestOption{
 		option.WithAPIKey(apiKey),
 	}
 
 	if baseURL != "" {
-		opts = append(opts, option.WithBaseURL(baseURL))
+		effective, err := NormalizeBaseURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		if effective != baseURL {
+			slog.Info("Normalized base URL", "provider", provider, "model", model, "configured", baseURL, "effective", effective)
+		} else {
+			slog.Info("Using base URL", "provider", provider, "model", model, "url", effective)
+		}
+		opts = append(opts, option.WithBaseURL(effective))
 	}
 
 	client := openai.NewClient(opts...)
@@ -50,10 +60,19 @@ func NewClient(provider string, apiKey string, model string, baseURL string, opt
 	}, nil
 }
 
+// Compile-time check that the client satisfies the []llm.Tool based contract.
+var _ llm.LLMClient = (*Client)(nil)
+
 func (c *Client) Provider() string {
 	return c.provider
 }
 
+// Capabilities reports permissive defaults, as support varies by model on
+// OpenAI-compatible servers; declare limits in the group's "capabilities".
+func (c *Client) Capabilities() llm.ModelCapabilities {
+	return llm.ModelCapabilities{Model: c.model, Vision: true, Tools: true, Reasoning: true}
+}
+
 func (c *Client) IsTransientError(err error) bool {
 	if err == nil {
 		return false
@@ -150,6 +169,10 @@ func (c *Client) StreamChat(ctx context.Context, messages []llm.Message, availab
 		var assistantTextAccumulator strings.Builder
 		var thinkingLogBuffer string
 		toolCallsMap := make(map[string]*llm.ToolCall)
+		var toolCallOrder []string // Item IDs in arrival order, so calls are emitted deterministically
+
+		// Merge per-token deltas into fewer chunks to reduce allocations and channel sends
+		out := llm.NewStreamCoalescer(chunkCh, c.sysConfig)
 
 		for stream.Next() {
 			event := stream.Current()
@@ -188,23 +211,23 @@ func (c *Client) StreamChat(ctx context.Context, messages []llm.Message, availab
 				}
 				if thought != "" {
 					thinkingLogBuffer += thought
-					chunkCh <- llm.NewThinkingChunk(thought)
+					out.AddThinking(thought)
 				}
 			}
 
 			// Handle different event types using SDK native types
 			switch variant := event.AsAny().(type) {
 			case responses.ResponseTextDeltaEvent:
-				chunkCh <- llm.NewTextChunk(variant.Delta)
+				out.AddText(variant.Delta)
 				assistantTextAccumulator.WriteString(variant.Delta)
 
 			case responses.ResponseReasoningTextDeltaEvent:
 				thinkingLogBuffer += variant.Delta
-				chunkCh <- llm.NewThinkingChunk(variant.Delta)
+				out.AddThinking(variant.Delta)
 
 			case responses.ResponseReasoningSummaryTextDeltaEvent:
 				thinkingLogBuffer += variant.Delta
-				chunkCh <- llm.NewThinkingChunk(variant.Delta)
+				out.AddThinking(variant.Delta)
 
 			case responses.ResponseFunctionCallArgumentsDeltaEvent:
 				tc, ok := toolCallsMap[variant.ItemID]
@@ -213,6 +236,7 @@ func (c *Client) StreamChat(ctx context.Context, messages []llm.Message, availab
 						ID: variant.ItemID,
 					}
 					toolCallsMap[variant.ItemID] = tc
+					toolCallOrder = append(toolCallOrder, variant.ItemID)
 				}
 				tc.Function.Arguments += variant.Delta
 
@@ -230,6 +254,7 @@ func (c *Client) StreamChat(ctx context.Context, messages []llm.Message, availab
 					if !ok {
 						tc = &llm.ToolCall{ID: variant.Item.ID}
 						toolCallsMap[variant.Item.ID] = tc
+						toolCallOrder = append(toolCallOrder, variant.Item.ID)
 					}
 					if variant.Item.Name != "" {
 						tc.Name = variant.Item.Name
@@ -269,14 +294,14 @@ func (c *Client) StreamChat(ctx context.Context, messages []llm.Message, availab
 
 			case responses.ResponseFailedEvent:
 				lastFinishReason = "failed"
-				chunkCh <- llm.NewErrorChunk("API Response Failed", nil, true)
+				out.Send(llm.NewErrorChunk("API Response Failed", nil, true))
 
 			case responses.ResponseIncompleteEvent:
 				lastFinishReason = "length"
-				chunkCh <- llm.NewErrorChunk("API Response Incomplete", nil, true)
+				out.Send(llm.NewErrorChunk("API Response Incomplete", nil, true))
 
 			case responses.ResponseErrorEvent:
-				chunkCh <- llm.NewErrorChunk(fmt.Sprintf("API Error: %s", variant.Message), nil, true)
+				out.Send(llm.NewErrorChunk(fmt.Sprintf("API Error: %s", variant.Message), nil, true))
 			}
 		}
 		if strings.TrimSpace(thinkingLogBuffer) != "" {
@@ -286,31 +311,31 @@ func (c *Client) StreamChat(ctx context.Context, messages []llm.Message, availab
 		// If we found tool calls, emit them now
 		if len(toolCallsMap) > 0 {
 			toolCallsFound := make([]llm.ToolCall, 0, len(toolCallsMap))
-			for _, tc := range toolCallsMap {
-				toolCallsFound = append(toolCallsFound, *tc)
+			for _, id := range toolCallOrder {
+				toolCallsFound = append(toolCallsFound, *toolCallsMap[id])
 			}
-			chunkCh <- llm.StreamChunk{
+			out.Send(llm.StreamChunk{
 				ToolCalls: toolCallsFound,
-			}
+			})
 		} else if assistantText := assistantTextAccumulator.String(); assistantText != "" {
 			// Fallback: Check for JSON-formatted tool calls in the text
 			extractedCalls := detectAndParseJsonToolCalls(assistantText)
 			if len(extractedCalls) > 0 {
-				chunkCh <- llm.StreamChunk{
+				out.Send(llm.StreamChunk{
 					ToolCalls: extractedCalls,
-				}
+				})
 			}
 		}
 
 		if err := stream.Err(); err != nil {
-			chunkCh <- llm.NewErrorChunk(fmt.Sprintf("Stream error: %v", err), err, true)
+			out.Send(llm.NewErrorChunk(fmt.Sprintf("Stream error: %v", err), err, true))
 		} else {
 			// Send final chunk with accumulated stats
 			reason := "stop"
 			if lastFinishReason != "" {
 				reason = normalizeStopReason(lastFinishReason)
 			}
-			chunkCh <- llm.NewFinalChunk(reason, lastUsage)
+			out.Send(llm.NewFinalChunk(reason, lastUsage))
 		}
 	}()
 
@@ -410,13 +435,7 @@ func (c *Client) convertTools(availableTools []llm.Tool) []responses.ToolUnionPa
 	var toolsParam []responses.ToolUnionParam
 	for _, t := range availableTools {
 		// OpenAI expects a full JSON Schema object for parameters
-		parameters := map[string]any{
-			"type":       "object",
-			"properties": t.Parameters(),
-		}
-		if required := t.RequiredParameters(); len(required) > 0 {
-			parameters["required"] = required
-		}
+		parameters := llm.ToolSchema(t)
 
 		toolsParam = append(toolsParam, responses.ToolUnionParam{
 			OfFunction: &responses.FunctionToolParam{
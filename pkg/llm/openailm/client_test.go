@@ -0,0 +1,123 @@
+package openailm
+
+import (
+	"context"
+	"encoding/json"
+	"genesis/pkg/config"
+	"genesis/pkg/llm"
+	"genesis/pkg/tools"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// captureRequests starts a server that records the JSON body of every
+// request and rejects it, so the stream under test ends right away.
+func captureRequests(t *testing.T) (*httptest.Server, <-chan map[string]any) {
+	t.Helper()
+	bodies := make(chan map[string]any, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		bodies <- body
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"rejected by test server"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, bodies
+}
+
+// requestTool returns the tool with the given name from a captured request.
+func requestTool(t *testing.T, body map[string]any, name string) map[string]any {
+	t.Helper()
+	list, _ := body["tools"].([]any)
+	for _, item := range list {
+		if tool, ok := item.(map[string]any); ok && tool["name"] == name {
+			return tool
+		}
+	}
+	t.Fatalf("tool %q not in request, got tools %v", name, body["tools"])
+	return nil
+}
+
+func TestStreamChatSendsTools(t *testing.T) {
+	srv, bodies := captureRequests(t)
+	c, err := NewClient("openai", "test-key", "test-model", srv.URL, nil, config.DefaultSystemConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []llm.Message{{Role: "user", Content: []llm.ContentBlock{llm.NewTextBlock("hi")}}}
+	ch, err := c.StreamChat(context.Background(), messages, []llm.Tool{tools.NewOSTool(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range ch {
+	}
+
+	tool := requestTool(t, <-bodies, "os_control")
+	if tool["type"] != "function" {
+		t.Errorf("tool type = %v, want function", tool["type"])
+	}
+	params, _ := tool["parameters"].(map[string]any)
+	if params["type"] != "object" {
+		t.Errorf("parameters type = %v, want object", params["type"])
+	}
+	props, _ := params["properties"].(map[string]any)
+	if _, ok := props["action"]; !ok {
+		t.Errorf("parameters lack the action property: %v", params)
+	}
+	if required, _ := params["required"].([]any); len(required) != 1 || required[0] != "action" {
+		t.Errorf("required = %v, want [action]", params["required"])
+	}
+}
+
+func TestStreamChatAccumulatesFragmentedToolCalls(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "fragmented_tool_calls.sse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write(fixture)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient("openai", "test-key", "test-model", srv.URL, nil, config.DefaultSystemConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []llm.Message{{Role: "user", Content: []llm.ContentBlock{llm.NewTextBlock("hi")}}}
+	ch, err := c.StreamChat(context.Background(), messages, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []llm.ToolCall
+	for chunk := range ch {
+		if chunk.Error != "" {
+			t.Fatalf("stream error: %s", chunk.Error)
+		}
+		got = append(got, chunk.ToolCalls...)
+	}
+
+	want := []llm.ToolCall{
+		{ID: "fc_1", Name: "search", Function: llm.FunctionCall{Name: "search", Arguments: `{"query":"golang","limit":5}`}},
+		{ID: "fc_2", Name: "get_weather", Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Taipei"}`}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d tool calls, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Name != want[i].Name || got[i].Function != want[i].Function {
+			t.Errorf("call %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -2,6 +2,8 @@ package llm
 
 import (
 	"genesis/pkg/config"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
 // ProviderGroupConfig defines a schema for configuring a cluster of models
@@ -13,6 +15,15 @@ type ProviderGroupConfig struct {
 	Models  []string       `json:"models"`             // List of model names to initialize (e.g., ["gemini-1.5-flash"])
 	BaseURL string         `json:"base_url,omitempty"` // Custom API endpoint (mostly used for local Ollama instances)
 	Options map[string]any `json:"options,omitempty"`  // Unified parameters (thinking_effort, temperature, topP, etc.)
+	// TimeoutMs overrides SystemConfig.LLMTimeoutMs for this group's models.
+	// 0 uses the global value; a negative value disables the deadline (e.g., slow local models).
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+	// Capabilities overrides the provider-reported ModelCapabilities for every
+	// model in the group; omitted fields keep the provider's value.
+	// e.g., {"vision": false, "tools": false, "max_context": 8192}
+	// Set "alternating_roles" for servers that reject consecutive user or
+	// assistant messages.
+	Capabilities jsoniter.RawMessage `json:"capabilities,omitempty"`
 }
 
 // ProviderFactory is a structural interface for provider-specific loaders.
@@ -1,78 +1,544 @@
 package llm
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"weak"
 )
 
 var filenameSafeRegex = regexp.MustCompile(`[^a-zA-Z0-9_\-]`)
 
+// evictionSweepInterval is the longest pause between idle eviction sweeps.
+const evictionSweepInterval = time.Minute
+
 // SessionManager manages multiple conversation histories isolated by session ID.
+// Histories are cached in memory and, when an eviction policy is set, dropped
+// again once idle or beyond the size cap; they are reloaded from disk on the
+// next access.
 type SessionManager struct {
-	histories map[string]*ChatHistory
-	storage   string
-	mu        sync.RWMutex
+	histories   map[string]*sessionEntry
+	evicted     map[string]weak.Pointer[ChatHistory] // Evicted histories possibly still held by in-flight requests
+	loading     map[string]*sessionLoad              // Disk loads in progress, shared by concurrent callers
+	active      map[string]string                    // Session switched to, keyed by the chat's default session
+	storage     string                               // Directory of history files; empty keeps histories in memory only
+	attachments string                               // Directory images are externalized to on save
+	maxSessions int                                  // Maximum histories kept in memory; 0 for unlimited
+	idleTTL     time.Duration                        // Idle time after which a history is evicted; 0 to keep
+	backups     int                                  // Previous versions kept per session; 0 disables backups
+	compress    bool                                 // Write histories gzip-compressed (.json.gz)
+	inline      bool                                 // Keep image data in history files instead of externalizing it
+	mu          sync.RWMutex
+}
+
+// sessionLoad is a disk load of one session. Callers asking for the same
+// session while it loads wait on done instead of reading the file again.
+type sessionLoad struct {
+	done    chan struct{}
+	history *ChatHistory
+	err     error
+}
+
+// sessionEntry is a cached history with its last access time.
+type sessionEntry struct {
+	history  *ChatHistory
+	lastUsed atomic.Int64 // Unix nanoseconds
 }
 
-// NewSessionManager initializes a SessionManager with a specific storage directory.
-func NewSessionManager(storage string) *SessionManager {
-	if storage != "" {
-		os.MkdirAll(storage, 0755)
+func (e *sessionEntry) touch() {
+	e.lastUsed.Store(time.Now().UnixNano())
+}
+
+// NewSessionManager initializes a SessionManager persisting to the data
+// directory dataDir: histories go to its "sessions" folder and their images
+// to "attachments". An empty dataDir keeps histories in memory only.
+func NewSessionManager(dataDir string) *SessionManager {
+	sm := &SessionManager{
+		histories: make(map[string]*sessionEntry),
+		evicted:   make(map[string]weak.Pointer[ChatHistory]),
+		loading:   make(map[string]*sessionLoad),
+		active:    make(map[string]string),
 	}
-	return &SessionManager{
-		histories: make(map[string]*ChatHistory),
-		storage:   storage,
+	if dataDir != "" {
+		sm.storage = filepath.Join(dataDir, "sessions")
+		sm.attachments = filepath.Join(dataDir, "attachments")
+		os.MkdirAll(sm.storage, 0755)
 	}
+	return sm
+}
+
+// SetEvictionPolicy bounds the in-memory cache: at most maxSessions
+// histories are kept (least recently used are evicted first) and histories
+// idle for idleTTL are dropped. Zero disables the respective limit.
+// Eviction happens when a session is loaded and, for idle histories, in
+// the sweep started by StartEvictionSweeper. It requires a storage
+// directory, since evicted histories are saved and reloaded from disk.
+func (sm *SessionManager) SetEvictionPolicy(maxSessions int, idleTTL time.Duration) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.maxSessions = maxSessions
+	sm.idleTTL = idleTTL
+}
+
+// StartEvictionSweeper evicts idle histories periodically until ctx is
+// done, so they are released even when no other session is loaded. The
+// sweep runs every half idle TTL, capped at evictionSweepInterval; it does
+// nothing without an idle TTL or storage directory.
+func (sm *SessionManager) StartEvictionSweeper(ctx context.Context) {
+	sm.mu.RLock()
+	ttl, storage := sm.idleTTL, sm.storage
+	sm.mu.RUnlock()
+	if ttl <= 0 || storage == "" {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(min(max(ttl/2, time.Second), evictionSweepInterval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			sm.EvictIdle()
+		}
+	}()
+}
+
+// EvictIdle applies the eviction policy to the cache now, saving the
+// evicted histories.
+func (sm *SessionManager) EvictIdle() {
+	sm.mu.Lock()
+	victims := sm.evictLocked("")
+	sm.mu.Unlock()
+	sm.saveEvicted(victims)
+}
+
+// SetBackupRetention keeps the last n versions of every history file in a
+// "backups" directory next to the histories, so a bad summarization or
+// truncation can be recovered by copying a backup back. Zero disables it.
+func (sm *SessionManager) SetBackupRetention(n int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.backups = n
+}
+
+// SetCompression selects whether histories are saved gzip-compressed.
+// Files in either format are read regardless; a session's file is converted
+// the next time it is saved.
+func (sm *SessionManager) SetCompression(enabled bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.compress = enabled
+}
+
+// SetInlineImages selects whether image data is kept inside history files
+// (base64) instead of being written to the attachments directory on save.
+func (sm *SessionManager) SetInlineImages(enabled bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.inline = enabled
 }
 
 // GetHistory retrieves an existing ChatHistory for a session or creates/loads a new one.
+// The file is read without holding the manager lock, so a slow load only
+// delays callers of the same session.
 func (sm *SessionManager) GetHistory(sessionID string) (*ChatHistory, error) {
 	sm.mu.RLock()
-	h, ok := sm.histories[sessionID]
+	e, ok := sm.histories[sessionID]
 	sm.mu.RUnlock()
 
 	if ok {
+		e.touch()
+		return e.history, nil
+	}
+
+	sm.mu.Lock()
+	// Double check under lock
+	if e, ok = sm.histories[sessionID]; ok {
+		sm.mu.Unlock()
+		e.touch()
+		return e.history, nil
+	}
+
+	// Join a load already in progress
+	if l, ok := sm.loading[sessionID]; ok {
+		sm.mu.Unlock()
+		<-l.done
+		return l.history, l.err
+	}
+
+	// A request still holding an evicted history keeps it alive; reuse it so
+	// the two never diverge
+	if h := sm.evicted[sessionID].Value(); h != nil || sm.storage == "" {
+		if h == nil {
+			h = NewChatHistory()
+		}
+		delete(sm.evicted, sessionID)
+		victims := sm.addLocked(sessionID, h)
+		sm.mu.Unlock()
+		sm.saveEvicted(victims)
 		return h, nil
 	}
 
+	l := &sessionLoad{done: make(chan struct{})}
+	sm.loading[sessionID] = l
+	sm.mu.Unlock()
+
+	h := NewChatHistory()
+	err := h.Load(sm.existingHistoryPath(sessionID))
+	if err == nil {
+		// Attachments may have expired while the history was on disk
+		h.DropMissingImages()
+	}
+
+	sm.mu.Lock()
+	delete(sm.loading, sessionID)
+	var victims map[string]*ChatHistory
+	if err == nil {
+		delete(sm.evicted, sessionID)
+		victims = sm.addLocked(sessionID, h)
+		l.history = h
+	}
+	l.err = err
+	sm.mu.Unlock()
+	close(l.done)
+
+	sm.saveEvicted(victims)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// ActiveSession resolves a chat's default session ID to the session the
+// chat is currently switched to, which is the default session itself unless
+// SetActiveSession redirected it.
+func (sm *SessionManager) ActiveSession(defaultID string) string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if id, ok := sm.active[defaultID]; ok {
+		return id
+	}
+	return defaultID
+}
+
+// SetActiveSession makes the chat with the given default session continue
+// in sessionID. Switching to the default session itself undoes the switch.
+// The redirect lives in memory only and is reset by a restart.
+func (sm *SessionManager) SetActiveSession(defaultID, sessionID string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
+	if sessionID == defaultID {
+		delete(sm.active, defaultID)
+		return
+	}
+	sm.active[defaultID] = sessionID
+}
 
-	// Double check under lock
-	if h, ok = sm.histories[sessionID]; ok {
-		return h, nil
+// SessionInfo describes a stored session for listings.
+type SessionInfo struct {
+	ID           string    // Session ID, as passed to GetHistory
+	Participants []string  // Users who wrote in the session, as "channel:user"
+	Summary      string    // Condensed summary of earlier conversation, if any
+	Messages     int       // Number of messages in the history
+	Updated      time.Time // Time of the last message
+}
+
+// OwnedBy reports whether the user, given as "channel:user", may access
+// the session: they wrote in it, or it is their private chat, whose history
+// may predate participant tracking.
+func (info SessionInfo) OwnedBy(userKey string) bool {
+	return info.ID == strings.Replace(userKey, ":", "_", 1) || slices.Contains(info.Participants, userKey)
+}
+
+// ListSessions describes every known session, cached or on disk, most
+// recently updated first. Sessions are not cached by listing them.
+func (sm *SessionManager) ListSessions() ([]SessionInfo, error) {
+	var infos []SessionInfo
+	err := sm.eachSession(func(id string, h *ChatHistory) {
+		infos = append(infos, sessionInfo(id, h))
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(infos, func(a, b SessionInfo) int { return b.Updated.Compare(a.Updated) })
+	return infos, nil
+}
+
+// eachSession calls fn for every cached session and every session stored
+// on disk, preferring the cached copy. Stored histories are read without
+// being cached.
+func (sm *SessionManager) eachSession(fn func(id string, h *ChatHistory)) error {
+	sm.mu.RLock()
+	cached := make(map[string]*ChatHistory, len(sm.histories))
+	for id, e := range sm.histories {
+		cached[id] = e.history
+	}
+	sm.mu.RUnlock()
+
+	for id, h := range cached {
+		fn(id, h)
+	}
+	if sm.storage == "" {
+		return nil
 	}
 
-	h = NewChatHistory()
-	if sm.storage != "" {
-		safeID := filenameSafeRegex.ReplaceAllString(sessionID, "_")
-		historyPath := filepath.Join(sm.storage, fmt.Sprintf("history_%s.json", safeID))
-		if err := h.Load(historyPath); err != nil {
-			return nil, err
+	paths, err := filepath.Glob(filepath.Join(sm.storage, "history_*.json*"))
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".json") && !strings.HasSuffix(path, ".json.gz") {
+			continue // Temporary files of an interrupted save
 		}
+		h := NewChatHistory()
+		if err := h.Load(path); err != nil {
+			slog.Warn("Skipping unreadable session history", "path", path, "error", err)
+			continue
+		}
+		// Files written before session IDs were stored are listed under
+		// their file name, which GetHistory maps back to the same file
+		id := h.SessionID
+		if id == "" {
+			id = strings.TrimPrefix(strings.SplitN(filepath.Base(path), ".", 2)[0], "history_")
+		}
+		if _, ok := cached[id]; ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		fn(id, h)
 	}
+	return nil
+}
 
-	sm.histories[sessionID] = h
-	return h, nil
+// sessionInfo summarizes a history for ListSessions.
+func sessionInfo(id string, h *ChatHistory) SessionInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	info := SessionInfo{
+		ID:           id,
+		Participants: slices.Clone(h.Participants),
+		Summary:      h.Summary,
+		Messages:     len(h.Messages),
+	}
+	if n := len(h.Messages); n > 0 {
+		info.Updated = time.Unix(h.Messages[n-1].Timestamp, 0)
+	}
+	return info
+}
+
+// addLocked caches a history and applies the eviction policy, returning
+// the evicted histories to save. The caller must hold sm.mu for writing.
+func (sm *SessionManager) addLocked(sessionID string, h *ChatHistory) map[string]*ChatHistory {
+	h.setSessionID(sessionID)
+	e := &sessionEntry{history: h}
+	e.touch()
+	sm.histories[sessionID] = e
+	return sm.evictLocked(sessionID)
 }
 
 // SaveSession persists a specific session's history to disk.
 func (sm *SessionManager) SaveSession(sessionID string) error {
 	sm.mu.RLock()
-	h, ok := sm.histories[sessionID]
+	var h *ChatHistory
+	if e, ok := sm.histories[sessionID]; ok {
+		h = e.history
+	} else {
+		h = sm.evicted[sessionID].Value()
+	}
+	sm.mu.RUnlock()
+
+	if h == nil || sm.storage == "" {
+		return nil
+	}
+	return sm.save(sessionID, h)
+}
+
+// save writes h to the session's history file, externalizing images first
+// unless they are kept inline. With backups enabled the previous file is
+// kept as a backup.
+func (sm *SessionManager) save(sessionID string, h *ChatHistory) error {
+	sm.mu.RLock()
+	backups, inline := sm.backups, sm.inline
 	sm.mu.RUnlock()
 
-	if !ok || sm.storage == "" {
+	if inline {
+		h.InlineImages()
+	} else {
+		h.ProcessImages(sm.attachments)
+	}
+	if backups > 0 {
+		if err := sm.backup(sessionID, backups); err != nil {
+			slog.Warn("Failed to back up session history", "session", sessionID, "error", err)
+		}
+	}
+
+	path := sm.historyPath(sessionID)
+	if err := h.Save(path); err != nil {
+		return err
+	}
+	// Drop the file in the other format so it cannot shadow the new one
+	other := sm.alternatePath(path)
+	if err := os.Remove(other); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove superseded history file", "path", other, "error", err)
+	}
+	return nil
+}
+
+// backup preserves the current history file of a session as
+// "backups/history_<id>.<unixnano>.json[.gz]" and deletes all but the
+// newest keep backups. The file is hard-linked when possible; Save replaces
+// the live file with a new one, so the link keeps the old content.
+func (sm *SessionManager) backup(sessionID string, keep int) error {
+	src := sm.existingHistoryPath(sessionID)
+	if _, err := os.Stat(src); os.IsNotExist(err) {
 		return nil
 	}
 
+	dir := filepath.Join(sm.storage, "backups")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	stem, ext, _ := strings.Cut(filepath.Base(src), ".")
+	dst := filepath.Join(dir, fmt.Sprintf("%s.%d.%s", stem, time.Now().UnixNano(), ext))
+	if err := os.Link(src, dst); err != nil {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	// Timestamps have a fixed width, so name order is age order
+	old, err := filepath.Glob(filepath.Join(dir, stem+".*.json*"))
+	if err != nil {
+		return err
+	}
+	slices.Sort(old)
+	for _, path := range old[:max(len(old)-keep, 0)] {
+		os.Remove(path)
+	}
+	return nil
+}
+
+// historyPath returns the file a session's history is saved to, with a
+// ".json.gz" extension when compression is enabled.
+func (sm *SessionManager) historyPath(sessionID string) string {
 	safeID := filenameSafeRegex.ReplaceAllString(sessionID, "_")
-	historyPath := filepath.Join(sm.storage, fmt.Sprintf("history_%s.json", safeID))
-	attachmentsDir := filepath.Join(sm.storage, "..", "attachments")
+	path := filepath.Join(sm.storage, fmt.Sprintf("history_%s.json", safeID))
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if sm.compress {
+		path += ".gz"
+	}
+	return path
+}
+
+// existingHistoryPath returns the session's history file in whichever
+// format is on disk, preferring the configured one.
+func (sm *SessionManager) existingHistoryPath(sessionID string) string {
+	path := sm.historyPath(sessionID)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return path
+	}
+	other := sm.alternatePath(path)
+	if _, err := os.Stat(other); err == nil {
+		return other
+	}
+	return path
+}
+
+// alternatePath maps a history file to its name in the other format.
+func (sm *SessionManager) alternatePath(path string) string {
+	if trimmed, ok := strings.CutSuffix(path, ".gz"); ok {
+		return trimmed
+	}
+	return path + ".gz"
+}
+
+// evictLocked drops idle histories and, beyond maxSessions, the least
+// recently used ones from the cache. The session being loaded (keep) always
+// stays. The evicted histories are returned for saveEvicted, which writes
+// them outside the lock; until then they stay reachable through the weak
+// map, so a concurrent GetHistory reuses them instead of reading a stale
+// file. The caller must hold sm.mu for writing.
+func (sm *SessionManager) evictLocked(keep string) map[string]*ChatHistory {
+	if sm.storage == "" || (sm.maxSessions <= 0 && sm.idleTTL <= 0) {
+		return nil
+	}
+
+	// Forget evicted histories that nothing references anymore
+	for id, wp := range sm.evicted {
+		if wp.Value() == nil {
+			delete(sm.evicted, id)
+		}
+	}
 
-	h.ProcessImages(attachmentsDir)
-	return h.Save(historyPath)
+	// Snapshot access times; hits update them without the write lock
+	type candidate struct {
+		id       string
+		lastUsed int64
+	}
+	candidates := make([]candidate, 0, len(sm.histories))
+	for id, e := range sm.histories {
+		candidates = append(candidates, candidate{id, e.lastUsed.Load()})
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int { return cmp.Compare(a.lastUsed, b.lastUsed) })
+
+	var victims map[string]*ChatHistory
+	now := time.Now()
+	excess := len(sm.histories) - sm.maxSessions
+	for _, c := range candidates {
+		id, e := c.id, sm.histories[c.id]
+		idle := now.Sub(time.Unix(0, c.lastUsed))
+		overCap := sm.maxSessions > 0 && excess > 0
+		if id == keep || !(overCap || (sm.idleTTL > 0 && idle >= sm.idleTTL)) {
+			continue
+		}
+
+		if victims == nil {
+			victims = make(map[string]*ChatHistory)
+		}
+		victims[id] = e.history
+		delete(sm.histories, id)
+		sm.evicted[id] = weak.Make(e.history)
+		excess--
+		slog.Debug("Session evicted from memory", "session", id, "idle", idle.Round(time.Second))
+	}
+	return victims
+}
+
+// saveEvicted persists histories dropped by evictLocked. A history that
+// fails to save is put back into the cache, unless it was reused meanwhile,
+// so no messages are lost.
+func (sm *SessionManager) saveEvicted(victims map[string]*ChatHistory) {
+	for id, h := range victims {
+		err := sm.save(id, h)
+		if err == nil {
+			continue
+		}
+		slog.Warn("Failed to save session before eviction, keeping it in memory", "session", id, "error", err)
+		sm.mu.Lock()
+		if _, ok := sm.histories[id]; !ok {
+			e := &sessionEntry{history: h}
+			e.touch()
+			sm.histories[id] = e
+			delete(sm.evicted, id)
+		}
+		sm.mu.Unlock()
+	}
 }
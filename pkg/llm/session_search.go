@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// searchSnippetRadius is how many runes of context a search snippet keeps
+// on each side of the match.
+const searchSnippetRadius = 60
+
+// SearchResult is a message matching a Search query.
+type SearchResult struct {
+	SessionID string    // Session the message belongs to
+	MessageID string    // ID of the matching message
+	Role      string    // "user" or "assistant"
+	Time      time.Time // When the message was written
+	Snippet   string    // Excerpt around the first match
+}
+
+// Search finds user and assistant messages containing every word of query,
+// case-insensitively, in the sessions owned by userKey ("channel:user", see
+// SessionInfo.OwnedBy). Results are newest first and capped at limit when
+// positive. Sessions on disk are scanned without being cached.
+func (sm *SessionManager) Search(userKey, query string, limit int) ([]SearchResult, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var results []SearchResult
+	err := sm.eachSession(func(id string, h *ChatHistory) {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		if !(SessionInfo{ID: id, Participants: h.Participants}).OwnedBy(userKey) {
+			return
+		}
+		for i := range h.Messages {
+			m := &h.Messages[i]
+			if m.Role != "user" && m.Role != "assistant" {
+				continue
+			}
+			text := m.GetTextContent()
+			if snippet, ok := matchSnippet(text, terms); ok {
+				results = append(results, SearchResult{
+					SessionID: id,
+					MessageID: m.ID,
+					Role:      m.Role,
+					Time:      time.Unix(m.Timestamp, 0),
+					Snippet:   snippet,
+				})
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(results, func(a, b SearchResult) int { return cmp.Compare(b.Time.Unix(), a.Time.Unix()) })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// matchSnippet reports whether text contains all terms (already lowercased)
+// and returns the text around the first one, on a single line.
+func matchSnippet(text string, terms []string) (string, bool) {
+	lower := strings.ToLower(text)
+	for _, term := range terms {
+		if !strings.Contains(lower, term) {
+			return "", false
+		}
+	}
+
+	// Lowercasing can change byte lengths, so locate the match in runes
+	runes := []rune(text)
+	at := min(utf8.RuneCountInString(lower[:strings.Index(lower, terms[0])]), len(runes))
+	start := max(at-searchSnippetRadius, 0)
+	end := min(at+utf8.RuneCountInString(terms[0])+searchSnippetRadius, len(runes))
+
+	snippet := strings.Join(strings.Fields(string(runes[start:end])), " ")
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(runes) {
+		snippet += "..."
+	}
+	return snippet, true
+}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"html"
+	"strings"
+	"text/tabwriter"
+)
+
+// Table carries structured tabular data for "table" content blocks.
+// Channels render it natively (Markdown, HTML, aligned text); everything else
+// (LLM providers, history, monitors) uses the block's plain-text Text.
+type Table struct {
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+}
+
+// NewTableBlock creates a table block. Its Text holds the aligned plain-text
+// rendering so consumers that only understand text still see the data.
+func NewTableBlock(headers []string, rows [][]string) ContentBlock {
+	t := &Table{Headers: headers, Rows: rows}
+	return ContentBlock{
+		Type:  BlockTypeTable,
+		Text:  t.PlainText(),
+		Table: t,
+	}
+}
+
+// PlainText renders the table as space-aligned columns, one row per line.
+func (t *Table) PlainText() string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	tw.Write([]byte(strings.Join(cleanCells(t.Headers, "\t", " "), "\t") + "\n"))
+	for _, row := range t.Rows {
+		tw.Write([]byte(strings.Join(cleanCells(row, "\t", " "), "\t") + "\n"))
+	}
+	tw.Flush()
+	return sb.String()
+}
+
+// Markdown renders the table as a GitHub-flavored Markdown table.
+func (t *Table) Markdown() string {
+	var sb strings.Builder
+	writeRow := func(cells []string) {
+		sb.WriteString("| " + strings.Join(cleanCells(cells, "|", `\|`), " | ") + " |\n")
+	}
+	writeRow(t.Headers)
+	sep := make([]string, len(t.Headers))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeRow(sep)
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+	return sb.String()
+}
+
+// HTML renders the table as an escaped HTML <table> element.
+func (t *Table) HTML() string {
+	var sb strings.Builder
+	sb.WriteString("<table><thead><tr>")
+	for _, h := range t.Headers {
+		sb.WriteString("<th>" + html.EscapeString(h) + "</th>")
+	}
+	sb.WriteString("</tr></thead><tbody>")
+	for _, row := range t.Rows {
+		sb.WriteString("<tr>")
+		for _, cell := range row {
+			sb.WriteString("<td>" + html.EscapeString(cell) + "</td>")
+		}
+		sb.WriteString("</tr>")
+	}
+	sb.WriteString("</tbody></table>")
+	return sb.String()
+}
+
+// cleanCells flattens line breaks and escapes the format's column separator
+// so that each cell stays within its column.
+func cleanCells(cells []string, sep, escaped string) []string {
+	r := strings.NewReplacer("\r", " ", "\n", " ", sep, escaped)
+	out := make([]string, len(cells))
+	for i, c := range cells {
+		out[i] = r.Replace(c)
+	}
+	return out
+}
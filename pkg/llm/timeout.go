@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrFirstTokenTimeout reports that a stream produced no output within
+// SystemConfig.FirstTokenTimeoutMs. It is always considered retryable.
+var ErrFirstTokenTimeout = errors.New("no response from model within first-token timeout")
+
+// TimedClient is implemented by clients that carry their own stream
+// deadline, typically configured per provider group. A zero Timeout means
+// the client imposes no deadline at all.
+type TimedClient interface {
+	LLMClient
+	Timeout() time.Duration
+}
+
+// timedClient decorates an LLMClient with a per-provider stream deadline.
+type timedClient struct {
+	LLMClient
+	timeout time.Duration
+}
+
+// NewTimedClient wraps client so that every stream it serves is bounded by
+// timeout. A timeout of zero or less disables the deadline.
+func NewTimedClient(client LLMClient, timeout time.Duration) TimedClient {
+	return &timedClient{LLMClient: client, timeout: max(timeout, 0)}
+}
+
+// Timeout returns the stream deadline applied by this client.
+func (t *timedClient) Timeout() time.Duration {
+	return t.timeout
+}
+
+// Unwrap returns the decorated client.
+func (t *timedClient) Unwrap() LLMClient {
+	return t.LLMClient
+}
+
+// StreamChat starts the stream under the client's own deadline. The deadline
+// is released once the underlying stream has been fully consumed.
+func (t *timedClient) StreamChat(ctx context.Context, messages []Message, availableTools []Tool) (<-chan StreamChunk, error) {
+	if t.timeout <= 0 {
+		return t.LLMClient.StreamChat(ctx, messages, availableTools)
+	}
+
+	streamCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	ch, err := t.LLMClient.StreamChat(streamCtx, messages, availableTools)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan StreamChunk, cap(ch))
+	go func() {
+		defer cancel()
+		defer close(out)
+		for chunk := range ch {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// The caller stopped reading; keep draining so the producer can exit
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,35 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// KeywordModerator is a minimal local classifier that blocks any text
+// containing one of the configured keywords (case-insensitive).
+type KeywordModerator struct {
+	keywords []string // Lower-cased blocklist entries
+}
+
+// NewKeywordModerator creates a KeywordModerator from a blocklist.
+func NewKeywordModerator(keywords []string) *KeywordModerator {
+	lowered := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		if k = strings.TrimSpace(k); k != "" {
+			lowered = append(lowered, strings.ToLower(k))
+		}
+	}
+	return &KeywordModerator{keywords: lowered}
+}
+
+// Check implements api.Moderator.
+func (m *KeywordModerator) Check(ctx context.Context, text string) (bool, string) {
+	lower := strings.ToLower(text)
+	for _, k := range m.keywords {
+		if strings.Contains(lower, k) {
+			return false, fmt.Sprintf("blocked keyword %q", k)
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,24 @@
+package moderation
+
+import (
+	"fmt"
+	"genesis/pkg/api"
+	"genesis/pkg/config"
+)
+
+// NewFromConfig instantiates the Moderator selected by the configuration.
+// It returns a nil Moderator (and no error) when moderation is disabled.
+func NewFromConfig(cfg config.ModerationConfig) (api.Moderator, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Provider {
+	case "openai":
+		return NewOpenAIModerator(cfg.APIKey, cfg.BaseURL), nil
+	case "keywords", "":
+		return NewKeywordModerator(cfg.Keywords), nil
+	default:
+		return nil, fmt.Errorf("unknown moderation provider: %s", cfg.Provider)
+	}
+}
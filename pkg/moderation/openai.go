@@ -0,0 +1,63 @@
+package moderation
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	openai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// OpenAIModerator classifies content using the OpenAI moderation endpoint.
+type OpenAIModerator struct {
+	client openai.Client
+}
+
+// NewOpenAIModerator creates a moderator backed by the OpenAI moderation API.
+// If apiKey is empty, the OPENAI_API_KEY environment variable is used.
+func NewOpenAIModerator(apiKey string, baseURL string) *OpenAIModerator {
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	return &OpenAIModerator{client: openai.NewClient(opts...)}
+}
+
+// Check implements api.Moderator. Endpoint failures fail open (content is
+// allowed) so that a moderation outage doesn't take the whole bot down.
+func (m *OpenAIModerator) Check(ctx context.Context, text string) (bool, string) {
+	resp, err := m.client.Moderations.New(ctx, openai.ModerationNewParams{
+		Input: openai.ModerationNewParamsInputUnion{OfString: openai.String(text)},
+		Model: openai.ModerationModelOmniModerationLatest,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Moderation request failed, allowing content", "error", err)
+		return true, ""
+	}
+
+	for _, result := range resp.Results {
+		if !result.Flagged {
+			continue
+		}
+
+		// Collect the names of the flagged categories for the reason string
+		var categories map[string]bool
+		jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal([]byte(result.Categories.RawJSON()), &categories)
+		var flagged []string
+		for name, hit := range categories {
+			if hit {
+				flagged = append(flagged, name)
+			}
+		}
+		sort.Strings(flagged)
+		return false, "flagged: " + strings.Join(flagged, ", ")
+	}
+	return true, ""
+}
@@ -2,27 +2,125 @@ package monitor
 
 import (
 	"fmt"
+	"genesis/pkg/utils"
 	"io"
 	"os"
+	"regexp"
+	"strings"
 )
 
+// themeTimestamp is the CLIMonitor theme key of the timestamp column.
+const themeTimestamp = "TIMESTAMP"
+
+// defaultTheme holds the ANSI SGR codes CLIMonitor uses per message type.
+var defaultTheme = map[string]string{
+	themeTimestamp:       "90",
+	MessageTypeAssistant: "36",
+	MessageTypeTool:      "33",
+	MessageTypeSystem:    "35",
+	MessageTypeError:     "31",
+}
+
+// colorNames maps the color names accepted by SetColors to SGR codes.
+var colorNames = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+	"gray":    "90",
+	"grey":    "90",
+	"bold":    "1",
+	"none":    "",
+}
+
+// sgrRegex matches a raw ANSI SGR parameter list such as "1;32".
+var sgrRegex = regexp.MustCompile(`^[0-9]+(;[0-9]+)*$`)
+
 // CLIMonitor implements the Monitor interface, providing a direct
 // terminal-based visualization of messages flowing through all channels.
 type CLIMonitor struct {
-	writer io.Writer // The output destination, typically os.Stdout.
+	writer io.Writer         // The output destination, typically os.Stdout.
+	muted  map[string]bool   // Channel IDs whose messages are not printed
+	color  bool              // Whether output is colored
+	theme  map[string]string // SGR code per message type and for the timestamp
 }
 
 // NewCLIMonitor creates a new CLI monitor
 func NewCLIMonitor() *CLIMonitor {
-	return &CLIMonitor{
+	m := &CLIMonitor{
 		writer: os.Stdout,
 	}
+	m.SetColors("auto", nil)
+	return m
+}
+
+// SetColors selects when output is colored, "always", "never" or "auto"
+// (a terminal and no NO_COLOR environment variable), and overrides theme
+// colors per message type or "TIMESTAMP" with color names or SGR codes.
+// Unknown colors are reported and leave the default in place.
+func (m *CLIMonitor) SetColors(mode string, theme map[string]string) error {
+	switch mode {
+	case "always":
+		m.color = true
+	case "never":
+		m.color = false
+	default:
+		m.color = os.Getenv("NO_COLOR") == "" && isTerminal(m.writer)
+	}
+
+	m.theme = make(map[string]string, len(defaultTheme)+len(theme))
+	for k, v := range defaultTheme {
+		m.theme[k] = v
+	}
+	var invalid []string
+	for key, value := range theme {
+		code, ok := colorNames[strings.ToLower(value)]
+		if !ok && sgrRegex.MatchString(value) {
+			code, ok = value, true
+		}
+		if !ok {
+			invalid = append(invalid, key+"="+value)
+			continue
+		}
+		m.theme[strings.ToUpper(key)] = code
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("unknown monitor colors: %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// isTerminal reports whether w is a character device such as a terminal,
+// as opposed to a file or pipe.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// MuteChannels stops the monitor from printing messages of the given
+// channels, typically ones that write the conversation to the same terminal
+// themselves. It must be called before the monitor receives messages.
+func (m *CLIMonitor) MuteChannels(channelIDs ...string) {
+	if m.muted == nil {
+		m.muted = make(map[string]bool)
+	}
+	for _, id := range channelIDs {
+		m.muted[id] = true
+	}
 }
 
 // Start starts the CLI monitor
 func (m *CLIMonitor) Start() error {
 	fmt.Fprintln(m.writer, "----------------------------------------------------------------")
-	fmt.Fprintln(m.writer, "💬 CLI Monitor Active - All channel messages will appear here")
+	fmt.Fprintln(m.writer, utils.IconChat.String()+" CLI Monitor Active - All channel messages will appear here")
 	fmt.Fprintln(m.writer, "----------------------------------------------------------------")
 	return nil
 }
@@ -32,17 +130,45 @@ func (m *CLIMonitor) Stop() error {
 	return nil
 }
 
-// OnMessage receives and displays a monitoring message
+// OnMessage receives and displays a monitoring message. Replies arrive
+// whole, after the channel has finished streaming them.
 func (m *CLIMonitor) OnMessage(msg MonitorMessage) {
+	if m.muted[msg.ChannelID] {
+		return
+	}
 	timestamp := msg.Timestamp.Format("2006-01-02 15:04:05")
 
-	var displayMsg string
-	if msg.MessageType == "ASSISTANT" {
-		displayMsg = fmt.Sprintf("[AI] %s", msg.Content)
-	} else {
-		displayMsg = fmt.Sprintf("[%s/%s] %s", msg.ChannelID, msg.Username, msg.Content)
+	var tag string
+	switch msg.MessageType {
+	case MessageTypeAssistant:
+		tag = "[AI]"
+	case MessageTypeTool:
+		tag = label(utils.IconTool, "[TOOL]")
+	case MessageTypeSystem:
+		tag = label(utils.IconInfo, "[SYSTEM]")
+	case MessageTypeError:
+		tag = label(utils.IconError, "[ERROR]")
+	default:
+		tag = fmt.Sprintf("[%s/%s]", msg.ChannelID, msg.Username)
 	}
 
-	// Use gray color for timestamp
-	fmt.Fprintf(m.writer, "\033[90m[%s]\033[0m %s\n", timestamp, displayMsg)
+	fmt.Fprintf(m.writer, "%s %s %s\n", m.paint(themeTimestamp, "["+timestamp+"]"), m.paint(msg.MessageType, tag), msg.Content)
+}
+
+// paint colors text with the theme color of key, if output is colored.
+func (m *CLIMonitor) paint(key, text string) string {
+	code := m.theme[key]
+	if !m.color || code == "" {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+// label prefixes a message type tag with its emoji marker. In ASCII mode the
+// tag already serves as the marker, so it is shown on its own.
+func label(icon utils.Icon, tag string) string {
+	if utils.UseEmoji() {
+		return icon.Emoji() + " " + tag
+	}
+	return tag
 }
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Rotation policies supported by JSONLMonitor.
+const (
+	RotationDaily  = "daily"
+	RotationHourly = "hourly"
+	RotationNone   = "none"
+)
+
+// JSONLMonitor implements the Monitor interface by appending every message
+// as one JSON line to a file under dir, rotated according to rotation, for
+// later analysis or audit.
+type JSONLMonitor struct {
+	dir      string     // Directory where log files are written
+	rotation string     // One of the Rotation* policies
+	file     *os.File   // Currently open log file
+	path     string     // Path of the currently open log file
+	mu       sync.Mutex // Serializes writes and rotation
+}
+
+// NewJSONLMonitor creates a file monitor writing to dir. Unknown rotation
+// values fall back to daily rotation.
+func NewJSONLMonitor(dir, rotation string) *JSONLMonitor {
+	switch rotation {
+	case RotationDaily, RotationHourly, RotationNone:
+	default:
+		rotation = RotationDaily
+	}
+	return &JSONLMonitor{
+		dir:      dir,
+		rotation: rotation,
+	}
+}
+
+// Start ensures the output directory exists.
+func (m *JSONLMonitor) Start() error {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create monitor log directory: %w", err)
+	}
+	return nil
+}
+
+// Stop closes the current log file.
+func (m *JSONLMonitor) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.file != nil {
+		err := m.file.Close()
+		m.file = nil
+		m.path = ""
+		return err
+	}
+	return nil
+}
+
+// OnMessage appends the message to the current log file, rotating first if
+// the message falls into a new period.
+func (m *JSONLMonitor) OnMessage(msg MonitorMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("Failed to encode monitor message", "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := filepath.Join(m.dir, m.fileName(msg))
+	if path != m.path {
+		if m.file != nil {
+			m.file.Close()
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			slog.Error("Failed to open monitor log file", "path", path, "error", err)
+			m.file, m.path = nil, ""
+			return
+		}
+		m.file, m.path = f, path
+	}
+
+	if _, err := m.file.Write(append(data, '\n')); err != nil {
+		slog.Error("Failed to write monitor log", "path", path, "error", err)
+	}
+}
+
+// fileName returns the log file name for the period the message belongs to.
+func (m *JSONLMonitor) fileName(msg MonitorMessage) string {
+	switch m.rotation {
+	case RotationHourly:
+		return msg.Timestamp.Format("2006-01-02_15") + ".jsonl"
+	case RotationNone:
+		return "messages.jsonl"
+	default:
+		return msg.Timestamp.Format("2006-01-02") + ".jsonl"
+	}
+}
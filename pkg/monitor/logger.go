@@ -8,6 +8,8 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -103,27 +105,155 @@ func (h *CustomHandler) WithGroup(name string) slog.Handler {
 	return h
 }
 
-// SetupSlog initializes the global slog logger with the CustomHandler.
-func SetupSlog(levelStr string) {
-	var level slog.Level
+// logLevel is the process-wide minimum log level. It is shared by every
+// handler created by SetupSlog so it can be adjusted at runtime.
+var logLevel = new(slog.LevelVar)
+
+// ParseLevel converts a level name ("debug", "info", "warn", "error") into a
+// slog.Level. Unknown names map to info.
+func ParseLevel(levelStr string) slog.Level {
 	switch strings.ToLower(levelStr) {
 	case "debug":
-		level = slog.LevelDebug
+		return slog.LevelDebug
 	case "warn", "warning":
-		level = slog.LevelWarn
+		return slog.LevelWarn
 	case "error":
-		level = slog.LevelError
+		return slog.LevelError
 	default:
-		level = slog.LevelInfo
+		return slog.LevelInfo
 	}
+}
+
+// Temporary override state used by OverrideLogLevel. baseLevel is the
+// configured level that an override reverts to.
+var (
+	overrideMu    sync.Mutex
+	overrideTimer *time.Timer
+	baseLevel     slog.Level
+)
+
+// SetupSlog initializes the global slog logger with the CustomHandler.
+// Any temporary override is discarded.
+func SetupSlog(levelStr string) {
+	SetLogLevel(levelStr)
 
 	handler := NewCustomHandler(os.Stderr, slog.HandlerOptions{
-		Level: level,
+		Level: logLevel,
 	})
 
 	slog.SetDefault(slog.New(handler))
 }
 
+// SetLogLevel changes the configured minimum log level in place, without
+// replacing the handler or restarting any service. Any temporary override
+// is discarded.
+func SetLogLevel(levelStr string) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+
+	if overrideTimer != nil {
+		overrideTimer.Stop()
+		overrideTimer = nil
+	}
+	baseLevel = ParseLevel(levelStr)
+	logLevel.Set(baseLevel)
+}
+
+// OverrideLogLevel temporarily switches to the given level and reverts to
+// the configured level after d. A new override replaces the previous one.
+func OverrideLogLevel(levelStr string, d time.Duration) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+
+	if overrideTimer != nil {
+		overrideTimer.Stop()
+	}
+	logLevel.Set(ParseLevel(levelStr))
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		overrideMu.Lock()
+		defer overrideMu.Unlock()
+		// Ignore a stale timer that fired while being replaced
+		if overrideTimer != timer {
+			return
+		}
+		overrideTimer = nil
+		logLevel.Set(baseLevel)
+	})
+	overrideTimer = timer
+}
+
+// RevertLogLevel ends a temporary override immediately. It reports whether
+// an override was active.
+func RevertLogLevel() bool {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+
+	if overrideTimer == nil {
+		return false
+	}
+	overrideTimer.Stop()
+	overrideTimer = nil
+	logLevel.Set(baseLevel)
+	return true
+}
+
+// LogLevel returns the current minimum log level.
+func LogLevel() slog.Level {
+	return logLevel.Level()
+}
+
+// SamplingHandler wraps another slog.Handler and emits only 1 in every N
+// records below WARN. Counters are kept per log message so that a noisy
+// hot-path line (e.g., "Message received") doesn't starve rarer ones.
+// Warnings and errors are always emitted.
+type SamplingHandler struct {
+	inner    slog.Handler
+	rate     uint64
+	counters *sync.Map // map[string]*atomic.Uint64 keyed by record message
+}
+
+// NewSamplingHandler creates a SamplingHandler emitting 1 in rate records.
+func NewSamplingHandler(inner slog.Handler, rate int) *SamplingHandler {
+	return &SamplingHandler{
+		inner:    inner,
+		rate:     uint64(max(rate, 1)),
+		counters: &sync.Map{},
+	}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && h.rate > 1 {
+		val, _ := h.counters.LoadOrStore(r.Message, new(atomic.Uint64))
+		if (val.(*atomic.Uint64).Add(1)-1)%h.rate != 0 {
+			return nil
+		}
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{inner: h.inner.WithAttrs(attrs), rate: h.rate, counters: h.counters}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{inner: h.inner.WithGroup(name), rate: h.rate, counters: h.counters}
+}
+
+// EnableLogSampling wraps the current default slog handler with a
+// SamplingHandler. A rate of 1 or less leaves logging unchanged.
+func EnableLogSampling(rate int) {
+	if rate <= 1 {
+		return
+	}
+	slog.SetDefault(slog.New(NewSamplingHandler(slog.Default().Handler(), rate)))
+}
+
 // PrintBanner prints the startup banner
 func PrintBanner() {
 	banner := `
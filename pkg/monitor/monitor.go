@@ -2,15 +2,25 @@ package monitor
 
 import "time"
 
+// Message types carried by MonitorMessage.MessageType.
+const (
+	MessageTypeUser      = "USER"      // Inbound user message
+	MessageTypeAssistant = "ASSISTANT" // Assistant reply text
+	MessageTypeTool      = "TOOL"      // Tool execution output
+	MessageTypeSystem    = "SYSTEM"    // System notices (retries, command feedback, refusals)
+	MessageTypeError     = "ERROR"     // Errors reported to the user
+)
+
 // MonitorMessage represents a standardized data packet for system observability.
 // It is broadcasted by the Gateway whenever a user or assistant message is
 // processed, allowing different monitors (CLI, Web, Log) to display or save it.
 type MonitorMessage struct {
-	Timestamp   time.Time // Precision recording of when the event occurred
-	MessageType string    // Identity of the sender: "USER" or "ASSISTANT"
-	ChannelID   string    // Source platform ID (e.g., "telegram", "web")
-	Username    string    // Display name of the participant
-	Content     string    // Standardized text content of the message
+	Timestamp   time.Time `json:"timestamp"`    // Precision recording of when the event occurred
+	MessageType string    `json:"message_type"` // Kind of message, one of the MessageType* constants
+	ChannelID   string    `json:"channel_id"`   // Source platform ID (e.g., "telegram", "web")
+	UserID      string    `json:"user_id"`      // Platform-specific ID of the participant
+	Username    string    `json:"username"`     // Display name of the participant
+	Content     string    `json:"content"`      // Standardized text content of the message
 }
 
 // Monitor defines the lifecycle and message consumption protocol for
@@ -31,7 +41,7 @@ type Monitor interface {
 // SetupEnvironment encapsulates the initialization of the system logging
 // environment and the creation of a default CLI monitor instance.
 // logLevel controls the minimum severity for slog output (e.g., "debug", "info").
-func SetupEnvironment(logLevel string) Monitor {
+func SetupEnvironment(logLevel string) *CLIMonitor {
 	// Print ASCII banner before logger takes over stderr
 	PrintBanner()
 	// Initialize global slog logger
@@ -0,0 +1,62 @@
+package monitor
+
+import "errors"
+
+// MultiMonitor fans every message out to several Monitor implementations,
+// allowing e.g. the CLI view and a web dashboard to run side by side.
+type MultiMonitor struct {
+	monitors []Monitor // Underlying monitors, in registration order
+}
+
+// NewMultiMonitor creates a monitor that broadcasts to all given monitors.
+func NewMultiMonitor(monitors ...Monitor) *MultiMonitor {
+	return &MultiMonitor{monitors: monitors}
+}
+
+// Start starts every underlying monitor, stopping at the first failure.
+func (m *MultiMonitor) Start() error {
+	for _, mon := range m.monitors {
+		if err := mon.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop stops every underlying monitor and returns the combined errors.
+func (m *MultiMonitor) Stop() error {
+	var errs []error
+	for _, mon := range m.monitors {
+		if err := mon.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OnMessage forwards the message to every underlying monitor.
+func (m *MultiMonitor) OnMessage(msg MonitorMessage) {
+	for _, mon := range m.monitors {
+		mon.OnMessage(msg)
+	}
+}
+
+// FilteredMonitor passes only the messages accepted by a predicate on to
+// another monitor, so one filter serves every monitor implementation.
+type FilteredMonitor struct {
+	Monitor                           // Underlying monitor
+	allow   func(MonitorMessage) bool // Reports whether a message is forwarded
+}
+
+// NewFilteredMonitor wraps m so that it only receives messages for which
+// allow returns true.
+func NewFilteredMonitor(m Monitor, allow func(MonitorMessage) bool) *FilteredMonitor {
+	return &FilteredMonitor{Monitor: m, allow: allow}
+}
+
+// OnMessage forwards the message if the filter accepts it.
+func (m *FilteredMonitor) OnMessage(msg MonitorMessage) {
+	if m.allow(msg) {
+		m.Monitor.OnMessage(msg)
+	}
+}
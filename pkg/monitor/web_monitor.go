@@ -0,0 +1,297 @@
+package monitor
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// webMonitorBacklog is the number of recent messages replayed to a dashboard
+// when it connects, so the feed is not empty on first load.
+const webMonitorBacklog = 200
+
+// WebMonitor implements the Monitor interface by serving an HTML dashboard
+// with a live feed of all messages, pushed to browsers via Server-Sent Events.
+// The feed shows every user's conversation, so it listens on the loopback
+// interface unless configured otherwise and can require a token.
+type WebMonitor struct {
+	host        string                           // Interface the dashboard listens on; empty for all
+	port        int                              // HTTP port of the dashboard
+	token       string                           // Token required for the dashboard and feed; empty for none
+	server      *http.Server                     // Underlying HTTP server
+	backlog     []MonitorMessage                 // Ring of the most recent messages
+	subscribers map[chan MonitorMessage]struct{} // Connected SSE clients
+	health      HealthFunc                       // Source of the /health report, if set
+	mu          sync.Mutex                       // Protects backlog, subscribers and health
+}
+
+// HealthFunc returns a JSON-serializable health report and whether the
+// system is fully healthy.
+type HealthFunc func() (report any, healthy bool)
+
+// SetHealthSource enables the /health endpoint, which serves the report
+// from fn with status 200 when healthy and 503 otherwise.
+func (m *WebMonitor) SetHealthSource(fn HealthFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health = fn
+}
+
+// NewWebMonitor creates a dashboard monitor listening on host and port.
+// A non-empty token must be presented as "?token=" or a bearer token to see
+// the dashboard; /health stays open for probes.
+func NewWebMonitor(host string, port int, token string) *WebMonitor {
+	return &WebMonitor{
+		host:        host,
+		port:        port,
+		token:       token,
+		subscribers: make(map[chan MonitorMessage]struct{}),
+	}
+}
+
+// Start binds the dashboard port and serves it in the background. A port
+// that cannot be bound is reported as an error.
+func (m *WebMonitor) Start() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", m.authorized(m.handleIndex))
+	mux.HandleFunc("/events", m.authorized(m.handleEvents))
+	mux.HandleFunc("/health", m.handleHealth)
+
+	m.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)))
+	if err != nil {
+		return fmt.Errorf("web monitor listen failed: %w", err)
+	}
+
+	go func() {
+		if err := m.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Web monitor server error", "error", err)
+		}
+	}()
+
+	slog.Info("Web monitor listening", "addr", ln.Addr().String(), "token", m.token != "")
+	return nil
+}
+
+// authorized wraps a handler so it is served only to requests carrying the
+// configured token, if any.
+func (m *WebMonitor) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if m.token != "" {
+			got := r.URL.Query().Get("token")
+			if got == "" {
+				got = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(m.token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// Stop shuts down the dashboard and disconnects all clients.
+func (m *WebMonitor) Stop() error {
+	if m.server != nil {
+		return m.server.Close()
+	}
+	return nil
+}
+
+// OnMessage records the message and pushes it to every connected dashboard.
+// Slow clients drop messages instead of blocking the gateway.
+func (m *WebMonitor) OnMessage(msg MonitorMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.backlog = append(m.backlog, msg)
+	if len(m.backlog) > webMonitorBacklog {
+		m.backlog = m.backlog[len(m.backlog)-webMonitorBacklog:]
+	}
+
+	for ch := range m.subscribers {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// handleIndex serves the dashboard page.
+func (m *WebMonitor) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(dashboardHTML))
+}
+
+// handleHealth serves the health report as JSON.
+func (m *WebMonitor) handleHealth(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	fn := m.health
+	m.mu.Unlock()
+	if fn == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	report, healthy := fn()
+	status := "ok"
+	code := http.StatusOK
+	if !healthy {
+		status = "degraded"
+		code = http.StatusServiceUnavailable
+	}
+
+	data, err := json.Marshal(map[string]any{"status": status, "channels": report})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+// handleEvents streams the backlog followed by live messages as SSE.
+func (m *WebMonitor) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan MonitorMessage, 64)
+	m.mu.Lock()
+	backlog := append([]MonitorMessage(nil), m.backlog...)
+	m.subscribers[ch] = struct{}{}
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.subscribers, ch)
+		m.mu.Unlock()
+	}()
+
+	for _, msg := range backlog {
+		writeEvent(w, msg)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg := <-ch:
+			writeEvent(w, msg)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent encodes a single message as an SSE data frame.
+func writeEvent(w http.ResponseWriter, msg MonitorMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// dashboardHTML is the self-contained dashboard page. Filtering and stats
+// are computed client-side from the event feed.
+const dashboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Genesis Monitor</title>
+<style>
+  body { font-family: monospace; margin: 0; background: #111; color: #ddd; }
+  header { padding: 8px 12px; background: #222; display: flex; gap: 12px; align-items: center; flex-wrap: wrap; }
+  header input { background: #111; color: #ddd; border: 1px solid #444; padding: 4px; }
+  #stats span { margin-right: 12px; }
+  #feed { padding: 8px 12px; }
+  .row { padding: 2px 0; white-space: pre-wrap; border-bottom: 1px solid #1c1c1c; }
+  .ts { color: #777; }
+  .USER { color: #ddd; } .ASSISTANT { color: #5fd7d7; } .TOOL { color: #d7af5f; }
+  .SYSTEM { color: #d787d7; } .ERROR { color: #ff5f5f; }
+</style>
+</head>
+<body>
+<header>
+  <strong>Genesis Monitor</strong>
+  <input id="channel" placeholder="filter channel">
+  <input id="user" placeholder="filter user">
+  <div id="stats"></div>
+</header>
+<div id="feed"></div>
+<script>
+  const feed = document.getElementById('feed');
+  const stats = {};
+  const channels = {};
+  const chFilter = document.getElementById('channel');
+  const userFilter = document.getElementById('user');
+
+  function visible(el) {
+    const ch = chFilter.value.trim(), u = userFilter.value.trim();
+    return (!ch || el.dataset.channel.includes(ch)) && (!u || el.dataset.user.includes(u));
+  }
+  function refilter() {
+    for (const el of feed.children) el.style.display = visible(el) ? '' : 'none';
+  }
+  function renderStats() {
+    const parts = Object.entries(stats).map(([k, v]) => '<span>' + k + ': ' + v + '</span>');
+    parts.push('<span>channels: ' + Object.keys(channels).length + '</span>');
+    document.getElementById('stats').innerHTML = parts.join('');
+  }
+  chFilter.oninput = refilter;
+  userFilter.oninput = refilter;
+
+  const es = new EventSource('/events' + location.search);
+  es.onmessage = (e) => {
+    const m = JSON.parse(e.data);
+    stats[m.message_type] = (stats[m.message_type] || 0) + 1;
+    channels[m.channel_id] = true;
+    const el = document.createElement('div');
+    el.className = 'row ' + m.message_type;
+    el.dataset.channel = m.channel_id || '';
+    el.dataset.user = m.username || '';
+    const ts = new Date(m.timestamp).toLocaleString();
+    el.innerHTML = '<span class="ts">[' + ts + ']</span> ';
+    el.appendChild(document.createTextNode('[' + m.message_type + '] [' + m.channel_id + '/' + m.username + '] ' + m.content));
+    el.style.display = visible(el) ? '' : 'none';
+    feed.prepend(el);
+    renderStats();
+  };
+</script>
+</body>
+</html>
+`
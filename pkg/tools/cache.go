@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"genesis/pkg/api"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// cacheEntry is a single stored tool result along with its expiry time.
+type cacheEntry struct {
+	result  *ToolResult
+	expires time.Time
+}
+
+// ToolCache stores recent results of cacheable tools, keyed by session scope,
+// tool name and normalized arguments, so repeated deterministic calls can be
+// answered without executing the tool again.
+type ToolCache struct {
+	mu      sync.Mutex            // Protects concurrent access to the entries map
+	ttl     time.Duration         // Lifetime of a cached result
+	entries map[string]cacheEntry // Cached results indexed by cache key
+}
+
+// NewToolCache creates a cache whose entries expire after ttl.
+func NewToolCache(ttl time.Duration) *ToolCache {
+	return &ToolCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// IsCacheable reports whether a tool has opted into result caching.
+func IsCacheable(t Tool) bool {
+	ct, ok := t.(api.CacheableTool)
+	return ok && ct.Cacheable()
+}
+
+// Get returns a cached result for the given scope, tool and arguments, if
+// one exists and has not expired.
+func (c *ToolCache) Get(scope, name string, args map[string]any) (*ToolResult, bool) {
+	key, ok := cacheKey(scope, name, args)
+	if !ok {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// Put stores a result and opportunistically evicts expired entries.
+func (c *ToolCache) Put(scope, name string, args map[string]any, result *ToolResult) {
+	key, ok := cacheKey(scope, name, args)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{result: result, expires: now.Add(c.ttl)}
+}
+
+// cacheKey builds a stable key from the arguments. Map keys are sorted
+// during encoding, so equivalent argument maps produce the same key.
+func cacheKey(scope, name string, args map[string]any) (string, bool) {
+	data, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	return scope + "\x00" + name + "\x00" + string(data), true
+}
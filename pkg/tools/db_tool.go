@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"genesis/pkg/llm"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the pure-Go "sqlite" database/sql driver
+)
+
+// DefaultDBDriver is the database/sql driver used when none is configured.
+// It needs no cgo, so it also works in cross-compiled builds.
+const DefaultDBDriver = "sqlite"
+
+// legacySQLiteDriver is the name of the former cgo SQLite driver, accepted
+// as an alias of DefaultDBDriver so existing configurations keep working.
+const legacySQLiteDriver = "sqlite3"
+
+// readOnlyPrefixes are the statement keywords accepted in read-only mode.
+// Matching them only gives an early, readable error; read-only mode is
+// enforced by the connection itself (see sqliteReadOnlyDSN).
+var readOnlyPrefixes = []string{"select", "with", "explain"}
+
+// DBOptions configures the database query tool.
+type DBOptions struct {
+	Driver      string        // database/sql driver name (default "sqlite")
+	DSN         string        // Data source name, e.g., a SQLite file path
+	MaxRows     int           // Maximum rows returned per query; 0 means unlimited
+	AllowWrites bool          // Permit statements other than SELECT/WITH/EXPLAIN
+	Timeout     time.Duration // Deadline for a single query; 0 means none
+}
+
+// DBTool implements the Tool interface to run SQL statements against a
+// configured database and return the rows as a table block.
+// It keeps a connection pool open for its lifetime; call Close on shutdown.
+type DBTool struct {
+	opts DBOptions
+	db   *sql.DB
+}
+
+// NewDBTool opens the database and verifies the connection, so an invalid
+// DSN fails at startup rather than on the first query. Read-only mode is
+// only available for SQLite, whose connections are then opened read-only
+// and with query_only set, so no statement can modify the database.
+func NewDBTool(opts DBOptions) (*DBTool, error) {
+	if opts.Driver == "" || opts.Driver == legacySQLiteDriver {
+		opts.Driver = DefaultDBDriver
+	}
+	if opts.DSN == "" {
+		return nil, fmt.Errorf("database DSN is empty")
+	}
+
+	dsn := opts.DSN
+	if !opts.AllowWrites {
+		if opts.Driver != DefaultDBDriver {
+			return nil, fmt.Errorf("read-only mode is not supported for driver %q; only %q connections can be made read-only", opts.Driver, DefaultDBDriver)
+		}
+		dsn = sqliteReadOnlyDSN(dsn)
+	}
+
+	db, err := sql.Open(opts.Driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if !opts.AllowWrites {
+		var queryOnly bool
+		if err := db.QueryRow("PRAGMA query_only").Scan(&queryOnly); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to check read-only mode: %w", err)
+		}
+		if !queryOnly {
+			db.Close()
+			return nil, fmt.Errorf("failed to open the database read-only")
+		}
+	}
+	return &DBTool{opts: opts, db: db}, nil
+}
+
+// Close releases all database connections.
+func (t *DBTool) Close() error {
+	return t.db.Close()
+}
+
+func (t *DBTool) Name() string {
+	return "database"
+}
+
+func (t *DBTool) Description() string {
+	desc := fmt.Sprintf("Query the configured %s database. Supported actions: 'query' (run a SQL statement and return the rows as a table).", t.opts.Driver)
+	if !t.opts.AllowWrites {
+		desc += " The database is read-only: only SELECT, WITH and EXPLAIN statements are accepted."
+	}
+	if t.opts.MaxRows > 0 {
+		desc += fmt.Sprintf(" At most %d rows are returned.", t.opts.MaxRows)
+	}
+	return desc
+}
+
+func (t *DBTool) Parameters() map[string]any {
+	return map[string]any{
+		"action": map[string]any{
+			"type":        "string",
+			"description": "Name of the action to execute",
+			"enum":        []string{"query"},
+		},
+		"sql": map[string]any{
+			"type":        "string",
+			"description": "A single SQL statement to execute",
+		},
+	}
+}
+
+func (t *DBTool) RequiredParameters() []string {
+	return []string{"action", "sql"}
+}
+
+func (t *DBTool) Execute(ctx context.Context, args map[string]any) (*ToolResult, error) {
+	if action, _ := args["action"].(string); action != "query" {
+		return nil, fmt.Errorf("unsupported action: %v", args["action"])
+	}
+	stmt, _ := args["sql"].(string)
+	stmt = strings.TrimSpace(stmt)
+	if stmt == "" {
+		return nil, fmt.Errorf("missing or invalid 'sql' parameter")
+	}
+	if err := t.checkStatement(stmt); err != nil {
+		return nil, err
+	}
+
+	if t.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.opts.Timeout)
+		defer cancel()
+	}
+
+	rows, err := t.db.QueryContext(ctx, stmt)
+	if err != nil {
+		return &ToolResult{
+			Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Query failed: %v", err)}},
+			Details: map[string]any{"success": false, "error": err.Error()},
+		}, nil
+	}
+	defer rows.Close()
+
+	headers, records, truncated, err := t.collectRows(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	count := len(records)
+
+	summary := fmt.Sprintf("(%d rows)", count)
+	if truncated {
+		summary = fmt.Sprintf("(showing the first %d rows)", count)
+	}
+
+	return &ToolResult{
+		Content: []ContentBlock{
+			{Type: llm.BlockTypeTable, Table: &llm.Table{Headers: headers, Rows: records}},
+			{Type: "text", Text: summary},
+		},
+		Details: map[string]any{
+			"success":   true,
+			"rows":      count,
+			"truncated": truncated,
+		},
+	}, nil
+}
+
+// checkStatement rejects multi-statement input and, in read-only mode,
+// anything that is not a query.
+func (t *DBTool) checkStatement(stmt string) error {
+	if strings.Contains(strings.TrimRight(stmt, "; \t\n"), ";") {
+		return fmt.Errorf("only a single SQL statement is allowed")
+	}
+	if t.opts.AllowWrites {
+		return nil
+	}
+	lower := strings.ToLower(stmt)
+	for _, prefix := range readOnlyPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("statement rejected: the database tool is read-only (SELECT, WITH and EXPLAIN only)")
+}
+
+// collectRows reads up to MaxRows rows as display strings.
+func (t *DBTool) collectRows(rows *sql.Rows) ([]string, [][]string, bool, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	values := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	var records [][]string
+	truncated := false
+	for rows.Next() {
+		if t.opts.MaxRows > 0 && len(records) >= t.opts.MaxRows {
+			truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, false, err
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = formatCell(v)
+		}
+		records = append(records, cells)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, false, err
+	}
+	return cols, records, truncated, nil
+}
+
+// formatCell converts a scanned value to display text.
+func formatCell(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// sqliteReadOnlyDSN makes every connection opened with a SQLite DSN
+// read-only: the file is opened with mode=ro and each connection sets
+// query_only. Any mode or query_only parameter already in the DSN is
+// replaced, except mode=memory, which names an in-memory database.
+func sqliteReadOnlyDSN(dsn string) string {
+	path, query, _ := strings.Cut(dsn, "?")
+
+	params := []string{"_pragma=query_only(1)"}
+	memory := path == ":memory:" || path == "file::memory:"
+	for _, param := range strings.Split(query, "&") {
+		lower := strings.ToLower(param)
+		switch {
+		case param == "":
+		case lower == "mode=memory":
+			memory = true
+			params = append(params, param)
+		case strings.HasPrefix(lower, "mode="), strings.HasPrefix(lower, "_pragma=query_only"):
+		default:
+			params = append(params, param)
+		}
+	}
+	if !memory {
+		params = append(params, "mode=ro")
+	}
+
+	if path != ":memory:" && !strings.HasPrefix(path, "file:") {
+		path = "file:" + path
+	}
+	return path + "?" + strings.Join(params, "&")
+}
@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestDBToolReadOnlyRejectsWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	rw, err := NewDBTool(DBOptions{DSN: path, AllowWrites: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{"CREATE TABLE t (x INTEGER)", "INSERT INTO t VALUES (1)"} {
+		if res, err := rw.Execute(context.Background(), map[string]any{"action": "query", "sql": stmt}); err != nil || res.Details["success"] != true {
+			t.Fatalf("%s: %v %v", stmt, err, res)
+		}
+	}
+	rw.Close()
+
+	// A mode already in the DSN must not reopen the database for writing
+	for _, dsn := range []string{path, "file:" + path + "?mode=rwc"} {
+		ro, err := NewDBTool(DBOptions{DSN: dsn})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		res, err := ro.Execute(ctx, map[string]any{"action": "query", "sql": "WITH x AS (SELECT 1) DELETE FROM t"})
+		if err != nil || res.Details["success"] != false {
+			t.Fatalf("%s: write through a CTE was not rejected: %v %v", dsn, err, res)
+		}
+		res, err = ro.Execute(ctx, map[string]any{"action": "query", "sql": "SELECT count(*) FROM t"})
+		if err != nil || res.Details["rows"] != 1 || res.Content[0].Table.Rows[0][0] != "1" {
+			t.Fatalf("%s: read failed: %v %v", dsn, err, res)
+		}
+		ro.Close()
+	}
+}
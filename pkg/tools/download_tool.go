@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errBlockedAddress is returned when a download (or one of its redirects)
+// resolves to a loopback, private or otherwise non-public address.
+var errBlockedAddress = errors.New("destination address is not allowed")
+
+// cgnatRange is the carrier-grade NAT block (RFC 6598), which net.IP does
+// not classify as private but is not publicly routable either.
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// downloadProgressStep is how many bytes are written between progress updates.
+const downloadProgressStep = 1 << 20
+
+// DownloadOptions configures the download tool.
+type DownloadOptions struct {
+	Dir         string        // Directory that receives downloaded files
+	MaxBytes    int64         // Maximum accepted file size; 0 means unlimited
+	AllowedMIME []string      // Accepted MIME types or prefixes (e.g., "text/"); empty accepts all
+	Timeout     time.Duration // Deadline for a single download; 0 means none
+}
+
+// DownloadTool implements api.StreamingTool to fetch a URL into a local
+// directory. Requests are restricted to public http(s) addresses so the model
+// cannot reach internal services (SSRF), and responses are bounded by size
+// and MIME type.
+type DownloadTool struct {
+	opts   DownloadOptions
+	client *http.Client
+}
+
+// NewDownloadTool creates a download tool saving into opts.Dir.
+func NewDownloadTool(opts DownloadOptions) *DownloadTool {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// Control runs after DNS resolution, so every connection, including
+		// those made for redirects, is checked against the resolved IP
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", errBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+
+	return &DownloadTool{
+		opts: opts,
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return fmt.Errorf("stopped after %d redirects", len(via))
+				}
+				return checkDownloadURL(req.URL)
+			},
+		},
+	}
+}
+
+func (t *DownloadTool) Name() string {
+	return "download"
+}
+
+func (t *DownloadTool) Description() string {
+	desc := "Download a file from a public http(s) URL and save it to disk. Returns the saved path and size."
+	if t.opts.MaxBytes > 0 {
+		desc += fmt.Sprintf(" Files larger than %d bytes are rejected.", t.opts.MaxBytes)
+	}
+	if len(t.opts.AllowedMIME) > 0 {
+		desc += " Allowed content types: " + strings.Join(t.opts.AllowedMIME, ", ") + "."
+	}
+	return desc
+}
+
+func (t *DownloadTool) Parameters() map[string]any {
+	return map[string]any{
+		"url": map[string]any{
+			"type":        "string",
+			"description": "The http(s) URL to download",
+		},
+		"filename": map[string]any{
+			"type":        "string",
+			"description": "Optional file name to save as (defaults to the last URL path segment)",
+		},
+	}
+}
+
+func (t *DownloadTool) RequiredParameters() []string {
+	return []string{"url"}
+}
+
+// Execute downloads the file without reporting progress.
+func (t *DownloadTool) Execute(ctx context.Context, args map[string]any) (*ToolResult, error) {
+	return t.ExecuteWithProgress(ctx, args, nil)
+}
+
+// ExecuteWithProgress downloads the file, reporting the byte count every MiB.
+func (t *DownloadTool) ExecuteWithProgress(ctx context.Context, args map[string]any, progress chan<- Progress) (*ToolResult, error) {
+	rawURL, _ := args["url"].(string)
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || rawURL == "" {
+		return nil, fmt.Errorf("missing or invalid 'url' parameter")
+	}
+	if err := checkDownloadURL(u); err != nil {
+		return nil, err
+	}
+
+	name, _ := args["filename"].(string)
+	name = sanitizeFilename(name)
+	if name == "" {
+		name = sanitizeFilename(filepath.Base(u.Path))
+	}
+	if name == "" {
+		name = "download"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: HTTP %d", resp.StatusCode)
+	}
+	if t.opts.MaxBytes > 0 && resp.ContentLength > t.opts.MaxBytes {
+		return nil, fmt.Errorf("file too large: %d bytes exceeds the %d byte limit", resp.ContentLength, t.opts.MaxBytes)
+	}
+
+	// Determine the MIME type from the header, sniffing the body if absent
+	body := bufio.NewReader(resp.Body)
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mimeType == "" {
+		head, _ := body.Peek(512)
+		mimeType, _, _ = mime.ParseMediaType(http.DetectContentType(head))
+	}
+	if !t.mimeAllowed(mimeType) {
+		return nil, fmt.Errorf("content type %q is not allowed", mimeType)
+	}
+
+	if err := os.MkdirAll(t.opts.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	f, err := os.CreateTemp(t.opts.Dir, ".download-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	var src io.Reader = body
+	if t.opts.MaxBytes > 0 {
+		src = io.LimitReader(body, t.opts.MaxBytes+1)
+	}
+	pw := &progressWriter{w: f, name: name, total: resp.ContentLength, progress: progress}
+	n, err := io.Copy(pw, src)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save download: %w", err)
+	}
+	if t.opts.MaxBytes > 0 && n > t.opts.MaxBytes {
+		return nil, fmt.Errorf("file too large: exceeds the %d byte limit", t.opts.MaxBytes)
+	}
+
+	path := uniquePath(filepath.Join(t.opts.Dir, name))
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("failed to save download: %w", err)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	return &ToolResult{
+		Content: []ContentBlock{
+			{Type: "text", Text: fmt.Sprintf("Saved %s (%d bytes, %s)", path, n, mimeType)},
+		},
+		Details: map[string]any{
+			"path":      path,
+			"size":      n,
+			"mime_type": mimeType,
+		},
+	}, nil
+}
+
+// mimeAllowed reports whether mimeType matches the allowlist. Entries ending
+// in "/" match a whole family (e.g., "text/").
+func (t *DownloadTool) mimeAllowed(mimeType string) bool {
+	if len(t.opts.AllowedMIME) == 0 {
+		return true
+	}
+	for _, allowed := range t.opts.AllowedMIME {
+		allowed = strings.ToLower(allowed)
+		if mimeType == allowed || (strings.HasSuffix(allowed, "/") && strings.HasPrefix(mimeType, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDownloadURL rejects non-http(s) URLs and literal non-public IPs.
+// Host names are checked again after resolution by the dialer.
+func checkDownloadURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme: %q", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("URL has no host")
+	}
+	if strings.EqualFold(host, "localhost") {
+		return fmt.Errorf("%w: %s", errBlockedAddress, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", errBlockedAddress, host)
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address.
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || cgnatRange.Contains(ip))
+}
+
+// sanitizeFilename reduces name to a plain file name, discarding any
+// directory components so the download cannot escape its directory.
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if name == "." || name == ".." || name == "/" || strings.HasPrefix(name, ".") {
+		return ""
+	}
+	return name
+}
+
+// uniquePath appends "-1", "-2", ... before the extension until the path
+// does not exist, so earlier downloads are never overwritten.
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", base, i, ext)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// progressWriter counts written bytes and emits a Progress update every
+// downloadProgressStep bytes.
+type progressWriter struct {
+	w        io.Writer
+	name     string
+	total    int64
+	written  int64
+	next     int64
+	progress chan<- Progress
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.progress != nil && p.written >= p.next {
+		p.next = p.written + downloadProgressStep
+		msg := fmt.Sprintf("Downloading %s: %d KiB", p.name, p.written/1024)
+		if p.total > 0 {
+			msg = fmt.Sprintf("Downloading %s: %d/%d KiB", p.name, p.written/1024, p.total/1024)
+		}
+		p.progress <- Progress{Message: msg}
+	}
+	return n, err
+}
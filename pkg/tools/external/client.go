@@ -0,0 +1,333 @@
+// Package external runs tool providers as subprocesses and exposes their
+// tools to the agent. Providers speak the tools subset of the Model Context
+// Protocol over stdio: newline-delimited JSON-RPC 2.0 messages on stdin and
+// stdout, with "initialize", "tools/list" and "tools/call" requests.
+// Anything a provider writes to stderr is logged.
+package external
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// protocolVersion is the MCP revision announced during initialization.
+const protocolVersion = "2025-06-18"
+
+// closeTimeout is how long Close waits for a provider to exit after its
+// stdin is closed before killing it.
+const closeTimeout = 3 * time.Second
+
+// initTimeout bounds the handshake with a newly started provider.
+const initTimeout = 30 * time.Second
+
+// ErrClosed is returned by calls made after Close.
+var ErrClosed = errors.New("external tool provider is closed")
+
+// Options configures an external tool provider process.
+type Options struct {
+	Command string            // Executable to run, looked up in PATH
+	Args    []string          // Command-line arguments
+	Env     map[string]string // Variables added to the current environment
+	Dir     string            // Working directory; empty means the current one
+	Timeout time.Duration     // Bound on a single tool call; 0 means none
+}
+
+// rpcError is the error object of a JSON-RPC response.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// message is any JSON-RPC message: a request (Method and ID), a notification
+// (Method only) or a response (ID with Result or Error).
+type message struct {
+	JSONRPC string              `json:"jsonrpc"`
+	ID      jsoniter.RawMessage `json:"id,omitempty"`
+	Method  string              `json:"method,omitempty"`
+	Params  any                 `json:"params,omitempty"`
+	Result  jsoniter.RawMessage `json:"result,omitempty"`
+	Error   *rpcError           `json:"error,omitempty"`
+}
+
+// Client manages one provider process. The process is started by Start and
+// restarted on the next call if it exits; Close stops it for good.
+// A Client is safe for concurrent use.
+type Client struct {
+	opts   Options
+	nextID atomic.Int64
+
+	mu     sync.Mutex // Guards proc and closed
+	proc   *process
+	closed bool
+}
+
+// process is a single run of the provider.
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex // Serializes writes to stdin
+
+	pendingMu sync.Mutex
+	pending   map[int64]chan *message // Response channels by request ID
+
+	done chan struct{} // Closed once the process has exited
+	err  error         // Why the process exited; set before done is closed
+}
+
+// Start launches the provider and performs the protocol handshake, so a
+// misconfigured command fails at startup rather than on the first call.
+func Start(ctx context.Context, opts Options) (*Client, error) {
+	if opts.Command == "" {
+		return nil, fmt.Errorf("external tool command is empty")
+	}
+	c := &Client{opts: opts}
+	if _, err := c.current(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close stops the provider: it closes its stdin, the conventional shutdown
+// signal for stdio servers, and kills it if it has not exited in time.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	p := c.proc
+	c.proc = nil
+	c.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	p.stdin.Close()
+	select {
+	case <-p.done:
+	case <-time.After(closeTimeout):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+	return nil
+}
+
+// String identifies the provider in logs and errors.
+func (c *Client) String() string {
+	return c.opts.Command
+}
+
+// current returns the running process, starting a new one if there is none
+// or the previous one has exited.
+func (c *Client) current(ctx context.Context) (*process, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	if c.proc != nil {
+		select {
+		case <-c.proc.done:
+			slog.Warn("External tool provider exited, restarting", "command", c.opts.Command, "error", c.proc.err)
+		default:
+			return c.proc, nil
+		}
+	}
+
+	p, err := c.spawn()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.initialize(ctx, p); err != nil {
+		p.cmd.Process.Kill()
+		<-p.done
+		return nil, fmt.Errorf("external tool %s: initialization failed: %w", c.opts.Command, err)
+	}
+	c.proc = p
+	return p, nil
+}
+
+// spawn starts the provider process and its stdout and stderr readers.
+func (c *Client) spawn() (*process, error) {
+	cmd := exec.Command(c.opts.Command, c.opts.Args...)
+	cmd.Dir = c.opts.Dir
+	if len(c.opts.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range c.opts.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start external tool %s: %w", c.opts.Command, err)
+	}
+
+	p := &process{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan *message),
+		done:    make(chan struct{}),
+	}
+	go c.logStderr(stderr)
+	go func() {
+		p.read(stdout)
+		p.err = cmd.Wait()
+		if p.err == nil {
+			p.err = errors.New("process exited")
+		}
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// logStderr forwards the provider's diagnostic output to the log.
+func (c *Client) logStderr(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		slog.Debug("External tool output", "command", c.opts.Command, "line", scanner.Text())
+	}
+}
+
+// initialize performs the MCP handshake on a freshly started process.
+func (c *Client) initialize(ctx context.Context, p *process) error {
+	ctx, cancel := context.WithTimeout(ctx, initTimeout)
+	defer cancel()
+	params := map[string]any{
+		"protocolVersion": protocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "genesis", "version": "1.0"},
+	}
+	if _, err := c.request(ctx, p, "initialize", params); err != nil {
+		return err
+	}
+	return p.write(&message{JSONRPC: "2.0", Method: "notifications/initialized"})
+}
+
+// call sends a request to the running provider, restarting it first if needed.
+func (c *Client) call(ctx context.Context, method string, params any) (jsoniter.RawMessage, error) {
+	p, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.request(ctx, p, method, params)
+}
+
+// request sends a request to p and waits for its response. If ctx ends
+// first, the provider is told to cancel the request.
+func (c *Client) request(ctx context.Context, p *process, method string, params any) (jsoniter.RawMessage, error) {
+	id := c.nextID.Add(1)
+	ch := make(chan *message, 1)
+	p.pendingMu.Lock()
+	p.pending[id] = ch
+	p.pendingMu.Unlock()
+	defer func() {
+		p.pendingMu.Lock()
+		delete(p.pending, id)
+		p.pendingMu.Unlock()
+	}()
+
+	rawID := jsoniter.RawMessage(strconv.FormatInt(id, 10))
+	if err := p.write(&message{JSONRPC: "2.0", ID: rawID, Method: method, Params: params}); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-p.done:
+		return nil, fmt.Errorf("external tool %s exited: %w", c.opts.Command, p.err)
+	case <-ctx.Done():
+		p.write(&message{JSONRPC: "2.0", Method: "notifications/cancelled", Params: map[string]any{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		}})
+		return nil, ctx.Err()
+	}
+}
+
+// write sends one message as a single line.
+func (p *process) write(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	_, err = p.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// read dispatches the messages on the provider's stdout until it is closed.
+// Responses go to the waiting request; the only request a client must
+// answer is "ping", others are rejected. Notifications are ignored.
+func (p *process) read(r io.Reader) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			p.dispatch(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *process) dispatch(line []byte) {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		slog.Debug("Ignoring malformed external tool message", "error", err)
+		return
+	}
+	switch {
+	case msg.Method != "" && len(msg.ID) > 0:
+		reply := &message{JSONRPC: "2.0", ID: msg.ID}
+		if msg.Method == "ping" {
+			reply.Result = jsoniter.RawMessage("{}")
+		} else {
+			reply.Error = &rpcError{Code: -32601, Message: "method not found: " + msg.Method}
+		}
+		p.write(reply)
+	case msg.Method == "" && len(msg.ID) > 0:
+		id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+		if err != nil {
+			return
+		}
+		p.pendingMu.Lock()
+		ch := p.pending[id]
+		p.pendingMu.Unlock()
+		if ch != nil {
+			ch <- &msg
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"genesis/pkg/api"
+	"strings"
+)
+
+// toolInfo is a tool as listed by a provider.
+type toolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// contentItem is a block of a tools/call result. Images carry base64 Data,
+// resource links a URI and embedded resources their content in Resource.
+type contentItem struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Data     string `json:"data"`
+	MimeType string `json:"mimeType"`
+	URI      string `json:"uri"`
+	Resource *struct {
+		URI      string `json:"uri"`
+		Text     string `json:"text"`
+		MimeType string `json:"mimeType"`
+	} `json:"resource"`
+}
+
+// Tools lists the provider's tools, each wrapped as an api.Tool that
+// forwards calls to the provider.
+func (c *Client) Tools(ctx context.Context) ([]api.Tool, error) {
+	var tools []api.Tool
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		raw, err := c.call(ctx, "tools/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("external tool %s: failed to list tools: %w", c.opts.Command, err)
+		}
+		var page struct {
+			Tools      []toolInfo `json:"tools"`
+			NextCursor string     `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("external tool %s: invalid tool list: %w", c.opts.Command, err)
+		}
+		for _, info := range page.Tools {
+			tools = append(tools, &Tool{client: c, info: info})
+		}
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// Call runs a tool of the provider, bounded by the configured timeout.
+// A result the provider flags as an error is returned as an error.
+func (c *Client) Call(ctx context.Context, name string, args map[string]any) (*api.ToolResult, error) {
+	if c.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+		defer cancel()
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	raw, err := c.call(ctx, "tools/call", map[string]any{"name": name, "arguments": args})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Content []contentItem `json:"content"`
+		IsError bool          `json:"isError"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("invalid result from external tool %s: %w", name, err)
+	}
+
+	result := &api.ToolResult{}
+	for _, item := range resp.Content {
+		result.Content = append(result.Content, convertContent(item))
+	}
+	if resp.IsError {
+		var texts []string
+		for _, block := range result.Content {
+			if block.Type == "text" {
+				texts = append(texts, block.Text)
+			}
+		}
+		if len(texts) == 0 {
+			return nil, fmt.Errorf("external tool %s reported an error", name)
+		}
+		return nil, fmt.Errorf("%s", strings.Join(texts, "\n"))
+	}
+	return result, nil
+}
+
+// convertContent maps a provider content item to a tool result block.
+// Content the agent cannot show (audio, binary resources) is described
+// in text instead.
+func convertContent(item contentItem) api.ContentBlock {
+	switch item.Type {
+	case "text":
+		return api.ContentBlock{Type: "text", Text: item.Text}
+	case "image":
+		return api.ContentBlock{Type: "image", Data: item.Data, MimeType: item.MimeType}
+	case "resource":
+		if item.Resource != nil && item.Resource.Text != "" {
+			return api.ContentBlock{Type: "text", Text: item.Resource.Text}
+		}
+		if item.Resource != nil {
+			return api.ContentBlock{Type: "text", Text: fmt.Sprintf("[resource %s (%s)]", item.Resource.URI, item.Resource.MimeType)}
+		}
+	case "resource_link":
+		return api.ContentBlock{Type: "text", Text: fmt.Sprintf("[resource %s]", item.URI)}
+	}
+	return api.ContentBlock{Type: "text", Text: fmt.Sprintf("[unsupported %s content]", item.Type)}
+}
+
+// Tool is a tool provided by an external process. It implements api.Tool.
+type Tool struct {
+	client *Client
+	info   toolInfo
+}
+
+func (t *Tool) Name() string {
+	return t.info.Name
+}
+
+func (t *Tool) Description() string {
+	return t.info.Description
+}
+
+// Parameters returns the properties of the tool's input schema.
+func (t *Tool) Parameters() map[string]any {
+	props, _ := t.info.InputSchema["properties"].(map[string]any)
+	return props
+}
+
+// RequiredParameters returns the required properties of the tool's input schema.
+func (t *Tool) RequiredParameters() []string {
+	list, _ := t.info.InputSchema["required"].([]any)
+	required := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			required = append(required, s)
+		}
+	}
+	return required
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (*api.ToolResult, error) {
+	return t.client.Call(ctx, t.info.Name, args)
+}
@@ -0,0 +1,174 @@
+// Package httpremote implements tools served by HTTP endpoints, so tools can
+// run as separate services.
+//
+// Every call is a POST of a JSON request to the tool's URL:
+//
+//	{"tool": "weather", "arguments": {...}, "session": {"channel_id": ..., "user_id": ..., "chat_id": ..., "username": ...}}
+//
+// A 2xx reply with a JSON body is decoded as the tool result:
+//
+//	{"content": [{"type": "text", "text": "..."}, {"type": "image", "data": "<base64>", "mime_type": "image/png"}], "details": {...}, "error": "..."}
+//
+// An optional "directive" becomes the result's api.ToolResult.Directive.
+// A non-empty "error" fails the call with that message. Replies of any other
+// content type are used as a single text block. Non-2xx replies fail the call.
+package httpremote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"genesis/pkg/api"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// DefaultTimeout bounds a call when Options.Timeout is zero.
+const DefaultTimeout = 30 * time.Second
+
+// maxResponseBytes caps the reply body read from an endpoint.
+const maxResponseBytes = 16 << 20
+
+// Options configures an HTTP tool.
+type Options struct {
+	Name        string            // Tool name shown to the LLM
+	Description string            // What the tool does, shown to the LLM
+	URL         string            // Endpoint receiving the POSTed calls
+	Parameters  map[string]any    // JSON Schema properties of the arguments
+	Required    []string          // Names of the mandatory arguments
+	Headers     map[string]string // Sent with every call, e.g., Authorization
+	Timeout     time.Duration     // Bound on a single call; 0 means DefaultTimeout
+}
+
+// request is the body POSTed for every call.
+type request struct {
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+	Session   *session       `json:"session,omitempty"`
+}
+
+// session identifies who the call is made for, so endpoints can scope or
+// audit their work.
+type session struct {
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+	ChatID    string `json:"chat_id"`
+	Username  string `json:"username,omitempty"`
+}
+
+// response is a decoded JSON reply.
+type response struct {
+	api.ToolResult
+	Error string `json:"error,omitempty"`
+}
+
+// Tool forwards calls to an HTTP endpoint. It implements api.Tool.
+type Tool struct {
+	opts   Options
+	client *http.Client
+}
+
+// New creates an HTTP tool. Header values may reference environment
+// variables as $VAR or ${VAR}, keeping credentials out of the config file;
+// they are expanded once here.
+func New(opts Options) (*Tool, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("http tool name is empty")
+	}
+	if !strings.HasPrefix(opts.URL, "http://") && !strings.HasPrefix(opts.URL, "https://") {
+		return nil, fmt.Errorf("http tool %s: invalid url %q", opts.Name, opts.URL)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	headers := make(map[string]string, len(opts.Headers))
+	for k, v := range opts.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+	opts.Headers = headers
+	return &Tool{opts: opts, client: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+func (t *Tool) Name() string {
+	return t.opts.Name
+}
+
+func (t *Tool) Description() string {
+	return t.opts.Description
+}
+
+func (t *Tool) Parameters() map[string]any {
+	return t.opts.Parameters
+}
+
+func (t *Tool) RequiredParameters() []string {
+	return t.opts.Required
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (*api.ToolResult, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	req := request{Tool: t.opts.Name, Arguments: args}
+	if s, ok := api.SessionFromContext(ctx); ok {
+		req.Session = &session{ChannelID: s.ChannelID, UserID: s.UserID, ChatID: s.ChatID, Username: s.Username}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	for k, v := range t.opts.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("endpoint returned %s: %s", resp.Status, snippet(data))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return &api.ToolResult{Content: []api.ContentBlock{{Type: "text", Text: string(data)}}}, nil
+	}
+	var out response
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if out.Error != "" {
+		return nil, fmt.Errorf("%s", out.Error)
+	}
+	return &out.ToolResult, nil
+}
+
+// snippet shortens an error body for inclusion in an error message.
+func snippet(data []byte) string {
+	const max = 200
+	runes := []rune(strings.TrimSpace(string(data)))
+	if len(runes) > max {
+		return string(runes[:max]) + "..."
+	}
+	return string(runes)
+}
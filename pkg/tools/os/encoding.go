@@ -0,0 +1,71 @@
+package os
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// codePages maps common Windows code page identifiers to their decoders.
+// UTF-8 (65001) is deliberately absent: it needs no transcoding.
+var codePages = map[uint32]encoding.Encoding{
+	437:  charmap.CodePage437,
+	850:  charmap.CodePage850,
+	866:  charmap.CodePage866,
+	932:  japanese.ShiftJIS,
+	936:  simplifiedchinese.GBK,
+	949:  korean.EUCKR,
+	950:  traditionalchinese.Big5,
+	1250: charmap.Windows1250,
+	1251: charmap.Windows1251,
+	1252: charmap.Windows1252,
+}
+
+// lookupEncoding resolves an encoding by code page number (e.g., "950") or
+// by label (e.g., "big5", "gbk", "shift_jis"). "utf-8" yields nil, meaning
+// output is used as-is.
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	name = strings.TrimSpace(name)
+	if cp, err := strconv.ParseUint(name, 10, 32); err == nil {
+		if cp == 65001 {
+			return nil, nil
+		}
+		enc, ok := codePages[uint32(cp)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported code page: %d", cp)
+		}
+		return enc, nil
+	}
+
+	if strings.EqualFold(name, "utf-8") || strings.EqualFold(name, "utf8") {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown output encoding %q: %w", name, err)
+	}
+	return enc, nil
+}
+
+// toUTF8 returns command output as a UTF-8 string. Output that is already
+// valid UTF-8 is returned unchanged; otherwise it is transcoded with enc.
+// If transcoding fails, the raw bytes are returned.
+func toUTF8(b []byte, enc encoding.Encoding) string {
+	if enc == nil || utf8.Valid(b) {
+		return string(b)
+	}
+	decoded, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(decoded)
+}
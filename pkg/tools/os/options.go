@@ -0,0 +1,97 @@
+package os
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Options configures a platform worker created by NewOSWorker.
+// The zero value reproduces the unrestricted default behavior.
+type Options struct {
+	// WorkDirRoot, when set, confines the tracked working directory to this
+	// subtree: commands start in the root and a 'cd' that resolves outside it
+	// is not followed. It is not a sandbox. The check runs after the command,
+	// which may read or write anywhere the process can.
+	WorkDirRoot string
+	// Shell overrides the shell used by run_command (e.g., "sh", "fish",
+	// "cmd.exe"). Empty selects the platform default.
+	Shell string
+	// OutputEncoding names the encoding of command output that is not valid
+	// UTF-8, as a code page number ("950") or label ("big5", "gbk"). Empty
+	// detects the console code page on Windows and disables transcoding elsewhere.
+	OutputEncoding string
+}
+
+// resolveShell validates that the configured shell (or the platform default
+// when none is configured) can be found, returning its resolved path.
+func resolveShell(shell, fallback string) (string, error) {
+	if shell == "" {
+		shell = fallback
+	}
+	path, err := exec.LookPath(shell)
+	if err != nil {
+		return "", fmt.Errorf("shell %q not found: %w", shell, err)
+	}
+	return path, nil
+}
+
+// shellName returns the lower-cased base name of a shell without extension,
+// e.g. "powershell" for `C:\Windows\System32\WindowsPowerShell\v1.0\PowerShell.exe`.
+func shellName(shell string) string {
+	base := strings.ToLower(filepath.Base(shell))
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// shellFlag returns the argument that makes a shell run a command string.
+func shellFlag(shell string) string {
+	switch shellName(shell) {
+	case "cmd":
+		return "/C"
+	case "powershell", "pwsh":
+		return "-Command"
+	default:
+		return "-c"
+	}
+}
+
+// initialDirs resolves the working directory root (if any) and the
+// directory in which the first command should run.
+func initialDirs(opts Options) (workingDir, workDirRoot string, err error) {
+	if opts.WorkDirRoot == "" {
+		cwd, _ := os.Getwd()
+		return cwd, "", nil
+	}
+
+	root, err := filepath.Abs(opts.WorkDirRoot)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid working directory root %q: %w", opts.WorkDirRoot, err)
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", "", fmt.Errorf("invalid working directory root %q: %w", opts.WorkDirRoot, err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("working directory root %q is not a directory", opts.WorkDirRoot)
+	}
+	return root, root, nil
+}
+
+// checkWorkDir reports an error when dir resolves outside root.
+// An empty root disables the check.
+func checkWorkDir(root, dir string) error {
+	if root == "" {
+		return nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("cannot resolve directory %s: %w", dir, err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return fmt.Errorf("the command ran, but its new directory %s is outside the working directory root %s and is not kept", dir, root)
+	}
+	return nil
+}
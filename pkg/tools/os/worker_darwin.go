@@ -9,25 +9,54 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+
+	"golang.org/x/text/encoding"
 )
 
 // DarwinWorker implements tools.Controller for macOS
 type DarwinWorker struct {
-	workingDir string
+	workingDir  string
+	workDirRoot string
+	shell       string
+	outputEnc   encoding.Encoding
 }
 
-func NewOSWorker() tools.Controller {
-	cwd, _ := os.Getwd()
-	return &DarwinWorker{
-		workingDir: cwd,
+// defaultShell is used when Options.Shell is empty.
+const defaultShell = "/bin/zsh"
+
+// NewOSWorker creates the platform worker. It fails if opts.WorkDirRoot is
+// set but does not name an existing directory, or if the shell is missing.
+func NewOSWorker(opts Options) (tools.Controller, error) {
+	workingDir, workDirRoot, err := initialDirs(opts)
+	if err != nil {
+		return nil, err
+	}
+	shell, err := resolveShell(opts.Shell, defaultShell)
+	if err != nil {
+		return nil, err
+	}
+	var outputEnc encoding.Encoding
+	if opts.OutputEncoding != "" {
+		if outputEnc, err = lookupEncoding(opts.OutputEncoding); err != nil {
+			return nil, err
+		}
 	}
+	return &DarwinWorker{
+		workingDir:  workingDir,
+		workDirRoot: workDirRoot,
+		shell:       shell,
+		outputEnc:   outputEnc,
+	}, nil
 }
 
 func (w *DarwinWorker) Capabilities() []string {
 	return []string{
 		"run_command",
 		"screenshot",
+		"list_processes",
+		"kill_process",
 	}
 }
 
@@ -51,23 +80,41 @@ func (w *DarwinWorker) Execute(ctx context.Context, req tools.ActionRequest) (*t
 		}
 		return &tools.ActionResponse{Success: true, Data: data}, nil
 
+	case "list_processes":
+		output, err := w.listProcesses(ctx)
+		if err != nil {
+			return &tools.ActionResponse{Success: false, Error: err.Error()}, nil
+		}
+		return &tools.ActionResponse{Success: true, Data: output}, nil
+
+	case "kill_process":
+		pid, err := tools.ParsePID(req.Params["pid"])
+		if err != nil {
+			return nil, err
+		}
+		output, err := w.killProcess(ctx, pid)
+		if err != nil {
+			return &tools.ActionResponse{Success: false, Error: err.Error()}, nil
+		}
+		return &tools.ActionResponse{Success: true, Data: output}, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported action: %s", req.Action)
 	}
 }
 
 func (w *DarwinWorker) runCommand(ctx context.Context, cmdStr string) (string, error) {
-	slog.InfoContext(ctx, "Executing command", "dir", w.workingDir, "command", cmdStr)
+	slog.InfoContext(ctx, "Executing command", "dir", w.workingDir, "shell", w.shell, "command", cmdStr)
 
-	// Use zsh for macOS
+	// Run through the configured shell (zsh by default)
 	// We want to persist directory changes, but since each command is isolated,
 	// we try to chain it with 'pwd' to get the new directory if changed.
 	// A robust way is to run: cd <workingDir> && <cmd> && pwd
 	fullCmd := fmt.Sprintf("cd %q && %s && pwd", w.workingDir, cmdStr)
 
-	cmd := exec.CommandContext(ctx, "/bin/zsh", "-c", fullCmd)
+	cmd := exec.CommandContext(ctx, w.shell, shellFlag(w.shell), fullCmd)
 	outputBytes, err := cmd.CombinedOutput()
-	output := string(outputBytes)
+	output := toUTF8(outputBytes, w.outputEnc)
 
 	if err != nil {
 		return output, err
@@ -77,6 +124,10 @@ func (w *DarwinWorker) runCommand(ctx context.Context, cmdStr string) (string, e
 	if len(lines) > 0 {
 		possibleCwd := lines[len(lines)-1]
 		if info, statErr := os.Stat(possibleCwd); statErr == nil && info.IsDir() {
+			// Do not follow a 'cd' that leaves the working directory root
+			if err := checkWorkDir(w.workDirRoot, possibleCwd); err != nil {
+				return strings.Join(lines[:len(lines)-1], "\n"), err
+			}
 			w.workingDir = possibleCwd
 			// Remove the PWD from output
 			output = strings.Join(lines[:len(lines)-1], "\n")
@@ -102,3 +153,23 @@ func (w *DarwinWorker) takeScreenshot(ctx context.Context) (string, error) {
 
 	return tools.Base64Encode(data), nil
 }
+
+// listProcesses returns the process table as reported by ps.
+func (w *DarwinWorker) listProcesses(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "ps", "-eo", "pid,user,%cpu,%mem,comm").CombinedOutput()
+	output := toUTF8(out, w.outputEnc)
+	if err != nil {
+		return "", fmt.Errorf("ps failed: %w: %s", err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// killProcess sends SIGTERM to the process via kill.
+func (w *DarwinWorker) killProcess(ctx context.Context, pid int) (string, error) {
+	slog.InfoContext(ctx, "Killing process", "pid", pid)
+	out, err := exec.CommandContext(ctx, "kill", strconv.Itoa(pid)).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("kill failed: %w: %s", err, strings.TrimSpace(toUTF8(out, w.outputEnc)))
+	}
+	return fmt.Sprintf("Sent SIGTERM to process %d", pid), nil
+}
@@ -9,25 +9,54 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+
+	"golang.org/x/text/encoding"
 )
 
 // LinuxWorker implements tools.Controller for Linux
 type LinuxWorker struct {
-	workingDir string
+	workingDir  string
+	workDirRoot string
+	shell       string
+	outputEnc   encoding.Encoding
 }
 
-func NewOSWorker() tools.Controller {
-	cwd, _ := os.Getwd()
-	return &LinuxWorker{
-		workingDir: cwd,
+// defaultShell is used when Options.Shell is empty.
+const defaultShell = "/bin/bash"
+
+// NewOSWorker creates the platform worker. It fails if opts.WorkDirRoot is
+// set but does not name an existing directory, or if the shell is missing.
+func NewOSWorker(opts Options) (tools.Controller, error) {
+	workingDir, workDirRoot, err := initialDirs(opts)
+	if err != nil {
+		return nil, err
+	}
+	shell, err := resolveShell(opts.Shell, defaultShell)
+	if err != nil {
+		return nil, err
+	}
+	var outputEnc encoding.Encoding
+	if opts.OutputEncoding != "" {
+		if outputEnc, err = lookupEncoding(opts.OutputEncoding); err != nil {
+			return nil, err
+		}
 	}
+	return &LinuxWorker{
+		workingDir:  workingDir,
+		workDirRoot: workDirRoot,
+		shell:       shell,
+		outputEnc:   outputEnc,
+	}, nil
 }
 
 func (w *LinuxWorker) Capabilities() []string {
 	return []string{
 		"run_command",
 		"screenshot",
+		"list_processes",
+		"kill_process",
 	}
 }
 
@@ -51,20 +80,38 @@ func (w *LinuxWorker) Execute(ctx context.Context, req tools.ActionRequest) (*to
 		}
 		return &tools.ActionResponse{Success: true, Data: data}, nil
 
+	case "list_processes":
+		output, err := w.listProcesses(ctx)
+		if err != nil {
+			return &tools.ActionResponse{Success: false, Error: err.Error()}, nil
+		}
+		return &tools.ActionResponse{Success: true, Data: output}, nil
+
+	case "kill_process":
+		pid, err := tools.ParsePID(req.Params["pid"])
+		if err != nil {
+			return nil, err
+		}
+		output, err := w.killProcess(ctx, pid)
+		if err != nil {
+			return &tools.ActionResponse{Success: false, Error: err.Error()}, nil
+		}
+		return &tools.ActionResponse{Success: true, Data: output}, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported action: %s", req.Action)
 	}
 }
 
 func (w *LinuxWorker) runCommand(ctx context.Context, cmdStr string) (string, error) {
-	slog.InfoContext(ctx, "Executing command", "dir", w.workingDir, "command", cmdStr)
+	slog.InfoContext(ctx, "Executing command", "dir", w.workingDir, "shell", w.shell, "command", cmdStr)
 
-	// Use bash for Linux
+	// Run through the configured shell (bash by default)
 	fullCmd := fmt.Sprintf("cd %q && %s && pwd", w.workingDir, cmdStr)
 
-	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", fullCmd)
+	cmd := exec.CommandContext(ctx, w.shell, shellFlag(w.shell), fullCmd)
 	outputBytes, err := cmd.CombinedOutput()
-	output := string(outputBytes)
+	output := toUTF8(outputBytes, w.outputEnc)
 
 	if err != nil {
 		return output, err
@@ -74,6 +121,10 @@ func (w *LinuxWorker) runCommand(ctx context.Context, cmdStr string) (string, er
 	if len(lines) > 0 {
 		possibleCwd := lines[len(lines)-1]
 		if info, statErr := os.Stat(possibleCwd); statErr == nil && info.IsDir() {
+			// Do not follow a 'cd' that leaves the working directory root
+			if err := checkWorkDir(w.workDirRoot, possibleCwd); err != nil {
+				return strings.Join(lines[:len(lines)-1], "\n"), err
+			}
 			w.workingDir = possibleCwd
 			output = strings.Join(lines[:len(lines)-1], "\n")
 		}
@@ -104,3 +155,23 @@ func (w *LinuxWorker) takeScreenshot(ctx context.Context) (string, error) {
 
 	return tools.Base64Encode(data), nil
 }
+
+// listProcesses returns the process table as reported by ps.
+func (w *LinuxWorker) listProcesses(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "ps", "-eo", "pid,user,%cpu,%mem,comm").CombinedOutput()
+	output := toUTF8(out, w.outputEnc)
+	if err != nil {
+		return "", fmt.Errorf("ps failed: %w: %s", err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// killProcess sends SIGTERM to the process via kill.
+func (w *LinuxWorker) killProcess(ctx context.Context, pid int) (string, error) {
+	slog.InfoContext(ctx, "Killing process", "pid", pid)
+	out, err := exec.CommandContext(ctx, "kill", strconv.Itoa(pid)).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("kill failed: %w: %s", err, strings.TrimSpace(toUTF8(out, w.outputEnc)))
+	}
+	return fmt.Sprintf("Sent SIGTERM to process %d", pid), nil
+}
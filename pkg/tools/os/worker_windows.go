@@ -12,28 +12,78 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/text/encoding"
 )
 
 // WindowsWorker implements the tools.Controller interface specifically for
 // Windows environments. It maintains stateful session data like the
 // current working directory to support sequential shell commands (e.g., 'cd').
 type WindowsWorker struct {
-	workingDir string // Tracks the persistent location for command execution context
+	workingDir  string            // Tracks the persistent location for command execution context
+	workDirRoot string            // Optional subtree the tracked working directory is confined to
+	shell       string            // Resolved shell executable (PowerShell, pwsh or cmd.exe)
+	outputEnc   encoding.Encoding // Decoder for legacy code page output; nil keeps bytes as-is
 }
 
-func NewOSWorker() tools.Controller {
-	cwd, _ := os.Getwd()
+// defaultShell is used when Options.Shell is empty.
+const defaultShell = "powershell"
+
+// NewOSWorker creates the platform worker. It fails if opts.WorkDirRoot is
+// set but does not name an existing directory, or if the shell is missing or
+// is not one of PowerShell, pwsh or cmd.exe.
+func NewOSWorker(opts Options) (tools.Controller, error) {
+	workingDir, workDirRoot, err := initialDirs(opts)
+	if err != nil {
+		return nil, err
+	}
+	shell, err := resolveShell(opts.Shell, defaultShell)
+	if err != nil {
+		return nil, err
+	}
+	switch shellName(shell) {
+	case "powershell", "pwsh", "cmd":
+	default:
+		return nil, fmt.Errorf("unsupported shell on Windows: %s (use powershell, pwsh or cmd)", shell)
+	}
+	outputEnc, err := outputEncoding(opts.OutputEncoding)
+	if err != nil {
+		return nil, err
+	}
 	return &WindowsWorker{
-		workingDir: cwd,
+		workingDir:  workingDir,
+		workDirRoot: workDirRoot,
+		shell:       shell,
+		outputEnc:   outputEnc,
+	}, nil
+}
+
+// outputEncoding resolves the configured output encoding, falling back to
+// the console code page (or the ANSI code page when no console is attached),
+// which is what native executables use for their output.
+func outputEncoding(name string) (encoding.Encoding, error) {
+	if name != "" {
+		return lookupEncoding(name)
+	}
+	cp, err := windows.GetConsoleOutputCP()
+	if err != nil || cp == 0 {
+		cp = windows.GetACP()
 	}
+	// Unknown code pages are left untranscoded rather than failing startup
+	enc, _ := lookupEncoding(strconv.FormatUint(uint64(cp), 10))
+	return enc, nil
 }
 
 // Capabilities returns a list of OS-native primitives supported on Windows.
 func (w *WindowsWorker) Capabilities() []string {
 	return []string{
-		"run_command", // Execute PowerShell/Shell commands
-		"screenshot",  // Capture primary screen area
+		"run_command",    // Execute PowerShell/Shell commands
+		"screenshot",     // Capture primary screen area
+		"list_processes", // List running processes via tasklist
+		"kill_process",   // Terminate a process via taskkill
 	}
 }
 
@@ -59,51 +109,82 @@ func (w *WindowsWorker) Execute(ctx context.Context, req tools.ActionRequest) (*
 		}
 		return &tools.ActionResponse{Success: true, Data: data}, nil
 
+	case "list_processes":
+		output, err := w.listProcesses(ctx)
+		if err != nil {
+			return &tools.ActionResponse{Success: false, Error: err.Error()}, nil
+		}
+		return &tools.ActionResponse{Success: true, Data: output}, nil
+
+	case "kill_process":
+		pid, err := tools.ParsePID(req.Params["pid"])
+		if err != nil {
+			return nil, err
+		}
+		output, err := w.killProcess(ctx, pid)
+		if err != nil {
+			return &tools.ActionResponse{Success: false, Error: err.Error()}, nil
+		}
+		return &tools.ActionResponse{Success: true, Data: output}, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported action: %s", req.Action)
 	}
 }
 
-// runCommand executes a string-based shell command via PowerShell.
-// It manages environment variable expansion (converting %VAR% to $env:VAR)
-// and handles UTF-8 encoding synchronization between Go and PowerShell.
+// runCommand executes a string-based shell command via the configured shell.
+// Under PowerShell it manages environment variable expansion (converting
+// %VAR% to $env:VAR) and handles UTF-8 encoding synchronization between Go
+// and PowerShell; cmd.exe expands %VAR% natively.
 //
 // Key features:
 // - Stateful: Appends a PWD command to track directory changes (e.g., after 'cd').
 // - Resilient: Merges Stdout and Stderr for comprehensive logging.
 // - Transparent: Strips the internal PWD metadata from the output before returning.
 func (w *WindowsWorker) runCommand(ctx context.Context, cmdStr string) (string, error) {
-	// Convert %VAR% to PowerShell format $env:VAR
-	re := regexp.MustCompile(`%([^%]+)%`)
-	expandedCmd := re.ReplaceAllString(cmdStr, `$env:$1`)
+	var fullCmd string
+	if shellName(w.shell) == "cmd" {
+		// 'cd' without arguments prints the current directory; & runs it
+		// regardless of the command's exit status
+		fullCmd = fmt.Sprintf("%s & cd", cmdStr)
+	} else {
+		// Convert %VAR% to PowerShell format $env:VAR
+		re := regexp.MustCompile(`%([^%]+)%`)
+		expandedCmd := re.ReplaceAllString(cmdStr, `$env:$1`)
 
-	// Force PowerShell output to UTF8 and execute the core command
-	// [Console]::OutputEncoding affects the output stream, $OutputEncoding affects internal byte conversion
-	utf8Cmd := "[Console]::OutputEncoding = [System.Text.Encoding]::UTF8; $OutputEncoding = [System.Text.Encoding]::UTF8; " + expandedCmd
+		// Force PowerShell output to UTF8 and execute the core command
+		// [Console]::OutputEncoding affects the output stream, $OutputEncoding affects internal byte conversion
+		utf8Cmd := "[Console]::OutputEncoding = [System.Text.Encoding]::UTF8; $OutputEncoding = [System.Text.Encoding]::UTF8; " + expandedCmd
 
-	// Default to powershell execution, and return current directory (pwd) to update state
-	// Use ; to separate multiple commands
-	fullCmd := fmt.Sprintf("%s; $ExecutionContext.SessionState.Path.CurrentLocation.Path", utf8Cmd)
+		// Return current directory (pwd) to update state
+		// Use ; to separate multiple commands
+		fullCmd = fmt.Sprintf("%s; $ExecutionContext.SessionState.Path.CurrentLocation.Path", utf8Cmd)
+	}
 
-	slog.InfoContext(ctx, "Executing command", "dir", w.workingDir, "command", fullCmd)
+	slog.InfoContext(ctx, "Executing command", "dir", w.workingDir, "shell", w.shell, "command", fullCmd)
 
-	cmd := exec.CommandContext(ctx, "powershell", "-Command", fullCmd)
+	cmd := exec.CommandContext(ctx, w.shell, shellFlag(w.shell), fullCmd)
 	cmd.Dir = w.workingDir
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &out
 	err := cmd.Run()
 
-	output := out.String()
+	// Native executables may ignore the UTF-8 setting and emit code page bytes
+	output := toUTF8(out.Bytes(), w.outputEnc)
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 	if len(lines) > 0 {
 		// Last line should be the new PWD
 		newCwd := strings.TrimSpace(lines[len(lines)-1])
 		// Verify if path exists and is a directory
 		if info, statErr := os.Stat(newCwd); statErr == nil && info.IsDir() {
-			w.workingDir = newCwd
 			// Remove the PWD info from output to avoid interfering with AI
 			output = strings.Join(lines[:len(lines)-1], "\n")
+			// Do not follow a 'cd' that leaves the working directory root
+			if wdErr := checkWorkDir(w.workDirRoot, newCwd); wdErr != nil {
+				return output, wdErr
+			}
+			w.workingDir = newCwd
 
 			// If output is empty (e.g., cd command), return the new directory to inform AI
 			if strings.TrimSpace(output) == "" {
@@ -153,3 +234,24 @@ $Bitmap.Dispose()
 	// Return Base64 encoding, which allows AI assistants (if they support Vision) to parse directly
 	return tools.Base64Encode(data), nil
 }
+
+// listProcesses returns the process table as reported by tasklist.
+func (w *WindowsWorker) listProcesses(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "tasklist", "/FO", "TABLE").CombinedOutput()
+	output := toUTF8(out, w.outputEnc)
+	if err != nil {
+		return "", fmt.Errorf("tasklist failed: %w: %s", err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// killProcess forcefully terminates the process (and its children) via taskkill.
+func (w *WindowsWorker) killProcess(ctx context.Context, pid int) (string, error) {
+	slog.InfoContext(ctx, "Killing process", "pid", pid)
+	out, err := exec.CommandContext(ctx, "taskkill", "/PID", strconv.Itoa(pid), "/T", "/F").CombinedOutput()
+	output := strings.TrimSpace(toUTF8(out, w.outputEnc))
+	if err != nil {
+		return "", fmt.Errorf("taskkill failed: %w: %s", err, output)
+	}
+	return output, nil
+}
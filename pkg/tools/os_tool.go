@@ -10,8 +10,10 @@ import (
 
 // Define constants to avoid Magic Numbers
 const (
-	ActionScreenshot = "screenshot"
-	ActionRunCommand = "run_command"
+	ActionScreenshot    = "screenshot"
+	ActionRunCommand    = "run_command"
+	ActionListProcesses = "list_processes"
+	ActionKillProcess   = "kill_process"
 )
 
 // ---------- Action Spec ----------
@@ -23,6 +25,7 @@ type ActionSpec struct {
 	Description   string                                             // Human-readable documentation for LLM ingestion
 	ParamSchema   map[string]any                                     // Properties for JSON Schema (tool definition)
 	RequireParams bool                                               // Flag to mandate the presence of the "params" object
+	ReadOnly      bool                                               // Action does not modify the host and stays available in read-only mode
 	Validate      func(params map[string]any) error                  // Logic for validating action-specific parameters
 	FormatResult  func(resp *ActionResponse) ([]ContentBlock, error) // Logic to convert controller response to tool blocks
 }
@@ -34,6 +37,7 @@ var osActionRegistry = map[string]ActionSpec{
 		Name:          ActionScreenshot,
 		Description:   "Capture a screenshot",
 		RequireParams: false,
+		ReadOnly:      true,
 		ParamSchema:   map[string]any{},
 		FormatResult: func(resp *ActionResponse) ([]ContentBlock, error) {
 			b64, ok := resp.Data.(string)
@@ -63,18 +67,45 @@ var osActionRegistry = map[string]ActionSpec{
 			// TODO: Add command blacklist check here (e.g., rm -rf /)
 			return nil
 		},
-		FormatResult: func(resp *ActionResponse) ([]ContentBlock, error) {
-			val := ""
-			if resp.Data != nil {
-				val = fmt.Sprintf("%v", resp.Data)
-			}
-			return []ContentBlock{
-				{Type: "text", Text: val},
-			}, nil
+		FormatResult: formatTextResult,
+	},
+	ActionListProcesses: {
+		Name:          ActionListProcesses,
+		Description:   "List running processes",
+		RequireParams: false,
+		ReadOnly:      true,
+		ParamSchema:   map[string]any{},
+		FormatResult:  formatTextResult,
+	},
+	ActionKillProcess: {
+		Name:          ActionKillProcess,
+		Description:   "Terminate a process by PID",
+		RequireParams: true,
+		ParamSchema: map[string]any{
+			"pid": map[string]any{
+				"type":        "integer",
+				"description": "ID of the process to terminate",
+			},
+		},
+		Validate: func(params map[string]any) error {
+			_, err := ParsePID(params["pid"])
+			return err
 		},
+		FormatResult: formatTextResult,
 	},
 }
 
+// formatTextResult renders the controller payload as a single text block.
+func formatTextResult(resp *ActionResponse) ([]ContentBlock, error) {
+	val := ""
+	if resp.Data != nil {
+		val = fmt.Sprintf("%v", resp.Data)
+	}
+	return []ContentBlock{
+		{Type: "text", Text: val},
+	}, nil
+}
+
 // ---------- Tool ----------
 
 // OSTool implements the tools.Tool interface to expose OS-level capabilities
@@ -82,6 +113,7 @@ var osActionRegistry = map[string]ActionSpec{
 // high-level tool registry and a platform-specific low-level Controller.
 type OSTool struct {
 	controller Controller // Primary engine for dispatching low-level actions
+	readOnly   bool       // Restricts the tool to actions flagged ReadOnly
 }
 
 // NewOSTool initializes a fresh OSTool instance with a specified controller (worker).
@@ -89,6 +121,26 @@ func NewOSTool(c Controller) *OSTool {
 	return &OSTool{controller: c}
 }
 
+// SetReadOnly restricts the tool to non-mutating actions (e.g., screenshot).
+// Other actions are hidden from the model and rejected at execution time.
+func (t *OSTool) SetReadOnly(readOnly bool) {
+	t.readOnly = readOnly
+}
+
+// actions returns the registry entries available under the current mode.
+func (t *OSTool) actions() map[string]ActionSpec {
+	if !t.readOnly {
+		return osActionRegistry
+	}
+	allowed := make(map[string]ActionSpec)
+	for name, spec := range osActionRegistry {
+		if spec.ReadOnly {
+			allowed[name] = spec
+		}
+	}
+	return allowed
+}
+
 func (t *OSTool) Name() string {
 	return "os_control"
 }
@@ -96,30 +148,44 @@ func (t *OSTool) Name() string {
 func (t *OSTool) Description() string {
 	// Dynamically generate supported actions list
 	var actions []string
-	for name, spec := range osActionRegistry {
+	for name, spec := range t.actions() {
 		actions = append(actions, fmt.Sprintf("'%s' (%s)", name, spec.Description))
 	}
 	sort.Strings(actions)
 
-	return fmt.Sprintf(
+	desc := fmt.Sprintf(
 		"Control the operating system (environment: %s). Supported actions: %s",
 		runtime.GOOS,
 		strings.Join(actions, ", "),
 	)
+	if t.readOnly {
+		desc += ". Read-only mode: commands and other actions that modify the system are disabled."
+	}
+	return desc
 }
 
 func (t *OSTool) Parameters() map[string]any {
-	return map[string]any{
+	params := map[string]any{
 		"action": map[string]any{
 			"type":        "string",
 			"description": "Name of the action to execute",
 			"enum":        t.getActionNames(),
 		},
-		"command": map[string]any{
+	}
+	actions := t.actions()
+	if _, ok := actions[ActionRunCommand]; ok {
+		params["command"] = map[string]any{
 			"type":        "string",
 			"description": "System command to execute (for 'run_command' action)",
-		},
+		}
+	}
+	if _, ok := actions[ActionKillProcess]; ok {
+		params["pid"] = map[string]any{
+			"type":        "integer",
+			"description": "Process ID to terminate (for 'kill_process' action)",
+		}
 	}
+	return params
 }
 
 func (t *OSTool) RequiredParameters() []string {
@@ -128,8 +194,9 @@ func (t *OSTool) RequiredParameters() []string {
 
 // getActionNames returns a sorted list of supported action names
 func (t *OSTool) getActionNames() []string {
-	keys := make([]string, 0, len(osActionRegistry))
-	for k := range osActionRegistry {
+	actions := t.actions()
+	keys := make([]string, 0, len(actions))
+	for k := range actions {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
@@ -196,6 +263,9 @@ func (t *OSTool) parseAndValidateArgs(args map[string]any) (ActionSpec, map[stri
 	if !exists {
 		return ActionSpec{}, nil, fmt.Errorf("unsupported action: %s", actionName)
 	}
+	if t.readOnly && !spec.ReadOnly {
+		return ActionSpec{}, nil, fmt.Errorf("action '%s' is disabled: the OS tool is in read-only mode", actionName)
+	}
 
 	// All top-level args except "action" are treated as params
 	params := make(map[string]any)
@@ -206,7 +276,7 @@ func (t *OSTool) parseAndValidateArgs(args map[string]any) (ActionSpec, map[stri
 	}
 
 	if spec.RequireParams && len(params) == 0 {
-		return ActionSpec{}, nil, fmt.Errorf("action '%s' requires parameters (e.g. 'command')", actionName)
+		return ActionSpec{}, nil, fmt.Errorf("action '%s' requires parameters (e.g. 'command' or 'pid')", actionName)
 	}
 
 	if spec.Validate != nil {
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"genesis/pkg/api"
+	"genesis/pkg/llm"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sessionTranscriptMessages is how many recent messages 'summarize' returns
+// alongside the stored summary.
+const sessionTranscriptMessages = 20
+
+// sessionSearchResults caps the matches returned by 'search'.
+const sessionSearchResults = 20
+
+// SessionTool implements api.Tool to let the agent inspect and switch
+// between the conversation sessions of the user it is talking to. Only
+// sessions the current user took part in are visible, so one user's
+// conversations never leak into another's.
+type SessionTool struct {
+	sessions *llm.SessionManager
+}
+
+// NewSessionTool creates a session tool operating on the given manager.
+func NewSessionTool(sessions *llm.SessionManager) *SessionTool {
+	return &SessionTool{sessions: sessions}
+}
+
+func (t *SessionTool) Name() string {
+	return "sessions"
+}
+
+func (t *SessionTool) Description() string {
+	return "Manage the current user's conversation sessions. Supported actions: 'list' (show the user's sessions), " +
+		"'summarize' (return the summary and recent messages of a session), " +
+		"'search' (find past messages containing all words of a query), " +
+		"'switch' (continue this chat in another session from the next message on; use the chat's own session ID to switch back). " +
+		"Switching is not available in group chats."
+}
+
+func (t *SessionTool) Parameters() map[string]any {
+	return map[string]any{
+		"action": map[string]any{
+			"type":        "string",
+			"description": "Name of the action to execute",
+			"enum":        []string{"list", "summarize", "search", "switch"},
+		},
+		"query": map[string]any{
+			"type":        "string",
+			"description": "Words to search for (required for 'search')",
+		},
+		"session_id": map[string]any{
+			"type":        "string",
+			"description": "Session ID as shown by 'list' (required for 'summarize' and 'switch')",
+		},
+	}
+}
+
+func (t *SessionTool) RequiredParameters() []string {
+	return []string{"action"}
+}
+
+func (t *SessionTool) Execute(ctx context.Context, args map[string]any) (*ToolResult, error) {
+	session, ok := api.SessionFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no session in context")
+	}
+
+	action, _ := args["action"].(string)
+	sessionID, _ := args["session_id"].(string)
+	sessionID = strings.TrimSpace(sessionID)
+
+	switch action {
+	case "list":
+		return t.list(session)
+	case "search":
+		query, _ := args["query"].(string)
+		if strings.TrimSpace(query) == "" {
+			return nil, fmt.Errorf("missing or invalid 'query' parameter")
+		}
+		return t.search(session, query)
+	case "summarize", "switch":
+		if sessionID == "" {
+			return nil, fmt.Errorf("missing or invalid 'session_id' parameter")
+		}
+		info, err := t.find(session, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if action == "summarize" {
+			return t.summarize(info)
+		}
+		return t.switchTo(session, info)
+	default:
+		return nil, fmt.Errorf("unsupported action: %v", args["action"])
+	}
+}
+
+// owned returns the sessions the user may access.
+func (t *SessionTool) owned(session api.SessionContext) ([]llm.SessionInfo, error) {
+	infos, err := t.sessions.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+	key := session.UserKey()
+	return slices.DeleteFunc(infos, func(info llm.SessionInfo) bool {
+		return !info.OwnedBy(key)
+	}), nil
+}
+
+// find returns an owned session by ID. Sessions of other users are reported
+// as missing, so their existence is not disclosed either.
+func (t *SessionTool) find(session api.SessionContext, sessionID string) (llm.SessionInfo, error) {
+	infos, err := t.owned(session)
+	if err != nil {
+		return llm.SessionInfo{}, err
+	}
+	idx := slices.IndexFunc(infos, func(info llm.SessionInfo) bool { return info.ID == sessionID })
+	if idx < 0 {
+		return llm.SessionInfo{}, fmt.Errorf("session not found: %s", sessionID)
+	}
+	return infos[idx], nil
+}
+
+func (t *SessionTool) list(session api.SessionContext) (*ToolResult, error) {
+	infos, err := t.owned(session)
+	if err != nil {
+		return nil, err
+	}
+	current := t.sessions.ActiveSession(fmt.Sprintf("%s_%s", session.ChannelID, session.ChatID))
+
+	table := &llm.Table{Headers: []string{"session_id", "messages", "updated", "summary"}}
+	for _, info := range infos {
+		id := info.ID
+		if id == current {
+			id += " (current)"
+		}
+		updated := ""
+		if !info.Updated.IsZero() {
+			updated = info.Updated.Format(time.DateTime)
+		}
+		table.Rows = append(table.Rows, []string{id, fmt.Sprint(info.Messages), updated, truncateRunes(info.Summary, 80)})
+	}
+
+	return &ToolResult{
+		Content: []ContentBlock{{Type: "table", Table: table}},
+		Details: map[string]any{"success": true, "count": len(infos)},
+	}, nil
+}
+
+func (t *SessionTool) summarize(info llm.SessionInfo) (*ToolResult, error) {
+	history, err := t.sessions.GetHistory(info.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	if summary := history.GetSummary(); summary != "" {
+		fmt.Fprintf(&sb, "Summary of earlier conversation:\n%s\n\n", summary)
+	}
+	msgs := history.GetMessages()
+	msgs = slices.DeleteFunc(msgs, func(m llm.Message) bool {
+		return m.Role == "system" || m.Role == "tool" || m.GetTextContent() == ""
+	})
+	msgs = msgs[max(len(msgs)-sessionTranscriptMessages, 0):]
+	if len(msgs) > 0 {
+		fmt.Fprintf(&sb, "Last %d messages:\n", len(msgs))
+		for _, m := range msgs {
+			fmt.Fprintf(&sb, "[%s] %s\n", m.Role, m.GetTextContent())
+		}
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("The session is empty.")
+	}
+
+	return &ToolResult{
+		Content: []ContentBlock{{Type: "text", Text: sb.String()}},
+		Details: map[string]any{"success": true, "session_id": info.ID},
+	}, nil
+}
+
+func (t *SessionTool) search(session api.SessionContext, query string) (*ToolResult, error) {
+	results, err := t.sessions.Search(session.UserKey(), query, sessionSearchResults)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &llm.Table{Headers: []string{"time", "session_id", "role", "snippet"}}
+	for _, r := range results {
+		table.Rows = append(table.Rows, []string{r.Time.Format(time.DateTime), r.SessionID, r.Role, r.Snippet})
+	}
+	return &ToolResult{
+		Content: []ContentBlock{{Type: "table", Table: table}},
+		Details: map[string]any{"success": true, "count": len(results)},
+	}, nil
+}
+
+func (t *SessionTool) switchTo(session api.SessionContext, info llm.SessionInfo) (*ToolResult, error) {
+	// A group chat shares one session; switching it would move everyone
+	if session.IsGroup {
+		return nil, fmt.Errorf("switching sessions is not available in group chats")
+	}
+	defaultID := fmt.Sprintf("%s_%s", session.ChannelID, session.ChatID)
+	t.sessions.SetActiveSession(defaultID, info.ID)
+
+	return &ToolResult{
+		Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("Switched to session %s. It takes effect with the next message.", info.ID)}},
+		Details: map[string]any{"success": true, "session_id": info.ID},
+	}, nil
+}
+
+// truncateRunes collapses whitespace in s and shortens it to at most n
+// runes, marking the cut with "...".
+func truncateRunes(s string, n int) string {
+	r := []rune(strings.Join(strings.Fields(s), " "))
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n]) + "..."
+}
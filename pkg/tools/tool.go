@@ -9,6 +9,7 @@ import (
 type Tool = api.Tool
 type ToolResult = api.ToolResult
 type ContentBlock = api.ContentBlock
+type Progress = api.Progress
 
 // ToolRegistry acts as a central inventory for all tools available to the Agent.
 type ToolRegistry struct {
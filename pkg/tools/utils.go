@@ -1,6 +1,10 @@
 package tools
 
-import "encoding/base64"
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+)
 
 // Base64Encode converts a byte slice to a Base64 string
 func Base64Encode(data []byte) string {
@@ -11,3 +15,31 @@ func Base64Encode(data []byte) string {
 func Base64Decode(s string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(s)
 }
+
+// ParsePID extracts a process ID from a tool parameter. JSON numbers arrive
+// as float64, but numeric strings are accepted too. Only positive integers
+// are valid.
+func ParsePID(v any) (int, error) {
+	var pid int
+	switch val := v.(type) {
+	case float64:
+		if val != float64(int(val)) {
+			return 0, fmt.Errorf("invalid 'pid' parameter: %v is not an integer", val)
+		}
+		pid = int(val)
+	case int:
+		pid = val
+	case string:
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return 0, fmt.Errorf("invalid 'pid' parameter: %q is not numeric", val)
+		}
+		pid = n
+	default:
+		return 0, fmt.Errorf("missing or invalid 'pid' parameter")
+	}
+	if pid <= 0 {
+		return 0, fmt.Errorf("invalid 'pid' parameter: %d", pid)
+	}
+	return pid, nil
+}
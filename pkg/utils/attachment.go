@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SaveAttachment stores data in dir under a name derived from its content,
+// so identical files share one copy. See SaveAttachmentFrom.
+func SaveAttachment(dir string, data []byte) (path, mimeType string, err error) {
+	return SaveAttachmentFrom(dir, bytes.NewReader(data))
+}
+
+// SaveAttachmentFrom streams r into dir and names the file after the
+// SHA-256 of its content plus the extension of its detected MIME type. If
+// a file with that name already exists the new copy is discarded and the
+// existing one's modification time is refreshed, which keeps age-based
+// expiry from removing content that is still being sent.
+func SaveAttachmentFrom(dir string, r io.Reader) (path, mimeType string, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".incoming-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create attachment file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	mimeType, ext := DetectFileMimeAndExt(tmp.Name())
+	path = filepath.Join(dir, hex.EncodeToString(hash.Sum(nil))+ext)
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return path, mimeType, nil
+	}
+	os.Chmod(tmp.Name(), 0644) // CreateTemp makes the file private
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", "", fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return path, mimeType, nil
+}
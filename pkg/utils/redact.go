@@ -0,0 +1,34 @@
+package utils
+
+import "regexp"
+
+// redactionRule pairs a detection pattern with its replacement placeholder.
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// redactionRules are applied in order; API keys run first so that their
+// digits are not partially matched by the phone/credit card patterns.
+var redactionRules = []redactionRule{
+	{regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_\-]{16,}\b`), "[REDACTED_KEY]"},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`), "[REDACTED_KEY]"},
+	{regexp.MustCompile(`\b(?:ghp|gho|ghs|ghu|github_pat)_[A-Za-z0-9_]{20,}\b`), "[REDACTED_KEY]"},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "[REDACTED_KEY]"},
+	{regexp.MustCompile(`\b\d{6,}:[A-Za-z0-9_\-]{30,}\b`), "[REDACTED_KEY]"}, // Telegram bot tokens
+	{regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._\-]{16,}`), "Bearer [REDACTED_KEY]"},
+	{regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), "[REDACTED_EMAIL]"},
+	{regexp.MustCompile(`\b(?:\d[ \-]?){13,19}\b`), "[REDACTED_CARD]"},
+	{regexp.MustCompile(`\+?\(?\d{1,4}\)?[ \-.]?\d{2,4}[ \-.]?\d{3,4}[ \-.]?\d{3,4}\b`), "[REDACTED_PHONE]"},
+}
+
+// RedactPII masks common personal data and secrets (API keys, emails,
+// credit card numbers, phone numbers) in the given text. It is intended for
+// log and monitor output only; the original text should still be used for
+// the actual LLM request.
+func RedactPII(s string) string {
+	for _, rule := range redactionRules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
@@ -0,0 +1,79 @@
+package utils
+
+import "sync/atomic"
+
+// Icon identifies a status marker placed at the start of user-facing status
+// strings. Every marker has an emoji form and an ASCII fallback for clients
+// and terminals that cannot render emoji.
+type Icon int
+
+const (
+	IconError     Icon = iota // ❌ / [ERROR]
+	IconWarn                  // ⚠️ / [WARN]
+	IconTool                  // 🛠️ / [TOOL]
+	IconOK                    // ✅ / [OK]
+	IconInfo                  // ℹ️ / [INFO]
+	IconThinking              // 💭 / [THINKING]
+	IconAssistant             // 🤖 / [AI]
+	IconChat                  // 💬 / [CHAT]
+)
+
+// iconForms holds the {emoji, ASCII} representation of each Icon.
+var iconForms = map[Icon][2]string{
+	IconError:     {"❌", "[ERROR]"},
+	IconWarn:      {"⚠️", "[WARN]"},
+	IconTool:      {"🛠️", "[TOOL]"},
+	IconOK:        {"✅", "[OK]"},
+	IconInfo:      {"ℹ️", "[INFO]"},
+	IconThinking:  {"💭", "[THINKING]"},
+	IconAssistant: {"🤖", "[AI]"},
+	IconChat:      {"💬", "[CHAT]"},
+}
+
+// asciiIcons is the process-wide switch selecting the ASCII fallback.
+// The zero value keeps emoji enabled.
+var asciiIcons atomic.Bool
+
+// SetUseEmoji selects between emoji (true) and ASCII (false) status markers.
+func SetUseEmoji(enabled bool) {
+	asciiIcons.Store(!enabled)
+}
+
+// UseEmoji reports whether emoji status markers are currently enabled.
+func UseEmoji() bool {
+	return !asciiIcons.Load()
+}
+
+// String returns the marker in the currently selected form, so an Icon can
+// be passed directly to fmt verbs such as %s.
+func (i Icon) String() string {
+	forms, ok := iconForms[i]
+	if !ok {
+		return ""
+	}
+	if asciiIcons.Load() {
+		return forms[1]
+	}
+	return forms[0]
+}
+
+// Emoji returns the emoji form of the marker regardless of the current setting.
+func (i Icon) Emoji() string {
+	return iconForms[i][0]
+}
+
+// ASCII returns the ASCII form of the marker regardless of the current setting.
+func (i Icon) ASCII() string {
+	return iconForms[i][1]
+}
+
+// ReasoningHeader introduces a reasoning transcript posted to a chat channel.
+func ReasoningHeader() string {
+	return IconThinking.String() + " Reasoning process:\n\n"
+}
+
+// AssistantHeader introduces the final answer when it follows a reasoning
+// transcript in a separate chat message.
+func AssistantHeader() string {
+	return IconAssistant.String() + " Assistant response:\n\n"
+}